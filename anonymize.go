@@ -0,0 +1,93 @@
+package dgocacheler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// anonymizedPrefix starts every hashed user ID, so that already anonymized messages are left as they are.
+const anonymizedPrefix = "anon:"
+
+// WithAnonymizedAuthors stores messages without user identities, for analytics-only deployments. On ingestion,
+// messages are cloned and every user ID is replaced with a salted hash: the author becomes a User carrying only
+// the hashed ID, mentions are reduced likewise, and user mentions in the content and embeds are rewritten to the
+// hashed IDs. The author's guild member data is dropped, and interaction users, thread owners and quoted replies
+// are anonymized the same way.
+//
+// Hashing is deterministic for a salt, so per-author features such as AuthorCounts keep working on the hashed
+// IDs; AnonymizedID maps a user ID to its hash for lookups like GetMessagesByAuthor. Hashed IDs start with
+// "anon:" and are never hashed again, so snapshots of an anonymized cache load unchanged; other snapshots are
+// anonymized on load. The salt is only held in memory and never written to snapshots.
+func WithAnonymizedAuthors(salt []byte) Option {
+	return func(c *MessageCache) {
+		c.anonymizeSalt = append([]byte{}, salt...)
+	}
+}
+
+// AnonymizedID returns the ID stored in place of userID when WithAnonymizedAuthors is set, and userID itself
+// otherwise.
+func (c *MessageCache) AnonymizedID(userID string) string {
+	if c.anonymizeSalt == nil {
+		return userID
+	}
+	return anonymizeID(c.anonymizeSalt, userID)
+}
+
+// anonymizeID hashes a user ID with salt. Empty and already hashed IDs are returned as they are.
+func anonymizeID(salt []byte, userID string) string {
+	if userID == "" || strings.HasPrefix(userID, anonymizedPrefix) {
+		return userID
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(userID))
+	return anonymizedPrefix + hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// anonymizeUser returns a User carrying only the hashed ID of user, or nil for a nil user.
+func anonymizeUser(salt []byte, user *discordgo.User) *discordgo.User {
+	if user == nil {
+		return nil
+	}
+	return &discordgo.User{ID: anonymizeID(salt, user.ID)}
+}
+
+// userMentionPattern matches user mentions in message text, capturing the user ID.
+var userMentionPattern = regexp.MustCompile(`<@!?(\d+)>`)
+
+// anonymizeMessage replaces the user identities in msg, which must be a clone made by cloneMessage.
+func anonymizeMessage(msg *discordgo.Message, salt []byte) {
+	msg.Author = anonymizeUser(salt, msg.Author)
+	for i, user := range msg.Mentions {
+		msg.Mentions[i] = anonymizeUser(salt, user)
+	}
+	msg.Member = nil
+	redactMessage(msg, func(text string) string {
+		return userMentionPattern.ReplaceAllStringFunc(text, func(mention string) string {
+			userID := userMentionPattern.FindStringSubmatch(mention)[1]
+			return "<@" + anonymizeID(salt, userID) + ">"
+		})
+	})
+	if msg.Interaction != nil {
+		interaction := *msg.Interaction
+		interaction.User = anonymizeUser(salt, interaction.User)
+		interaction.Member = nil
+		msg.Interaction = &interaction
+	}
+	if msg.Thread != nil {
+		thread := *msg.Thread
+		thread.OwnerID = anonymizeID(salt, thread.OwnerID)
+		thread.Recipients = nil
+		thread.Member = nil
+		thread.Members = nil
+		msg.Thread = &thread
+	}
+	if msg.ReferencedMessage != nil {
+		msg.ReferencedMessage = cloneMessage(msg.ReferencedMessage)
+		anonymizeMessage(msg.ReferencedMessage, salt)
+	}
+}
@@ -0,0 +1,105 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// identifiedMessage carries the user ID 4242 in every field that may identify a user.
+func identifiedMessage(id string) *discordgo.Message {
+	user := &discordgo.User{ID: "4242", Username: "alice", GlobalName: "Alice"}
+	return &discordgo.Message{
+		ID:          id,
+		GuildID:     "guild1",
+		Author:      user,
+		Content:     "ping <@4242> and <@!4242>",
+		Mentions:    []*discordgo.User{user},
+		Member:      &discordgo.Member{User: user, Nick: "ally"},
+		Embeds:      []*discordgo.MessageEmbed{{Description: "by <@4242>"}},
+		Interaction: &discordgo.MessageInteraction{User: user, Member: &discordgo.Member{User: user}},
+		Thread:      &discordgo.Channel{ID: "thread1", OwnerID: "4242", Recipients: []*discordgo.User{user}},
+		ReferencedMessage: &discordgo.Message{ID: "0", Author: user, Content: "<@4242>",
+			Member: &discordgo.Member{User: user}},
+	}
+}
+
+func TestAnonymizedAuthorsHideIdentities(t *testing.T) {
+	salt := []byte("pepper")
+	cache := NewMessageCache(10, WithAnonymizedAuthors(salt))
+	original := identifiedMessage("1")
+	cache.AddMessage("channel1", original)
+	cache.AddMessages("channel1", []*discordgo.Message{identifiedMessage("2")})
+	cache.UpdateMessage("channel1", identifiedMessage("2"))
+
+	if original.Author.ID != "4242" || original.Member == nil {
+		t.Error("Expected the caller's message to be left untouched")
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	stored, _ := json.Marshal(msgs)
+	var snapshot bytes.Buffer
+	cache.WriteTo(&snapshot)
+	for name, data := range map[string][]byte{"stored messages": stored, "snapshot": snapshot.Bytes()} {
+		for _, secret := range []string{"4242", "alice", "Alice", "ally", string(salt)} {
+			if bytes.Contains(data, []byte(secret)) {
+				t.Errorf("Expected %s not to contain %q", name, secret)
+			}
+		}
+	}
+
+	hashed := cache.AnonymizedID("4242")
+	if !strings.HasPrefix(hashed, "anon:") || msgs[0].Author.ID != hashed || msgs[0].Author.Username != "" {
+		t.Errorf("Expected the author to carry only the hashed ID %s, got %+v", hashed, msgs[0].Author)
+	}
+	if msgs[0].Content != "ping <@"+hashed+"> and <@"+hashed+">" {
+		t.Errorf("Expected content mentions to use the hashed ID, got %q", msgs[0].Content)
+	}
+	if byAuthor, _ := cache.GetMessagesByAuthor("channel1", hashed); len(byAuthor) != 2 {
+		t.Errorf("Expected per-author lookups to work on hashed IDs, got %d messages", len(byAuthor))
+	}
+
+	restored := NewMessageCache(10, WithAnonymizedAuthors(salt))
+	if _, err := restored.ReadFrom(&snapshot); err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	if got, _ := restored.GetMessagesByAuthor("channel1", hashed); len(got) != 2 {
+		t.Errorf("Expected hashed IDs to survive a snapshot unchanged, got %d messages", len(got))
+	}
+}
+
+func TestAnonymizedAuthorsAreDeterministic(t *testing.T) {
+	a := NewMessageCache(10, WithAnonymizedAuthors([]byte("salt")))
+	b := NewMessageCache(10, WithAnonymizedAuthors([]byte("salt")))
+	other := NewMessageCache(10, WithAnonymizedAuthors([]byte("other salt")))
+	if a.AnonymizedID("4242") != b.AnonymizedID("4242") {
+		t.Error("Expected the same salt to give the same hash")
+	}
+	if a.AnonymizedID("4242") == other.AnonymizedID("4242") {
+		t.Error("Expected different salts to give different hashes")
+	}
+	if got := NewMessageCache(10).AnonymizedID("4242"); got != "4242" {
+		t.Errorf("Expected IDs unchanged without anonymization, got %s", got)
+	}
+
+	seen := make(map[string]string)
+	for i := 0; i < 100_000; i++ {
+		userID := fmt.Sprint(80351110224678912 + i)
+		hashed := a.AnonymizedID(userID)
+		if previous, ok := seen[hashed]; ok {
+			t.Fatalf("Users %s and %s collide on %s", previous, userID, hashed)
+		}
+		seen[hashed] = userID
+	}
+}
+
+func TestForgetUserWithAnonymizedAuthors(t *testing.T) {
+	cache := NewMessageCache(10, WithAnonymizedAuthors([]byte("salt")))
+	cache.AddMessage("channel1", identifiedMessage("1"))
+	if removed, err := cache.ForgetUser("4242"); err != nil || removed != 1 {
+		t.Errorf("Expected ForgetUser to take the original ID, got %d, %v", removed, err)
+	}
+}
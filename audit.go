@@ -0,0 +1,136 @@
+package dgocacheler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// auditFlushInterval is how often buffered audit records are written out.
+const auditFlushInterval = time.Second
+
+// AuditRecord is one line of the audit log written by WithAuditLog. It identifies what was removed but never
+// carries message content.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`                 // Time is when the operation ran, from the cache clock
+	Op        string    `json:"op"`                   // Op names the operation, such as "RemoveMessage"
+	ChannelID string    `json:"channel_id,omitempty"` // ChannelID is the affected channel, empty for cache-wide sweeps
+	GuildID   string    `json:"guild_id,omitempty"`   // GuildID is the guild of the affected channel, when known
+	UserID    string    `json:"user_id,omitempty"`    // UserID is the author of the removed message, for single-message removals
+	MessageID string    `json:"message_id,omitempty"` // MessageID is the removed message, for single-message removals
+	Count     int       `json:"count"`                // Count is the number of messages removed
+}
+
+// WithAuditLog writes a JSON line to w for every operation that removes messages: RemoveMessage, ClearChannel,
+// DeleteChannel, DrainChannel, EvictOldest and KeepLast, capacity reductions by SetMaxMessages and friends, and
+// guild policies, with janitor sweeps summarized in one record each. Evictions making room for new messages are
+// not logged. Records are buffered and written on a background goroutine about once a second and on Close;
+// write errors go to the WithErrorHandler hook.
+func WithAuditLog(w io.Writer) Option {
+	return func(c *MessageCache) {
+		c.audit = &auditLog{w: bufio.NewWriter(w), stop: make(chan struct{}), done: make(chan struct{})}
+	}
+}
+
+// auditLog buffers audit records and writes them out periodically.
+type auditLog struct {
+	mu      sync.Mutex
+	pending []AuditRecord // pending holds the records not yet written
+	w       *bufio.Writer // w is only used by flush, under writeMu
+	writeMu sync.Mutex
+	stop    chan struct{} // stop is closed to end the goroutine
+	done    chan struct{} // done is closed once the goroutine returned
+	once    sync.Once
+}
+
+// start runs the goroutine writing the records out every auditFlushInterval, reporting errors to onError.
+func (a *auditLog) start(onError func(error)) {
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(auditFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := a.flush(); err != nil {
+					onError(err)
+				}
+			case <-a.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close stops the goroutine and writes out the remaining records. It is safe to call more than once.
+func (a *auditLog) close() error {
+	a.once.Do(func() { close(a.stop) })
+	<-a.done
+	return a.flush()
+}
+
+// add buffers a record.
+func (a *auditLog) add(record AuditRecord) {
+	a.mu.Lock()
+	a.pending = append(a.pending, record)
+	a.mu.Unlock()
+}
+
+// flush writes the buffered records.
+func (a *auditLog) flush() error {
+	a.mu.Lock()
+	records := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	enc := json.NewEncoder(a.w)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("dgocacheler: writing audit log: %w", err)
+		}
+	}
+	if err := a.w.Flush(); err != nil {
+		return fmt.Errorf("dgocacheler: writing audit log: %w", err)
+	}
+	return nil
+}
+
+// auditRemoval records that op removed count messages from a channel, if an audit log is configured and
+// anything was removed. The caller must hold the channel lock.
+func (c *MessageCache) auditRemoval(op string, cc *ChannelCache, count int) {
+	if c.audit == nil || count <= 0 {
+		return
+	}
+	c.audit.add(AuditRecord{Time: c.now(), Op: op, ChannelID: cc.id, GuildID: cc.guildID, Count: count})
+}
+
+// auditMessage records that op removed a single message from a channel, if an audit log is configured. The
+// caller must hold the channel lock.
+func (c *MessageCache) auditMessage(op string, cc *ChannelCache, entry cachedMessage) {
+	if c.audit == nil {
+		return
+	}
+	c.audit.add(AuditRecord{
+		Time:      c.now(),
+		Op:        op,
+		ChannelID: cc.id,
+		GuildID:   cc.guildID,
+		UserID:    messageAuthorID(entry.message),
+		MessageID: entry.message.ID,
+		Count:     1,
+	})
+}
+
+// auditSweep records a cache-wide operation that removed count messages, if an audit log is configured and
+// anything was removed.
+func (c *MessageCache) auditSweep(op string, count int) {
+	if c.audit == nil || count <= 0 {
+		return
+	}
+	c.audit.add(AuditRecord{Time: c.now(), Op: op, Count: count})
+}
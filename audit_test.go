@@ -0,0 +1,111 @@
+package dgocacheler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAuditLog(t *testing.T) {
+	var out bytes.Buffer
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cache := NewMessageCache(10, WithAuditLog(&out), WithClock(clock.Now))
+	history := func() []*discordgo.Message {
+		msgs := testHistory(5)
+		for _, msg := range msgs {
+			msg.Author = &discordgo.User{ID: "user1"}
+			msg.Content = "secret"
+		}
+		return msgs
+	}
+	for _, channelID := range []string{"remove", "clear", "delete", "drain", "evict", "keep", "shrink", "resize"} {
+		cache.AddMessages(channelID, history())
+	}
+	cache.AddMessages("guild", guildHistory("G", 3, clock.now))
+
+	cache.RemoveMessage("remove", "102")
+	cache.ClearChannel("clear")
+	cache.DeleteChannel("delete")
+	cache.DrainChannel("drain")
+	cache.EvictOldest("evict", 2)
+	cache.KeepLast("keep", 1)
+	cache.SetChannelMaxMessages("resize", 2)
+	cache.SetMaxMessages(3)
+	cache.SetGuildPolicy("G", GuildPolicy{Enabled: false})
+	cache.SetGuildPolicy("G", GuildPolicy{Enabled: true, MaxAge: time.Minute})
+	cache.AddMessages("guild", guildHistory("G", 1, clock.now))
+	clock.Advance(time.Hour)
+	cache.RunJanitor()
+	cache.RemoveMessage("remove", "missing") // removes nothing, so no record
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if strings.Contains(out.String(), "secret") {
+		t.Error("The audit log must never contain message content.")
+	}
+	var records []AuditRecord
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Unparseable audit record %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	got := make([]string, len(records))
+	for i, r := range records {
+		got[i] = fmt.Sprintf("%s %s %s %d", r.Op, r.ChannelID, r.GuildID, r.Count)
+	}
+	want := []string{
+		"RemoveMessage remove  1",
+		"ClearChannel clear  5",
+		"DeleteChannel delete  5",
+		"DrainChannel drain  5",
+		"EvictOldest evict  2",
+		"KeepLast keep  4",
+		"SetChannelMaxMessages resize  3",
+		"SetGuildPolicy guild G 3",
+		"RunJanitor   1",
+	}
+	var shrinks, others []string
+	for _, line := range got {
+		if strings.HasPrefix(line, "SetMaxMessages") {
+			shrinks = append(shrinks, line)
+		} else {
+			others = append(others, line)
+		}
+	}
+	if fmt.Sprint(others) != fmt.Sprint(want) {
+		t.Errorf("Unexpected audit records:\n got %q\nwant %q", others, want)
+	}
+	slices.Sort(shrinks)
+	if fmt.Sprint(shrinks) != "[SetMaxMessages remove  1 SetMaxMessages shrink  2]" {
+		t.Errorf("Expected one SetMaxMessages record per shrunk channel, got %q", shrinks)
+	}
+	if records[0].UserID != "user1" || records[0].MessageID != "102" || !records[0].Time.Equal(time.Unix(1_700_000_000, 0)) {
+		t.Errorf("Unexpected RemoveMessage record %+v", records[0])
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestAuditLogWriteError(t *testing.T) {
+	cache := NewMessageCache(10, WithAuditLog(failingWriter{}))
+	cache.AddMessages("channel1", testHistory(3))
+	cache.ClearChannel("channel1")
+	if err := cache.Close(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected Close to return the write error, got %v", err)
+	}
+}
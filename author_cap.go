@@ -0,0 +1,68 @@
+package dgocacheler
+
+import (
+	"maps"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// WithPerAuthorCap limits how many messages one author can have cached in a channel, so a single chatty user
+// cannot push everyone else's messages out. When an add would give an author more than n messages, that
+// author's oldest message is evicted with EvictAuthorCap instead of the channel's oldest. Messages without an
+// author are not limited. n <= 0 disables the cap.
+func WithPerAuthorCap(n int) Option {
+	return func(c *MessageCache) {
+		c.perAuthorCap = max(n, 0)
+	}
+}
+
+// entryAuthor returns the author key stored with an entry, which is only tracked when a per-author cap is set.
+func (c *MessageCache) entryAuthor(message *discordgo.Message) string {
+	if c.perAuthorCap <= 0 {
+		return ""
+	}
+	return c.authorKey(message)
+}
+
+// enforceAuthorCap evicts the oldest messages of author until one more fits under the per-author cap.
+// The caller must hold the channel's write lock.
+func (c *MessageCache) enforceAuthorCap(cc *ChannelCache, author string) {
+	if c.perAuthorCap <= 0 || author == "" {
+		return
+	}
+	for i := 0; i < cc.size && cc.authorCounts[author] >= c.perAuthorCap; {
+		if cc.at(i).author == author {
+			cc.evictAt(i, EvictAuthorCap)
+			continue
+		}
+		i++
+	}
+}
+
+// capAuthors drops the oldest of entries, sorted by ID, of every author over the per-author cap, as
+// enforceAuthorCap would while adding them in order. cached holds the messages per author that already count
+// against the cap because they are newer than every entry, as for prepended history; it may be nil.
+func (c *MessageCache) capAuthors(entries []cachedMessage, cached map[string]int) []cachedMessage {
+	if c.perAuthorCap <= 0 {
+		return entries
+	}
+	counts := maps.Clone(cached)
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	keep := make([]bool, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		author := entries[i].author
+		if author == "" || counts[author] < c.perAuthorCap {
+			counts[author]++
+			keep[i] = true
+		}
+	}
+	kept := entries[:0]
+	for i, entry := range entries {
+		if keep[i] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,49 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPerAuthorCap(t *testing.T) {
+	var evictions []string
+	cache := NewMessageCache(10, WithPerAuthorCap(3), OnEvict(func(channelID string, msg *discordgo.Message, reason EvictReason) {
+		evictions = append(evictions, fmt.Sprintf("%s:%s", msg.ID, reason))
+	}))
+	cache.AddMessage("channel1", authoredMessage("1", "quiet", "hello"))
+	for i := 0; i < 20; i++ {
+		cache.AddMessage("channel1", authoredMessage(fmt.Sprint(100+i), "chatty", fmt.Sprint("spam ", i)))
+		if i == 10 {
+			cache.AddMessage("channel1", authoredMessage("2", "quiet", "still here"))
+		}
+	}
+
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[1 2 117 118 119]" {
+		t.Errorf("Expected the quiet author's messages to survive, got %v", got)
+	}
+	if len(evictions) != 17 || evictions[0] != "100:author_cap" {
+		t.Errorf("Expected 17 author cap evictions, got %v", evictions)
+	}
+	counts, _ := cache.AuthorCounts("channel1")
+	if counts["chatty"] != 3 || counts["quiet"] != 2 {
+		t.Errorf("Unexpected author counts: %v", counts)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestOnEvictCapacity(t *testing.T) {
+	var reasons []EvictReason
+	cache := NewMessageCache(3, WithPerAuthorCap(0), OnEvict(func(channelID string, msg *discordgo.Message, reason EvictReason) {
+		reasons = append(reasons, reason)
+	}))
+	cache.AddMessages("channel1", testHistory(5))
+	cache.SetMaxMessages(1)
+	if fmt.Sprint(reasons) != "[capacity capacity capacity capacity]" {
+		t.Errorf("Expected four capacity evictions, got %v", reasons)
+	}
+}
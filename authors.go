@@ -0,0 +1,153 @@
+package dgocacheler
+
+import (
+	"maps"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AuthorCounts returns how many cached messages each author has in a channel, keyed by author ID.
+// Messages without an author are counted under the empty string. The returned map is owned by the caller.
+// It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) AuthorCounts(channelID string) (map[string]int, error) {
+	return c.authorCounts(channelID, time.Time{})
+}
+
+// AuthorCountsSince is like AuthorCounts but only counts messages sent after since.
+func (c *MessageCache) AuthorCountsSince(channelID string, since time.Time) (map[string]int, error) {
+	return c.authorCounts(channelID, since)
+}
+
+// authorCounts counts the messages per author sent after since in a single pass over the channel.
+func (c *MessageCache) authorCounts(channelID string, since time.Time) (map[string]int, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	if since.IsZero() && cc.authorCounts != nil {
+		return maps.Clone(cc.authorCounts), nil
+	}
+	counts := make(map[string]int)
+	for i := 0; i < cc.size; i++ {
+		msg := cc.at(i).message
+		if !since.IsZero() && !messageTime(msg).After(since) {
+			continue
+		}
+		counts[c.authorKey(msg)]++
+	}
+	return counts, nil
+}
+
+// AuthorActivity returns the send times of the first and last cached messages of an author in a channel,
+// keyed by the author's user ID, for "active from X to Y" features. The range is kept up to date as messages
+// are added; removals and evictions only mark it stale, and it is recomputed from the cached messages on the
+// next call. Messages without a known send time are not counted in the range. It returns ErrCacheMiss for
+// unknown channels and ErrMessageNotFound if the author has no cached messages in the channel.
+func (c *MessageCache) AuthorActivity(channelID, authorID string) (first, last time.Time, err error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return time.Time{}, time.Time{}, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	a, ok := cc.activity[authorID]
+	stale := ok && a.stale
+	if ok {
+		first, last = a.first, a.last
+	}
+	cc.RUnlock()
+	switch {
+	case !ok:
+		return time.Time{}, time.Time{}, ErrMessageNotFound
+	case !stale:
+		return first, last, nil
+	}
+
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if a, ok = cc.activity[authorID]; !ok {
+		return time.Time{}, time.Time{}, ErrMessageNotFound
+	}
+	if a.stale {
+		cc.refreshActivity(authorID, a)
+	}
+	return a.first, a.last, nil
+}
+
+// authorActivity is the range of send times of an author's cached messages in a channel.
+type authorActivity struct {
+	count int       // count is the number of cached messages by the author
+	first time.Time // first is the earliest send time, zero if none is known
+	last  time.Time // last is the latest send time, zero if none is known
+	stale bool      // stale is set when a message at either end of the range was removed
+}
+
+// trackActivity adds (sign 1) or removes (sign -1) a message from the activity of its author. The caller must
+// hold the write lock.
+func (cc *ChannelCache) trackActivity(msg *discordgo.Message, sign int) {
+	author := messageAuthorID(msg)
+	if author == "" {
+		return
+	}
+	a := cc.activity[author]
+	t := messageTime(msg)
+	if sign < 0 {
+		if a == nil {
+			return
+		}
+		if a.count--; a.count == 0 {
+			delete(cc.activity, author)
+		} else if !t.IsZero() && (t.Equal(a.first) || t.Equal(a.last)) {
+			a.stale = true
+		}
+		return
+	}
+	if a == nil {
+		if cc.activity == nil {
+			cc.activity = make(map[string]*authorActivity)
+		}
+		a = &authorActivity{}
+		cc.activity[author] = a
+	}
+	a.count++
+	a.include(t)
+}
+
+// include widens the range to cover t, unless t is unknown.
+func (a *authorActivity) include(t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	if a.first.IsZero() || t.Before(a.first) {
+		a.first = t
+	}
+	if t.After(a.last) {
+		a.last = t
+	}
+}
+
+// refreshActivity recomputes the range of an author's activity from the cached messages. The caller must hold
+// the write lock.
+func (cc *ChannelCache) refreshActivity(author string, a *authorActivity) {
+	a.first, a.last, a.stale = time.Time{}, time.Time{}, false
+	for i := 0; i < cc.size; i++ {
+		if msg := cc.at(i).message; messageAuthorID(msg) == author {
+			a.include(messageTime(msg))
+		}
+	}
+}
+
+// messageTime returns when a message was sent: its Timestamp when set, otherwise the time encoded in its
+// snowflake ID, or the zero time when neither is available.
+func messageTime(msg *discordgo.Message) time.Time {
+	if !msg.Timestamp.IsZero() {
+		return msg.Timestamp
+	}
+	t, err := discordgo.SnowflakeTimestamp(msg.ID)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
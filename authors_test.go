@@ -0,0 +1,112 @@
+package dgocacheler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAuthorCounts(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", []*discordgo.Message{
+		authoredMessage("1", "alice", "hi"),
+		authoredMessage("2", "bob", "hey"),
+		authoredMessage("3", "alice", "how are you"),
+		{ID: "4", Content: "no author"},
+	})
+
+	counts, err := cache.AuthorCounts("channel1")
+	if err != nil {
+		t.Fatalf("AuthorCounts returned error: %v", err)
+	}
+	if len(counts) != 3 || counts["alice"] != 2 || counts["bob"] != 1 || counts[""] != 1 {
+		t.Errorf("Unexpected counts: %v", counts)
+	}
+
+	// The map belongs to the caller.
+	counts["alice"] = 100
+	if again, _ := cache.AuthorCounts("channel1"); again["alice"] != 2 {
+		t.Error("AuthorCounts should return a fresh map on every call.")
+	}
+
+	if _, err := cache.AuthorCounts("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestAuthorCountsSince(t *testing.T) {
+	now := time.Now()
+	old := authoredMessage("1", "alice", "old")
+	old.Timestamp = now.Add(-2 * time.Hour)
+	recent := authoredMessage("2", "bob", "recent")
+	recent.Timestamp = now.Add(-10 * time.Minute)
+	latest := authoredMessage("3", "bob", "latest")
+	latest.Timestamp = now
+
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", []*discordgo.Message{old, recent, latest})
+
+	counts, err := cache.AuthorCountsSince("channel1", now.Add(-time.Hour))
+	if err != nil || len(counts) != 1 || counts["bob"] != 2 {
+		t.Errorf("Unexpected counts for the last hour: %v (err %v)", counts, err)
+	}
+}
+
+func TestMessageTime(t *testing.T) {
+	stamp := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := messageTime(&discordgo.Message{ID: "1", Timestamp: stamp}); !got.Equal(stamp) {
+		t.Errorf("Expected the message timestamp, got %v", got)
+	}
+	// 175928847299117063 is the example snowflake from the Discord documentation.
+	want := time.Date(2016, 4, 30, 11, 18, 25, 796000000, time.UTC)
+	if got := messageTime(&discordgo.Message{ID: "175928847299117063"}); !got.Equal(want) {
+		t.Errorf("Expected the snowflake time %v, got %v", want, got)
+	}
+	if got := messageTime(&discordgo.Message{ID: "not-a-snowflake"}); !got.IsZero() {
+		t.Errorf("Expected the zero time, got %v", got)
+	}
+}
+
+func TestAuthorActivity(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(id, author string, minutes int) *discordgo.Message {
+		msg := authoredMessage(id, author, "hi")
+		msg.Timestamp = base.Add(time.Duration(minutes) * time.Minute)
+		return msg
+	}
+	cache := NewMessageCache(3)
+	cache.AddMessage("channel1", at("1", "alice", 0))
+	cache.AddMessage("channel1", at("2", "bob", 5))
+	cache.AddMessage("channel1", at("3", "alice", 10))
+
+	first, last, err := cache.AuthorActivity("channel1", "alice")
+	if err != nil || !first.Equal(base) || !last.Equal(base.Add(10*time.Minute)) {
+		t.Errorf("Expected alice active from 0 to 10 minutes, got %v to %v, %v", first, last, err)
+	}
+
+	// Evicting alice's first message narrows her range; a later add widens it again.
+	cache.AddMessage("channel1", at("4", "alice", 20))
+	first, last, _ = cache.AuthorActivity("channel1", "alice")
+	if !first.Equal(base.Add(10*time.Minute)) || !last.Equal(base.Add(20*time.Minute)) {
+		t.Errorf("Expected alice active from 10 to 20 minutes after the eviction, got %v to %v", first, last)
+	}
+	cache.RemoveMessage("channel1", "4")
+	if _, last, _ = cache.AuthorActivity("channel1", "alice"); !last.Equal(base.Add(10 * time.Minute)) {
+		t.Errorf("Expected removing the last message to narrow the range, got %v", last)
+	}
+
+	cache.AddMessage("channel1", at("5", "carol", 30))
+	cache.AddMessage("channel1", at("6", "carol", 40))
+	if _, _, err := cache.AuthorActivity("channel1", "bob"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound once bob's messages are evicted, got %v", err)
+	}
+	cache.ClearChannel("channel1")
+	if _, _, err := cache.AuthorActivity("channel1", "alice"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound after clearing, got %v", err)
+	}
+	if _, _, err := cache.AuthorActivity("unknown", "alice"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss for unknown channels, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// messageFetcher fetches pages of channel messages. *discordgo.Session implements it; tests use fakes.
+type messageFetcher interface {
+	ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error)
+}
+
+// BackfillResult describes the backfill of one channel.
+type BackfillResult struct {
+	Fetched int    // Fetched is the number of messages fetched from Discord
+	Added   int    // Added is the number of fetched messages stored, the others being cached already or filtered
+	Cursor  string // Cursor is the ID of the oldest message fetched, to continue from with BackfillFrom
+	Err     error  // Err is the error the backfill ended with, nil if it completed
+}
+
+// BackfillOptions paces the requests of a backfill. The zero value fetches as fast as Discord allows.
+type BackfillOptions struct {
+	MaxPagesPerChannel int           // MaxPagesPerChannel caps the pages fetched per channel, no cap if not positive
+	PageDelay          time.Duration // PageDelay is waited between the pages of a channel
+	Budget             *RateBudget   // Budget is drawn from before every page, shared by all channels and callers using it
+}
+
+// Backfill fetches up to n of the newest messages of a channel through the session, paging backwards 100
+// messages at a time, and stores them oldest first through the AddMessages batch path, so messages already
+// cached are skipped. Messages fetched before a failing page are still stored. The result's Cursor continues
+// the walk with BackfillFrom. It returns ErrInvalidLimit if n is not positive.
+func (c *MessageCache) Backfill(ctx context.Context, s *discordgo.Session, channelID string, n int) (BackfillResult, error) {
+	return c.BackfillWithOptions(ctx, s, channelID, n, BackfillOptions{})
+}
+
+// BackfillWithOptions is Backfill paced by opts. Waiting for the budget or the page delay ends the backfill
+// with ctx's error when ctx is done first.
+func (c *MessageCache) BackfillWithOptions(ctx context.Context, s *discordgo.Session, channelID string, n int, opts BackfillOptions) (BackfillResult, error) {
+	if n <= 0 {
+		return BackfillResult{}, ErrInvalidLimit
+	}
+	result := c.backfill(ctx, s, channelID, n, opts)
+	return result, result.Err
+}
+
+// BackfillMany runs Backfill for every listed channel on a pool of workers goroutines, since Discord rate
+// limits each channel's route separately. The result map has an entry for every channel. A channel the bot
+// may not read only records its permission error; other failures are also returned as *ChannelError values
+// joined with errors.Join. Cancelling ctx aborts the running fetches and stops channels from being started;
+// those never started record ctx's error, and BackfillMany returns ctx.Err(). It returns ErrInvalidLimit if
+// perChannel or workers is not positive.
+func (c *MessageCache) BackfillMany(ctx context.Context, s *discordgo.Session, channelIDs []string, perChannel int, workers int) (map[string]BackfillResult, error) {
+	return c.BackfillManyWithOptions(ctx, s, channelIDs, perChannel, workers, BackfillOptions{})
+}
+
+// BackfillManyWithOptions is BackfillMany paced by opts. A Budget caps the request rate of all workers
+// together; MaxPagesPerChannel and PageDelay apply to each channel.
+func (c *MessageCache) BackfillManyWithOptions(ctx context.Context, s *discordgo.Session, channelIDs []string, perChannel int, workers int, opts BackfillOptions) (map[string]BackfillResult, error) {
+	if perChannel <= 0 || workers <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	return c.backfillMany(ctx, s, channelIDs, perChannel, workers, opts)
+}
+
+// backfillMany implements BackfillMany with any fetcher.
+func (c *MessageCache) backfillMany(ctx context.Context, f messageFetcher, channelIDs []string, perChannel int, workers int, opts BackfillOptions) (map[string]BackfillResult, error) {
+	channelIDs = slices.Compact(slices.Sorted(slices.Values(channelIDs)))
+	results := make(map[string]BackfillResult, len(channelIDs))
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	jobs := make(chan string)
+	for range min(workers, len(channelIDs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for channelID := range jobs {
+				result := BackfillResult{Err: ctx.Err()}
+				if result.Err == nil {
+					result = c.backfill(ctx, f, channelID, perChannel, opts)
+				}
+				mu.Lock()
+				results[channelID] = result
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, channelID := range channelIDs {
+		select {
+		case jobs <- channelID:
+			continue
+		case <-ctx.Done():
+		}
+		mu.Lock()
+		results[channelID] = BackfillResult{Err: ctx.Err()}
+		mu.Unlock()
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+	failed := make(map[string]error)
+	for channelID, result := range results {
+		if result.Err != nil && !isMissingPermissions(result.Err) {
+			failed[channelID] = result.Err
+		}
+	}
+	return results, joinChannelErrors(failed)
+}
+
+// BackfillFrom continues a backfill of a channel from a cursor, fetching up to n messages older than it and
+// storing them at the old end of the channel through PrependMessages, so that a backfill interrupted by a
+// restart resumes where it stopped. An empty cursor starts from the newest message. newCursor is the ID of the
+// oldest message fetched, to pass to the next call; it is cursor itself once no older messages are left.
+// Cursors are message IDs, plain strings that can be persisted anywhere. If Discord rejects the cursor because
+// its message was deleted, paging continues from the cursor's timestamp instead. Messages fetched before a
+// failing page are still stored and counted in newCursor. It returns ErrInvalidLimit if n is not positive and
+// ErrInvalidCursor if cursor is not a message ID.
+func (c *MessageCache) BackfillFrom(ctx context.Context, s *discordgo.Session, channelID, cursor string, n int) (newCursor string, added int, err error) {
+	if n <= 0 {
+		return cursor, 0, ErrInvalidLimit
+	}
+	if _, err := cursorTimestamp(cursor); err != nil {
+		return cursor, 0, err
+	}
+	result := c.backfillFrom(ctx, s, channelID, cursor, n, BackfillOptions{}, true)
+	return result.Cursor, result.Added, result.Err
+}
+
+// backfill fetches up to n of the newest messages of a channel, paced by opts, and stores them.
+func (c *MessageCache) backfill(ctx context.Context, f messageFetcher, channelID string, n int, opts BackfillOptions) BackfillResult {
+	return c.backfillFrom(ctx, f, channelID, "", n, opts, false)
+}
+
+// backfillFrom fetches up to n messages of a channel older than cursor, paced by opts, and stores them, at the
+// old end of the channel with prepend.
+func (c *MessageCache) backfillFrom(ctx context.Context, f messageFetcher, channelID, cursor string, n int, opts BackfillOptions, prepend bool) (result BackfillResult) {
+	op := "Backfill"
+	if prepend {
+		op = "BackfillFrom"
+	}
+	ctx, span := c.startSpan(ctx, op)
+	span.SetAttribute(AttrChannelID, channelID)
+	defer func() {
+		span.SetAttribute(AttrResultCount, result.Added)
+		endSpan(span, result.Err)
+	}()
+
+	c.profileOp(ctx, "backfill", channelID, func(ctx context.Context) {
+		result = c.fetchBackfill(ctx, f, channelID, cursor, n, opts, prepend, op)
+	})
+	return result
+}
+
+// fetchBackfill implements backfillFrom. source names the public method backfilling, for diagnostics.
+func (c *MessageCache) fetchBackfill(ctx context.Context, f messageFetcher, channelID, cursor string, n int, opts BackfillOptions, prepend bool, source string) (result BackfillResult) {
+	var fetched []*discordgo.Message
+	beforeID := cursor
+	result.Cursor = cursor
+	for pages := 0; len(fetched) < n; pages++ {
+		if opts.MaxPagesPerChannel > 0 && pages == opts.MaxPagesPerChannel {
+			break
+		}
+		if err := opts.pace(ctx, pages); err != nil {
+			result.Err = err
+			break
+		}
+		limit := min(n-len(fetched), discordMaxMessagesPerRequest)
+		page, err := f.ChannelMessages(channelID, limit, beforeID, "", "", discordgo.WithContext(ctx))
+		if isUnknownMessage(err) && beforeID == cursor && cursor != "" {
+			// The anchor was deleted; page from its timestamp instead.
+			beforeID, err = timestampCursor(cursor)
+			if err == nil {
+				page, err = f.ChannelMessages(channelID, limit, beforeID, "", "", discordgo.WithContext(ctx))
+			}
+		}
+		if err != nil {
+			result.Err = err
+			break
+		}
+		fetched = append(fetched, page...)
+		if len(page) < limit {
+			break
+		}
+		beforeID = page[len(page)-1].ID
+	}
+	result.Fetched = len(fetched)
+	if len(fetched) == 0 {
+		return result
+	}
+	result.Cursor = fetched[len(fetched)-1].ID
+	slices.Reverse(fetched)
+	var report BatchReport
+	var err error
+	if prepend {
+		report, err = c.prependBatch(channelID, fetched, source)
+	} else {
+		report, err = c.addBatch(channelID, fetched, source)
+	}
+	result.Added = report.Added
+	if result.Err == nil {
+		result.Err = err
+	}
+	return result
+}
+
+// pace waits before fetching a channel's page with the given index: the page delay between pages, then a
+// budget token.
+func (o BackfillOptions) pace(ctx context.Context, page int) error {
+	if page > 0 && o.PageDelay > 0 {
+		if err := sleepContext(ctx, o.PageDelay); err != nil {
+			return err
+		}
+	}
+	return o.Budget.Wait(ctx)
+}
+
+// cursorTimestamp returns the creation time of a cursor's message in milliseconds since the Discord epoch, or
+// ErrInvalidCursor if cursor is neither empty nor a snowflake.
+func cursorTimestamp(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(cursor, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return id >> 22, nil
+}
+
+// timestampCursor returns the lowest snowflake created after the millisecond of the cursor's message, to page
+// before in place of a deleted cursor message. Messages created in the same millisecond are fetched again and
+// skipped as duplicates.
+func timestampCursor(cursor string) (string, error) {
+	ms, err := cursorTimestamp(cursor)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint((ms+1)<<22, 10), nil
+}
+
+// isUnknownMessage reports whether err is Discord rejecting a request because a message does not exist.
+func isUnknownMessage(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage
+}
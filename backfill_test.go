@@ -0,0 +1,211 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeFetcher serves channel histories of consecutive message IDs, newest first like the Discord API.
+type fakeFetcher struct {
+	mu      sync.Mutex
+	history map[string]int   // history maps channel IDs to their number of messages, IDs 1000 upwards
+	errs    map[string]error // errs maps channel IDs to the error fetching them returns
+	calls   []string         // calls records the requests made
+	running atomic.Int32
+	peak    atomic.Int32
+	fetch   func(channelID string) // fetch is called at the start of every request when set
+	shift   int                    // shift spaces the IDs out, the message numbered i having ID i<<shift
+	deleted map[string]bool        // deleted holds the message IDs rejected as unknown when paging before them
+}
+
+func (f *fakeFetcher) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	n := f.running.Add(1)
+	defer f.running.Add(-1)
+	for p := f.peak.Load(); n > p && !f.peak.CompareAndSwap(p, n); p = f.peak.Load() {
+	}
+	if f.fetch != nil {
+		f.fetch(channelID)
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, fmt.Sprintf("%s/%d/%s", channelID, limit, beforeID))
+	f.mu.Unlock()
+	if err := f.errs[channelID]; err != nil {
+		return nil, err
+	}
+	if f.deleted[beforeID] {
+		return nil, &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeUnknownMessage}}
+	}
+	before := uint64(math.MaxUint64)
+	if beforeID != "" {
+		before, _ = strconv.ParseUint(beforeID, 10, 64)
+	}
+	var page []*discordgo.Message
+	for i := 1000 + f.history[channelID] - 1; i >= 1000 && len(page) < limit; i-- {
+		if id := uint64(i) << f.shift; id < before && !f.deleted[strconv.FormatUint(id, 10)] {
+			page = append(page, &discordgo.Message{ID: strconv.FormatUint(id, 10), ChannelID: channelID})
+		}
+	}
+	return page, nil
+}
+
+func TestBackfill(t *testing.T) {
+	f := &fakeFetcher{history: map[string]int{"channel1": 250}}
+	cache := NewMessageCache(300)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1249"})
+
+	result := cache.backfill(context.Background(), f, "channel1", 230, BackfillOptions{})
+	if result.Err != nil || result.Fetched != 230 || result.Added != 229 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if fmt.Sprint(f.calls) != "[channel1/100/ channel1/100/1150 channel1/30/1050]" {
+		t.Errorf("Unexpected requests: %v", f.calls)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if len(msgs) != 230 || msgs[1].ID != "1020" || msgs[len(msgs)-1].ID != "1248" {
+		t.Errorf("Expected the fetched messages stored oldest first, got %v", messageIDs(msgs))
+	}
+
+	f = &fakeFetcher{history: map[string]int{"channel2": 20}}
+	if result := cache.backfill(context.Background(), f, "channel2", 50, BackfillOptions{}); result.Fetched != 20 || len(f.calls) != 1 {
+		t.Errorf("Expected a short page to end the backfill, got %+v after %v", result, f.calls)
+	}
+	if _, err := cache.Backfill(context.Background(), nil, "channel1", 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+}
+
+func TestBackfillMany(t *testing.T) {
+	forbidden := &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeMissingAccess}}
+	failure := errors.New("unavailable")
+	f := &fakeFetcher{
+		history: map[string]int{"a": 5, "b": 150, "c": 2, "d": 7},
+		errs:    map[string]error{"secret": forbidden, "broken": failure},
+	}
+	cache := NewMessageCache(100)
+
+	results, err := cache.backfillMany(context.Background(), f, []string{"a", "b", "secret", "broken", "c", "d", "a"}, 120, 2, BackfillOptions{})
+	var channelErr *ChannelError
+	if !errors.Is(err, failure) || !errors.As(err, &channelErr) || channelErr.ChannelID != "broken" {
+		t.Errorf("Expected the non-permission failure to be returned for its channel, got %v", err)
+	}
+	if len(results) != 6 || results["a"].Fetched != 5 || results["b"].Fetched != 120 || results["b"].Added != 120 {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+	if !errors.Is(results["secret"].Err, forbidden) || !errors.Is(results["broken"].Err, failure) {
+		t.Errorf("Expected the failures recorded per channel, got %+v", results)
+	}
+	if n, _ := cache.MessageCount("b"); n != 100 {
+		t.Errorf("Expected the channel filled to capacity, got %d messages", n)
+	}
+	if f.peak.Load() > 2 {
+		t.Errorf("Expected at most 2 concurrent fetches, got %d", f.peak.Load())
+	}
+	if _, err := cache.BackfillMany(context.Background(), nil, []string{"a"}, 10, 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+}
+
+func TestBackfillManyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := &fakeFetcher{history: map[string]int{"a": 5, "b": 5, "c": 5}}
+	f.fetch = func(channelID string) {
+		if channelID == "a" {
+			cancel()
+		}
+	}
+	cache := NewMessageCache(10)
+
+	results, err := cache.backfillMany(ctx, f, []string{"c", "b", "a"}, 5, 1, BackfillOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if results["a"].Fetched != 5 {
+		t.Errorf("Expected the running backfill to finish its page, got %+v", results["a"])
+	}
+	for _, channelID := range []string{"b", "c"} {
+		if !errors.Is(results[channelID].Err, context.Canceled) || results[channelID].Fetched != 0 {
+			t.Errorf("Expected channel %s not to be started, got %+v", channelID, results[channelID])
+		}
+	}
+	if len(f.calls) != 1 {
+		t.Errorf("Expected a single request, got %v", f.calls)
+	}
+}
+
+func TestBackfillOptions(t *testing.T) {
+	cache := NewMessageCache(500)
+	f := &fakeFetcher{history: map[string]int{"a": 250, "b": 250}}
+	result := cache.backfill(context.Background(), f, "a", 250, BackfillOptions{MaxPagesPerChannel: 2})
+	if result.Err != nil || result.Fetched != 200 || len(f.calls) != 2 {
+		t.Errorf("Expected 2 pages, got %+v after %v", result, f.calls)
+	}
+
+	var slept []time.Duration
+	budget, _ := fakeBudget(2, 1, &slept)
+	f = &fakeFetcher{history: map[string]int{"a": 150, "b": 150}}
+	cache.backfillMany(context.Background(), f, []string{"a", "b"}, 150, 1, BackfillOptions{Budget: budget})
+	if len(f.calls) != 4 || fmt.Sprint(slept) != "[500ms 500ms 500ms]" {
+		t.Errorf("Expected every page after the first to wait for the shared budget, got %v after %v", slept, f.calls)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	f = &fakeFetcher{history: map[string]int{"c": 250}}
+	result = cache.backfill(ctx, f, "c", 250, BackfillOptions{PageDelay: time.Hour})
+	if !errors.Is(result.Err, context.DeadlineExceeded) || result.Fetched != 100 || result.Added != 100 {
+		t.Errorf("Expected the page delay to end with ctx, keeping the first page, got %+v", result)
+	}
+}
+
+func TestBackfillFrom(t *testing.T) {
+	f := &fakeFetcher{history: map[string]int{"channel1": 250}}
+	cache := NewMessageCache(300)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1249"})
+
+	cursor, added, err := cache.backfillFromCursor(f, "channel1", "", 120)
+	if err != nil || cursor != "1130" || added != 119 {
+		t.Errorf("Unexpected first backfill: %q, %d, %v", cursor, added, err)
+	}
+	cursor, added, err = cache.backfillFromCursor(f, "channel1", cursor, 200)
+	if err != nil || cursor != "1000" || added != 130 {
+		t.Errorf("Unexpected resumed backfill: %q, %d, %v", cursor, added, err)
+	}
+	if ordered, _ := cache.IsOrdered("channel1"); !ordered {
+		t.Errorf("Expected resumed history stored at the old end in order")
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 250 {
+		t.Errorf("Expected the whole history cached, got %d messages", n)
+	}
+	if cursor, added, err = cache.backfillFromCursor(f, "channel1", cursor, 100); cursor != "1000" || added != 0 || err != nil {
+		t.Errorf("Expected the cursor kept once the history is exhausted, got %q, %d, %v", cursor, added, err)
+	}
+
+	f = &fakeFetcher{history: map[string]int{"channel2": 20}, shift: 22, deleted: map[string]bool{strconv.Itoa(1010 << 22): true}}
+	cursor, added, err = cache.backfillFromCursor(f, "channel2", strconv.Itoa(1010<<22), 100)
+	if err != nil || cursor != strconv.Itoa(1000<<22) || added != 10 {
+		t.Errorf("Expected a deleted anchor to fall back to its timestamp, got %q, %d, %v", cursor, added, err)
+	}
+
+	if _, _, err := cache.BackfillFrom(context.Background(), nil, "channel1", "not-an-id", 10); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+	if result := cache.backfill(context.Background(), f, "channel2", 5, BackfillOptions{}); result.Cursor != strconv.Itoa(1015<<22) {
+		t.Errorf("Expected Backfill to report its cursor, got %+v", result)
+	}
+}
+
+// backfillFromCursor is BackfillFrom with any fetcher.
+func (c *MessageCache) backfillFromCursor(f messageFetcher, channelID, cursor string, n int) (string, int, error) {
+	result := c.backfillFrom(context.Background(), f, channelID, cursor, n, BackfillOptions{}, true)
+	return result.Cursor, result.Added, result.Err
+}
@@ -0,0 +1,153 @@
+package dgocacheler
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// batchEntries recycles the staging slices of addBatchBulk.
+var batchEntries = sync.Pool{New: func() any { return new([]cachedMessage) }}
+
+// addBatchBulk adds a batch in bulk instead of message by message, reporting false without changing anything
+// when the batch needs the per-message path of addBatchEach. A first pass admits the messages in order and
+// keeps the channel's ID set as the loop would, dropping the ID of each message the loop would evict before
+// recording the next, so that duplicates are decided against the same IDs. The displaced entries are then
+// evicted at once and the new ones copied into the buffer around its wrap point. The stored messages, reports,
+// evictions and their order are those of addBatchEach; only ingestion hooks such as a Redactor now run for the
+// whole batch before the first eviction is reported. The caller must hold the channel's write lock.
+func (c *MessageCache) addBatchBulk(cc *ChannelCache, messages []*discordgo.Message, call addCall) (report BatchReport, ok bool) {
+	if !c.bulkAddable(cc, messages) {
+		return report, false
+	}
+	existing := cc.size
+	scratch := batchEntries.Get().(*[]cachedMessage)
+	entries := (*scratch)[:0]
+	defer func() {
+		clear(entries)
+		*scratch = entries[:0]
+		batchEntries.Put(scratch)
+	}()
+	for _, message := range messages {
+		if message == nil {
+			report.count(addNil)
+			continue
+		}
+		if c.skipWebhook(message) || c.skipInteraction(message) {
+			report.count(addFiltered)
+			continue
+		}
+		if p, ok := c.attributeGuild(cc, message); ok && !p.admits(message, c.now()) {
+			report.count(addFiltered)
+			continue
+		}
+		if c.isDuplicate(cc, message.ID, call) {
+			report.count(addDuplicate)
+			continue
+		}
+		message, sanitized := c.ingestLogged(message, call.logger)
+		if message == nil {
+			report.count(addFiltered)
+			continue
+		}
+		entry := c.newEntry(message)
+		entry.size += sanitized
+		// The loop would evict the existing entries oldest first, then the ones this batch stored.
+		if victim := existing + len(entries) - cc.maxMessages; victim >= existing {
+			cc.untrackID(entries[victim-existing].message.ID)
+		} else if victim >= 0 {
+			cc.untrackID(cc.at(victim).message.ID)
+		}
+		cc.trackID(message.ID)
+		entries = append(entries, entry)
+		cc.horizon.push(message.ID)
+		report.count(addStored)
+	}
+	cc.placeBatch(entries)
+	return report, true
+}
+
+// bulkAddable reports whether addBatchBulk can add messages to cc: no feature makes the admission or eviction
+// of a message depend on more than the channel's IDs stored before it.
+func (c *MessageCache) bulkAddable(cc *ChannelCache, messages []*discordgo.Message) bool {
+	if c.batchDuplicatePolicy.Load() == int32(LastWins) || c.similarityThreshold.Load() != 0 ||
+		c.perAuthorCap > 0 || c.strictCapacity || c.crossposts != nil || c.globalIDs != nil {
+		return false
+	}
+	if cc.sealed || cc.frozen.Load() || cc.maxMessages <= 0 ||
+		cc.size > cc.maxMessages || cc.prioritized > 0 || cc.tiers != nil || cc.mirror != nil || len(cc.tails) > 0 {
+		return false
+	}
+	if cc.guildID == "" {
+		// The first message with a guild ID may apply a guild policy to the stored messages mid-batch.
+		for _, message := range messages {
+			if message != nil && message.GuildID != "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// placeBatch appends entries whose IDs are already tracked as if added one by one, down to the physical layout
+// of the buffer. The buffer first grows as far as the loop would have grown it before its first eviction. The
+// oldest entries the batch displaces are evicted next, then the entries that would be evicted again before the
+// batch ends, each moving the head on by one slot like an add to a full ring. The rest are copied into the
+// buffer in at most two runs around its wrap point. The caller must hold the write lock.
+func (cc *ChannelCache) placeBatch(entries []cachedMessage) {
+	if len(entries) == 0 {
+		return
+	}
+	cc.reserve(min(cc.size+len(entries), cc.maxMessages))
+	displaced := min(cc.size, max(cc.size+len(entries)-cc.maxMessages, 0))
+	for range displaced {
+		evicted := cc.buffer[cc.head]
+		cc.buffer[cc.head] = cachedMessage{}
+		cc.head = (cc.head + 1) % len(cc.buffer)
+		cc.size--
+		if cc.optimistic != nil {
+			cc.optimistic.dropOldest()
+		}
+		cc.tally(evicted, -1)
+		cc.evictions++
+		if cc.onEvict != nil {
+			cc.onEvict(cc.id, evicted.message, EvictCapacity)
+		}
+	}
+	transient := max(len(entries)-cc.maxMessages, 0)
+	for _, entry := range entries[:transient] {
+		cc.tally(entry, 1)
+		cc.tally(entry, -1)
+		cc.evictions++
+		if cc.onEvict != nil {
+			cc.onEvict(cc.id, entry.message, EvictCapacity)
+		}
+	}
+	if displaced+transient > 0 {
+		cc.head = (cc.head + transient) % len(cc.buffer)
+		cc.wrapped = true
+	}
+	entries = entries[transient:]
+	tail := cc.index(cc.size)
+	n := copy(cc.buffer[tail:], entries)
+	copy(cc.buffer, entries[n:])
+	cc.size += len(entries)
+	for _, entry := range entries {
+		if cc.optimistic != nil {
+			cc.optimistic.push(entry.message)
+		}
+		cc.tally(entry, 1)
+	}
+}
+
+// reserve grows the buffer to hold n entries, to the capacity repeated calls to grow would reach. n must not
+// exceed maxMessages. The caller must hold the write lock.
+func (cc *ChannelCache) reserve(n int) {
+	capacity := len(cc.buffer)
+	for capacity < n {
+		capacity = min(max(2*capacity, channelInitialCapacity), cc.maxMessages)
+	}
+	if capacity != len(cc.buffer) {
+		cc.resize(capacity)
+	}
+}
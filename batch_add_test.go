@@ -0,0 +1,106 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAddMessagesBulkMatchesLoop(t *testing.T) {
+	variants := map[string]Option{
+		"default": func(*MessageCache) {},
+		"horizon": WithDedupHorizon(12),
+		"bloom":   WithBloomDedup(0.01),
+		"nodedup": WithoutDedup(),
+	}
+	for variant, opt := range variants {
+		rng := rand.New(rand.NewSource(1))
+		var bulkEvicted, loopEvicted []string
+		record := func(evicted *[]string) Option {
+			return OnEvict(func(channelID string, message *discordgo.Message, reason EvictReason) {
+				*evicted = append(*evicted, fmt.Sprint(message.ID, reason))
+			})
+		}
+		bulk := NewMessageCache(8, opt, WithOptimisticReads(), record(&bulkEvicted))
+		loop := NewMessageCache(8, opt, WithOptimisticReads(), record(&loopEvicted))
+
+		for op := 0; op < 500; op++ {
+			if rng.Intn(20) == 0 {
+				maxMessages := 1 + rng.Intn(40)
+				bulk.SetChannelMaxMessages("channel1", maxMessages)
+				loop.SetChannelMaxMessages("channel1", maxMessages)
+			}
+			batch := make([]*discordgo.Message, rng.Intn(50))
+			for i := range batch {
+				if rng.Intn(15) > 0 {
+					batch[i] = &discordgo.Message{ID: strconv.Itoa(rng.Intn(100)), Author: &discordgo.User{ID: strconv.Itoa(rng.Intn(3))}}
+				}
+			}
+			got, err := bulk.AddMessagesReport("channel1", batch)
+			if err != nil {
+				t.Fatalf("AddMessagesReport returned error: %v", err)
+			}
+			cc := loop.lockChannelForAdd("channel1")
+			evictions := cc.evictions
+			want, _ := loop.addBatchEach(cc, batch, addCall{source: "AddMessagesReport"})
+			want.Evicted = int(cc.evictions - evictions)
+			cc.Unlock()
+
+			if got != want {
+				t.Fatalf("%s, batch %d: got report %+v, want %+v", variant, op, got, want)
+			}
+			gotMsgs, gotWrapped, _ := bulk.GetMessagesWithWrapInfo("channel1")
+			wantMsgs, wantWrapped, _ := loop.GetMessagesWithWrapInfo("channel1")
+			if fmt.Sprint(messageIDs(gotMsgs), gotWrapped) != fmt.Sprint(messageIDs(wantMsgs), wantWrapped) {
+				t.Fatalf("%s, batch %d: got messages %v (wrapped %v), want %v (wrapped %v)", variant, op,
+					messageIDs(gotMsgs), gotWrapped, messageIDs(wantMsgs), wantWrapped)
+			}
+			gotLayout, _ := bulk.DebugDump("channel1")
+			wantLayout, _ := loop.DebugDump("channel1")
+			if fmt.Sprintf("%+v", gotLayout) != fmt.Sprintf("%+v", wantLayout) {
+				t.Fatalf("%s, batch %d: got layout %+v, want %+v", variant, op, gotLayout, wantLayout)
+			}
+			if got, want := bulk.Stats(), loop.Stats(); got != want {
+				t.Fatalf("%s, batch %d: got stats %+v, want %+v", variant, op, got, want)
+			}
+			gotLimit, _ := bulk.GetMessagesLimit("channel1", 5)
+			wantLimit, _ := loop.GetMessagesLimit("channel1", 5)
+			if fmt.Sprint(messageIDs(gotLimit)) != fmt.Sprint(messageIDs(wantLimit)) {
+				t.Fatalf("%s, batch %d: got optimistic read %v, want %v", variant, op, messageIDs(gotLimit), messageIDs(wantLimit))
+			}
+		}
+		if fmt.Sprint(bulkEvicted) != fmt.Sprint(loopEvicted) {
+			t.Errorf("%s: got evictions %v, want %v", variant, bulkEvicted, loopEvicted)
+		}
+	}
+}
+
+// benchmarkAddMessagesFull adds batches of the given size to a full 5000-message channel. The batches cycle
+// through four times the capacity of distinct messages, so every message is evicted before it comes round again.
+func benchmarkAddMessagesFull(b *testing.B, batchSize int) {
+	cache := NewMessageCache(5000)
+	for i := range 5000 {
+		cache.AddMessage("channel1", &discordgo.Message{ID: strconv.Itoa(i)})
+	}
+	batches := make([][]*discordgo.Message, 20000/batchSize)
+	next := 5000
+	for i := range batches {
+		batches[i] = make([]*discordgo.Message, batchSize)
+		for j := range batches[i] {
+			batches[i][j] = &discordgo.Message{ID: strconv.Itoa(next)}
+			next++
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.AddMessages("channel1", batches[i%len(batches)])
+	}
+}
+
+func BenchmarkAddMessagesFull10(b *testing.B)   { benchmarkAddMessagesFull(b, 10) }
+func BenchmarkAddMessagesFull100(b *testing.B)  { benchmarkAddMessagesFull(b, 100) }
+func BenchmarkAddMessagesFull1000(b *testing.B) { benchmarkAddMessagesFull(b, 1000) }
@@ -0,0 +1,51 @@
+package dgocacheler
+
+// BatchReport breaks down what AddMessagesReport did with each message of a batch.
+type BatchReport struct {
+	Added      int // Added counts the messages stored, including any evicted again later in the batch
+	Duplicates int // Duplicates counts the messages skipped because their ID was already cached
+	Updated    int // Updated counts the repeated IDs that replaced an earlier copy under the LastWins policy
+	NilSkipped int // NilSkipped counts the nil entries of the batch
+	Filtered   int // Filtered counts the messages rejected by policy: frozen channels, webhook and interaction filters, similarity suppression
+	Held       int // Held counts the messages taken while ingestion was paused, buffered or dropped
+	Evicted    int // Evicted counts the cached messages evicted to make room while the batch was added
+}
+
+// addOutcome is what happened to one message passed to an add method.
+type addOutcome int
+
+const (
+	addStored addOutcome = iota
+	addNil
+	addDuplicate
+	addFiltered
+)
+
+// count adds an outcome to the report.
+func (r *BatchReport) count(outcome addOutcome) {
+	switch outcome {
+	case addStored:
+		r.Added++
+	case addNil:
+		r.NilSkipped++
+	case addDuplicate:
+		r.Duplicates++
+	case addFiltered:
+		r.Filtered++
+	}
+}
+
+// String returns the outcome name used in logs.
+func (o addOutcome) String() string {
+	switch o {
+	case addStored:
+		return "stored"
+	case addNil:
+		return "nil"
+	case addDuplicate:
+		return "duplicate"
+	case addFiltered:
+		return "filtered"
+	}
+	return "unknown"
+}
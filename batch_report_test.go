@@ -0,0 +1,56 @@
+package dgocacheler
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAddMessagesReport(t *testing.T) {
+	msg := func(id string) *discordgo.Message { return &discordgo.Message{ID: id} }
+	webhook := &discordgo.Message{ID: "9", WebhookID: "w"}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		cached  []*discordgo.Message
+		batch   []*discordgo.Message
+		lastWin bool
+		want    BatchReport
+	}{
+		{"all new", nil, nil, []*discordgo.Message{msg("1"), msg("2")}, false, BatchReport{Added: 2}},
+		{"nil and cached", nil, []*discordgo.Message{msg("1")}, []*discordgo.Message{nil, msg("1"), msg("2")}, false, BatchReport{Added: 1, Duplicates: 1, NilSkipped: 1}},
+		{"evicts cached", nil, []*discordgo.Message{msg("1"), msg("2")}, []*discordgo.Message{msg("3"), msg("4")}, false, BatchReport{Added: 2, Evicted: 1}},
+		// 4 evicts 1, then the repeated 4 is still cached and a duplicate.
+		{"evicting message repeated", nil, []*discordgo.Message{msg("1"), msg("2"), msg("3")}, []*discordgo.Message{msg("4"), msg("4")}, false, BatchReport{Added: 1, Duplicates: 1, Evicted: 1}},
+		// 1 is evicted by 4 within the batch, so its repeat is stored again, evicting 2.
+		{"evicted message repeated", nil, []*discordgo.Message{msg("1"), msg("2"), msg("3")}, []*discordgo.Message{msg("4"), msg("1")}, false, BatchReport{Added: 2, Evicted: 2}},
+		// Batch messages evicted by later ones of the same batch count as added and evicted.
+		{"batch overflows", nil, nil, []*discordgo.Message{msg("1"), msg("2"), msg("3"), msg("4"), msg("5")}, false, BatchReport{Added: 5, Evicted: 2}},
+		{"last wins", nil, nil, []*discordgo.Message{msg("1"), {ID: "1", Content: "edited"}}, true, BatchReport{Added: 1, Updated: 1}},
+		{"webhook filtered", []Option{WithWebhookPolicy(WebhookSkip)}, nil, []*discordgo.Message{webhook, msg("1")}, false, BatchReport{Added: 1, Filtered: 1}},
+	}
+	for _, tt := range tests {
+		cache := NewMessageCache(3, tt.opts...)
+		if tt.lastWin {
+			cache.SetIntraBatchDuplicatePolicy(LastWins)
+		}
+		cache.AddMessages("channel1", tt.cached)
+		report, err := cache.AddMessagesReport("channel1", tt.batch)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if report != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, report, tt.want)
+		}
+	}
+}
+
+func TestAddMessagesReportPaused(t *testing.T) {
+	cache := NewMessageCache(3, PauseBuffering(1))
+	cache.Pause()
+	report, _ := cache.AddMessagesReport("channel1", []*discordgo.Message{{ID: "1"}, nil, {ID: "2"}})
+	if want := (BatchReport{Held: 2, NilSkipped: 1}); report != want {
+		t.Errorf("Got %+v, want %+v", report, want)
+	}
+}
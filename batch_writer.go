@@ -0,0 +1,126 @@
+package dgocacheler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// BatchWriter buffers messages for one channel and adds them to the cache in batches, so that a stream of
+// single-message events takes the cache locks once per batch instead of once per message. It is safe for
+// concurrent use. Buffered messages are not visible to reads until they are flushed.
+type BatchWriter struct {
+	cache         *MessageCache
+	channelID     string
+	flushSize     int           // flushSize is the number of buffered messages that triggers a flush, 0 for no limit
+	flushInterval time.Duration // flushInterval is the longest a message stays buffered, 0 for no limit
+
+	mu      sync.Mutex
+	pending []*discordgo.Message // pending holds the buffered messages in arrival order
+	since   time.Time            // since is when the oldest buffered message arrived
+	timer   *time.Timer          // timer flushes a buffer that stops growing, nil when none is scheduled
+	closed  bool                 // closed is set by Close
+}
+
+// NewBatchWriter returns a BatchWriter adding to channelID. The buffer is flushed with AddMessages once it holds
+// flushSize messages, or once its oldest message was buffered flushInterval ago, measured with the clock set by
+// WithClock. A flushSize or flushInterval of zero or less disables that trigger; with both disabled, messages are
+// only added on Flush and Close.
+func (c *MessageCache) NewBatchWriter(channelID string, flushSize int, flushInterval time.Duration) *BatchWriter {
+	return &BatchWriter{
+		cache:         c,
+		channelID:     channelID,
+		flushSize:     max(flushSize, 0),
+		flushInterval: max(flushInterval, 0),
+	}
+}
+
+// Add buffers message, flushing the buffer if it is full or its oldest message is due. It returns the error of
+// that flush, if any, or ErrWriterClosed after Close. Nil messages are ignored.
+func (w *BatchWriter) Add(message *discordgo.Message) error {
+	if message == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrWriterClosed
+	}
+	now := w.cache.now()
+	if len(w.pending) == 0 {
+		w.since = now
+		if w.flushInterval > 0 && w.timer == nil {
+			w.timer = time.AfterFunc(w.flushInterval, w.tick)
+		}
+	}
+	w.pending = append(w.pending, message)
+	if w.flushSize > 0 && len(w.pending) >= w.flushSize || w.due(now) {
+		return w.flushLocked()
+	}
+	return nil
+}
+
+// Flush adds the buffered messages to the cache now, returning the error of AddMessages.
+func (w *BatchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushLocked()
+}
+
+// Close flushes the buffered messages and stops the writer. Later adds return ErrWriterClosed. Closing twice
+// is a no-op.
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	return w.flushLocked()
+}
+
+// Len returns the number of buffered messages.
+func (w *BatchWriter) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// due reports whether the buffered messages waited for the flush interval. The caller must hold w.mu.
+func (w *BatchWriter) due(now time.Time) bool {
+	return w.flushInterval > 0 && len(w.pending) > 0 && now.Sub(w.since) >= w.flushInterval
+}
+
+// flushLocked adds the buffered messages to the cache. The caller must hold w.mu, which keeps batches in order.
+func (w *BatchWriter) flushLocked() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+	batch := w.pending
+	w.pending = nil
+	return w.cache.AddMessages(w.channelID, batch)
+}
+
+// tick runs on the timer, flushing a buffer that is due and rescheduling itself while messages are buffered.
+// Errors go to the handler set by WithErrorHandler.
+func (w *BatchWriter) tick() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = nil
+	if w.closed || len(w.pending) == 0 {
+		return
+	}
+	now := w.cache.now()
+	if w.due(now) {
+		if err := w.flushLocked(); err != nil {
+			w.cache.reportError(err)
+		}
+		return
+	}
+	w.timer = time.AfterFunc(max(w.flushInterval-now.Sub(w.since), time.Millisecond), w.tick)
+}
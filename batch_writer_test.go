@@ -0,0 +1,117 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func cachedCount(cache *MessageCache, channelID string) int {
+	msgs, _ := cache.GetMessages(channelID)
+	return len(msgs)
+}
+
+func TestBatchWriterFlushesOnSize(t *testing.T) {
+	cache := NewMessageCache(10)
+	w := cache.NewBatchWriter("channel1", 3, 0)
+	for i := 1; i <= 2; i++ {
+		if err := w.Add(&discordgo.Message{ID: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if got := cachedCount(cache, "channel1"); got != 0 {
+		t.Errorf("Expected messages to stay buffered below the flush size, got %d cached", got)
+	}
+	w.Add(&discordgo.Message{ID: "3"})
+	if got := cachedCount(cache, "channel1"); got != 3 || w.Len() != 0 {
+		t.Errorf("Expected a flush at the flush size, got %d cached and %d buffered", got, w.Len())
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if msgs[0].ID != "1" || msgs[2].ID != "3" {
+		t.Errorf("Expected messages in arrival order, got %v", messageIDs(msgs))
+	}
+}
+
+func TestBatchWriterFlushesOnInterval(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cache := NewMessageCache(10, WithClock(clock.Now))
+	w := cache.NewBatchWriter("channel1", 100, time.Hour)
+	defer w.Close()
+
+	w.Add(&discordgo.Message{ID: "1"})
+	clock.Advance(30 * time.Minute)
+	w.Add(&discordgo.Message{ID: "2"})
+	if got := cachedCount(cache, "channel1"); got != 0 {
+		t.Errorf("Expected messages to stay buffered within the interval, got %d cached", got)
+	}
+	clock.Advance(30 * time.Minute)
+	w.Add(&discordgo.Message{ID: "3"})
+	if got := cachedCount(cache, "channel1"); got != 3 {
+		t.Errorf("Expected a flush once the oldest message is due, got %d cached", got)
+	}
+}
+
+func TestBatchWriterFlushesIdleBufferOnTimer(t *testing.T) {
+	cache := NewMessageCache(10)
+	w := cache.NewBatchWriter("channel1", 100, 10*time.Millisecond)
+	defer w.Close()
+
+	w.Add(&discordgo.Message{ID: "1"})
+	deadline := time.Now().Add(time.Second)
+	for cachedCount(cache, "channel1") == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := cachedCount(cache, "channel1"); got != 1 {
+		t.Errorf("Expected the timer to flush an idle buffer, got %d cached", got)
+	}
+}
+
+func TestBatchWriterExplicitFlushAndClose(t *testing.T) {
+	cache := NewMessageCache(10)
+	w := cache.NewBatchWriter("channel1", 0, 0)
+	w.Add(&discordgo.Message{ID: "1"})
+	w.Add(&discordgo.Message{ID: "2"})
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := cachedCount(cache, "channel1"); got != 2 {
+		t.Errorf("Expected Flush to add the buffered messages, got %d cached", got)
+	}
+
+	w.Add(&discordgo.Message{ID: "3"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := cachedCount(cache, "channel1"); got != 3 {
+		t.Errorf("Expected Close to flush the buffered messages, got %d cached", got)
+	}
+	if err := w.Add(&discordgo.Message{ID: "4"}); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("Expected ErrWriterClosed after Close, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Expected a second Close to be a no-op, got %v", err)
+	}
+}
+
+func TestBatchWriterReturnsFlushErrors(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.SealChannel("channel1")
+	w := cache.NewBatchWriter("channel1", 2, 0)
+	w.Add(&discordgo.Message{ID: "2"})
+	if err := w.Add(&discordgo.Message{ID: "3"}); !errors.Is(err, ErrChannelSealed) {
+		t.Errorf("Expected the flush error to be returned, got %v", err)
+	}
+}
+
+func BenchmarkBatchWriterAdd(b *testing.B) {
+	cache := NewMessageCache(1000)
+	w := cache.NewBatchWriter("channel1", 64, 0)
+	for i := 0; i < b.N; i++ {
+		w.Add(&discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	w.Close()
+}
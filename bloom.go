@@ -0,0 +1,99 @@
+package dgocacheler
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// bloomSeed seeds the hashes of all counting Bloom filters.
+var bloomSeed = maphash.MakeSeed()
+
+// countingBloom is a counting Bloom filter over message IDs. Each slot counts the IDs hashed to it, so IDs can
+// be removed again; a slot that saturates stays saturated.
+type countingBloom struct {
+	counts []uint8
+	k      int // k is the number of slots per ID
+	filled int // filled is the number of non-zero slots
+}
+
+// WithBloomDedup replaces each channel's exact set of message IDs with a counting Bloom filter sized for the
+// channel capacity and the target false-positive rate, such as 0.01. The filter takes about 1.44·log2(1/rate)
+// bytes per message instead of the 50 or more of a map entry. The price is that an incoming message is wrongly
+// taken for a duplicate, and dropped, with probability of about rate, and Contains can report true for such
+// IDs. Rates outside (0, 1) are ignored. Stats reports the filters' fill ratio as BloomFillRatio.
+func WithBloomDedup(falsePositiveRate float64) Option {
+	return func(c *MessageCache) {
+		if falsePositiveRate > 0 && falsePositiveRate < 1 {
+			c.bloomRate = falsePositiveRate
+		}
+	}
+}
+
+// newCountingBloom returns a filter for up to n IDs with the given false-positive rate.
+func newCountingBloom(n int, rate float64) *countingBloom {
+	n = max(n, 1)
+	m := math.Ceil(-float64(n) * math.Log(rate) / (math.Ln2 * math.Ln2))
+	k := max(int(math.Round(m/float64(n)*math.Ln2)), 1)
+	return &countingBloom{counts: make([]uint8, int(m)), k: k}
+}
+
+// slots calls fn with each slot of id. The slots are derived from a single hash of id by mixing it with the slot
+// number, which unlike plain double hashing does not collapse onto a few slots when the step shares a factor
+// with the filter size.
+func (b *countingBloom) slots(id string, fn func(slot int)) {
+	h := maphash.String(bloomSeed, id)
+	for i := range uint64(b.k) {
+		fn(int(mix64(h+i*0x9e3779b97f4a7c15) % uint64(len(b.counts))))
+	}
+}
+
+// mix64 is the SplitMix64 finalizer, spreading every input bit over the whole output.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// contains reports whether id may have been added.
+func (b *countingBloom) contains(id string) bool {
+	found := true
+	b.slots(id, func(slot int) {
+		found = found && b.counts[slot] > 0
+	})
+	return found
+}
+
+// add adds id.
+func (b *countingBloom) add(id string) {
+	b.slots(id, func(slot int) {
+		switch b.counts[slot] {
+		case 0:
+			b.filled++
+		case math.MaxUint8:
+			return
+		}
+		b.counts[slot]++
+	})
+}
+
+// remove removes an id that was added.
+func (b *countingBloom) remove(id string) {
+	b.slots(id, func(slot int) {
+		switch b.counts[slot] {
+		case 0, math.MaxUint8:
+			return
+		case 1:
+			b.filled--
+		}
+		b.counts[slot]--
+	})
+}
+
+// clear removes all IDs.
+func (b *countingBloom) clear() {
+	clear(b.counts)
+	b.filled = 0
+}
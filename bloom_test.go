@@ -0,0 +1,90 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestBloomDedup(t *testing.T) {
+	// A tiny rate keeps false positives, which depend on the per-process hash seed, out of the exact checks.
+	cache := NewMessageCache(5, WithBloomDedup(1e-9))
+	history := testHistory(7)
+	cache.AddMessages("channel1", history[:5])
+	cache.AddMessages("channel1", history[:5])
+	if n, _ := cache.MessageCount("channel1"); n != 5 {
+		t.Errorf("Expected duplicates to be rejected, got %d messages", n)
+	}
+	if ok, _ := cache.Contains("channel1", "103"); !ok {
+		t.Error("Expected a stored ID to be contained.")
+	}
+
+	cache.AddMessages("channel1", history[5:]) // evicts 100 and 101
+	cache.AddMessage("channel1", history[0])
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[103 104 105 106 100]" {
+		t.Errorf("Expected evicted IDs to be removed from the filter, got %v", got)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Expected a valid cache, got %v", err)
+	}
+	if ratio := cache.Stats().BloomFillRatio; ratio <= 0 || ratio >= 1 {
+		t.Errorf("Expected a fill ratio between 0 and 1, got %v", ratio)
+	}
+	if ratio := NewMessageCache(5).Stats().BloomFillRatio; ratio != 0 {
+		t.Errorf("Expected no fill ratio without Bloom dedup, got %v", ratio)
+	}
+}
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	const n, probes, rate = 5000, 100000, 0.01
+	rng := rand.New(rand.NewPCG(1, 2))
+	bloom := newCountingBloom(n, rate)
+	added := make(map[string]struct{}, n)
+	for len(added) < n {
+		id := fmt.Sprint(rng.Uint64())
+		added[id] = struct{}{}
+		bloom.add(id)
+	}
+	falsePositives := 0
+	for range probes {
+		id := fmt.Sprint(rng.Uint64())
+		if _, ok := added[id]; !ok && bloom.contains(id) {
+			falsePositives++
+		}
+	}
+	if got := float64(falsePositives) / probes; got > 2*rate {
+		t.Errorf("False-positive rate %.4f exceeds twice the target %.2f", got, rate)
+	}
+	for id := range added {
+		if !bloom.contains(id) {
+			t.Fatalf("False negative for %s", id)
+		}
+	}
+}
+
+func benchmarkDedupMemory(b *testing.B, opts ...Option) {
+	history := make([]*discordgo.Message, 5000)
+	for i := range history {
+		history[i] = &discordgo.Message{ID: fmt.Sprint(1_000_000_000_000 + i)}
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cache := NewMessageCache(5000, opts...)
+		cache.AddMessages("channel1", history)
+	}
+}
+
+func BenchmarkDedupMemoryMap(b *testing.B) {
+	benchmarkDedupMemory(b)
+}
+
+func BenchmarkDedupMemoryBloom(b *testing.B) {
+	benchmarkDedupMemory(b, WithBloomDedup(0.01))
+}
+
+func BenchmarkDedupMemoryNone(b *testing.B) {
+	benchmarkDedupMemory(b, WithoutDedup())
+}
@@ -0,0 +1,443 @@
+package dgocacheler
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// channelInitialCapacity is the starting size of a channel's ring buffer. Buffers double from here as messages
+// arrive, up to the channel's maxMessages, so a large limit costs nothing until it is actually used.
+const channelInitialCapacity = 16
+
+// ChannelCache holds the cached messages of a single channel. Each channel has its own lock so that
+// operations on different channels do not contend with each other.
+//
+// Messages are kept in a circular buffer: head is the physical index of the oldest message and the
+// logical order wraps around the end of the buffer. The buffer grows lazily toward maxMessages.
+type ChannelCache struct {
+	sync.RWMutex                              // Embedding RWMutex to provide per-channel locking
+	id           string                       // id is the channel ID the cache is stored under
+	buffer       []cachedMessage              // buffer is the ring buffer backing the channel, len(buffer) is its current capacity
+	head         int                          // head is the physical index of the oldest message
+	size         int                          // size is the number of messages currently stored
+	maxMessages  int                          // maxMessages defines the max number of messages kept for this channel
+	customMax    bool                         // customMax is set when maxMessages was configured for this channel specifically
+	bytes        int64                        // bytes is the sum of the estimated sizes of the stored entries
+	messageIDs   map[string]struct{}          // messageIDs holds the IDs of the stored messages for duplicate detection
+	bloom        *countingBloom               // bloom replaces messageIDs when WithBloomDedup is set
+	bloomRate    float64                      // bloomRate is the false-positive rate bloom is sized for
+	noDedup      bool                         // noDedup is set when the channel was created without ID tracking, and never changes
+	prioritized  int                          // prioritized counts the stored entries with a non-zero priority
+	tails        map[*tailSubscriber]struct{} // tails holds the Tail consumers of the channel
+	lastUsed     atomic.Int64                 // lastUsed is the use clock value of the last lookup, for WithMaxChannels
+	frozen       atomic.Bool                  // frozen makes the channel read-only, written under the channel lock
+	sealed       bool                         // sealed makes writes to the channel fail with ErrChannelSealed
+	authorCounts map[string]int               // authorCounts holds the messages per author key, nil unless WithPerAuthorCap is set
+	activity     map[string]*authorActivity   // activity holds the message time range per author ID, nil until a message with an author is stored
+	onEvict      EvictFunc                    // onEvict is called for each evicted message, nil when not set
+	counters     map[string]int64             // counters holds the IncrCounter values, nil until one is incremented
+	globalIDs    *globalIDSet                 // globalIDs counts IDs across channels, nil unless WithGlobalDedup is set
+	users        *userIndex                   // users indexes messages per author across channels, nil unless SetPerUserRetention is set
+	lru          *messageLRU                  // lru orders messages by recency of use across channels, nil unless SetGlobalMaxMessages is set
+	crossposts   *crosspostIndex              // crossposts tracks crosspost copies and aliases, nil unless WithCrosspostDedup is set
+	tiers        *tierState                   // tiers samples messages into a cold tier, nil unless WithTieredRetention is set
+	optimistic   *optimisticRing              // optimistic mirrors the messages for lock-free reads, nil unless WithOptimisticReads is set
+	tags         map[string]struct{}          // tags label the channel, set by SetChannelTags
+	readers      map[string]string            // readers maps consumer IDs to the last message ID GetUnread returned them, nil until one reads
+	guildID      string                       // guildID is the guild of the channel, learned from its messages, empty until known
+	duplicates   *duplicateRing               // duplicates records recent duplicate drops, nil until WithDuplicateTracking records one
+	evictions    uint64                       // evictions counts the messages evicted from the channel
+	wrapped      bool                         // wrapped is set once messages were dropped because the channel was full
+	horizon      *idRing                      // horizon remembers recently added IDs, nil unless WithDedupHorizon applies
+	generation   atomic.Uint64                // generation changes with every change to the stored messages
+	view         atomic.Pointer[channelView]  // view caches the messages for GetMessages
+	mirror       *stateMirror                 // mirror feeds a discordgo.State while the channel is live, nil otherwise
+	retired      bool                         // retired is set once the channel cache was replaced or deleted
+}
+
+// cachedMessage is a single stored message together with its insertion sequence.
+type cachedMessage struct {
+	message     *discordgo.Message // message is the cached Discord message
+	insertSeq   uint64             // insertSeq records the order in which the message was inserted into the cache
+	size        int                // size is the estimated memory footprint of the message when it was stored
+	interaction bool               // interaction is set for interaction responses when WithTagInteractions is enabled
+	priority    int                // priority orders eviction, lower priorities are evicted first
+	author      string             // author is the author key of the message, only set when WithPerAuthorCap is enabled
+	cold        bool               // cold is set once the message was sampled into the cold tier of WithTieredRetention
+}
+
+// newChannelCache creates an empty ChannelCache for a channel holding at most maxMessages messages, with room
+// for up to capacity of them allocated up front. Without trackIDs no ID map is kept and duplicates are not detected.
+func newChannelCache(channelID string, maxMessages, capacity int, trackIDs bool) *ChannelCache {
+	capacity = min(max(maxMessages, 0), capacity)
+	cc := &ChannelCache{
+		id:          channelID,
+		maxMessages: maxMessages,
+		noDedup:     !trackIDs,
+	}
+	if capacity > 0 {
+		cc.buffer = make([]cachedMessage, capacity)
+	}
+	if trackIDs {
+		cc.messageIDs = make(map[string]struct{}, capacity)
+	}
+	return cc
+}
+
+// contains reports whether a message with the given ID is stored. It is always false when IDs are not tracked.
+// The caller must hold at least the read lock.
+func (cc *ChannelCache) contains(messageID string) bool {
+	if cc.bloom != nil {
+		return cc.bloom.contains(messageID)
+	}
+	_, ok := cc.messageIDs[messageID]
+	return ok
+}
+
+// tracksIDs reports whether the channel keeps an ID map or filter.
+func (cc *ChannelCache) tracksIDs() bool {
+	return cc.messageIDs != nil || cc.bloom != nil
+}
+
+// trackID records a stored message ID when IDs are tracked.
+func (cc *ChannelCache) trackID(messageID string) {
+	switch {
+	case cc.bloom != nil:
+		cc.bloom.add(messageID)
+	case cc.messageIDs != nil:
+		cc.messageIDs[messageID] = struct{}{}
+	}
+}
+
+// untrackID forgets a message ID that is no longer stored.
+func (cc *ChannelCache) untrackID(messageID string) {
+	if cc.bloom != nil {
+		cc.bloom.remove(messageID)
+		return
+	}
+	delete(cc.messageIDs, messageID)
+}
+
+// index converts a logical position (0 is the oldest message) into a physical buffer index.
+func (cc *ChannelCache) index(i int) int {
+	return (cc.head + i) % len(cc.buffer)
+}
+
+// at returns the entry at a logical position. The caller must hold at least the read lock.
+func (cc *ChannelCache) at(i int) *cachedMessage {
+	return &cc.buffer[cc.index(i)]
+}
+
+// add appends an entry, evicting the oldest one when the channel is full. The caller must hold the write lock.
+func (cc *ChannelCache) add(entry cachedMessage) {
+	if cc.maxMessages <= 0 {
+		return
+	}
+	if cc.size >= cc.maxMessages {
+		cc.evict(cc.size - cc.maxMessages + 1)
+	}
+	if cc.size == len(cc.buffer) {
+		cc.grow()
+	}
+	cc.buffer[cc.index(cc.size)] = entry
+	cc.size++
+	if cc.optimistic != nil {
+		cc.optimistic.push(entry.message)
+	}
+	cc.account(entry, 1)
+	if cc.mirror != nil {
+		cc.mirror.add(cc.id, entry.message)
+	}
+	if cc.crossposts != nil {
+		cc.crossposts.add(cc, entry.message)
+	}
+	cc.ageTiers()
+}
+
+// account adds (sign 1) or removes (sign -1) an entry's contribution to the channel's byte, ID and priority
+// bookkeeping.
+func (cc *ChannelCache) account(entry cachedMessage, sign int) {
+	if sign > 0 {
+		cc.trackID(entry.message.ID)
+	} else {
+		cc.untrackID(entry.message.ID)
+	}
+	cc.tally(entry, sign)
+}
+
+// tally is account without the ID tracking.
+func (cc *ChannelCache) tally(entry cachedMessage, sign int) {
+	cc.bumpGeneration()
+	cc.bytes += int64(sign * entry.size)
+	if entry.priority != 0 {
+		cc.prioritized += sign
+	}
+	if cc.authorCounts != nil {
+		if cc.authorCounts[entry.author] += sign; cc.authorCounts[entry.author] == 0 {
+			delete(cc.authorCounts, entry.author)
+		}
+	}
+	if cc.globalIDs != nil {
+		cc.globalIDs.update(entry.message.ID, sign)
+	}
+	if cc.users != nil {
+		cc.users.update(cc, entry, sign)
+	}
+	if cc.lru != nil {
+		cc.lru.update(cc, entry, sign)
+	}
+	cc.trackActivity(entry.message, sign)
+}
+
+// evict removes n entries to make room, each time the oldest of those with the lowest priority. Without
+// prioritized entries this is the same as dropping the oldest. The caller must hold the write lock.
+func (cc *ChannelCache) evict(n int) {
+	for ; n > 0 && cc.size > 0; n-- {
+		victim := 0
+		for i := 1; i < cc.size && cc.prioritized > 0; i++ {
+			if cc.at(i).priority < cc.at(victim).priority {
+				victim = i
+			}
+		}
+		cc.evictAt(victim, EvictCapacity)
+		cc.wrapped = true
+	}
+}
+
+// evictAt removes the entry at a logical position and reports it to the eviction callback.
+// The caller must hold the write lock.
+func (cc *ChannelCache) evictAt(i int, reason EvictReason) {
+	var evicted cachedMessage
+	if i == 0 {
+		evicted = *cc.at(0)
+		cc.dropOldest(1)
+	} else {
+		evicted = cc.removeAt(i)
+	}
+	cc.evictions++
+	if cc.onEvict != nil {
+		cc.onEvict(cc.id, evicted.message, reason)
+	}
+}
+
+// replace swaps the entry at a logical position for another one. The caller must hold the write lock.
+func (cc *ChannelCache) replace(i int, entry cachedMessage) {
+	slot := cc.at(i)
+	entry.cold = slot.cold
+	cc.account(*slot, -1)
+	cc.account(entry, 1)
+	*slot = entry
+	if cc.optimistic != nil {
+		cc.optimistic.set(i, entry.message)
+	}
+	if cc.mirror != nil {
+		cc.mirror.add(cc.id, entry.message)
+	}
+	if cc.crossposts != nil {
+		cc.crossposts.add(cc, entry.message)
+	}
+}
+
+// removeAt deletes the entry at a logical position, shifting the newer entries back by one.
+// The caller must hold the write lock.
+func (cc *ChannelCache) removeAt(i int) cachedMessage {
+	removed := *cc.at(i)
+	if i < cc.size/2 {
+		// Shift the older entries forward instead, which is cheaper near the front.
+		for j := i; j > 0; j-- {
+			*cc.at(j) = *cc.at(j - 1)
+		}
+		*cc.at(0) = cachedMessage{}
+		cc.head = (cc.head + 1) % len(cc.buffer)
+	} else {
+		for j := i; j < cc.size-1; j++ {
+			*cc.at(j) = *cc.at(j + 1)
+		}
+		*cc.at(cc.size - 1) = cachedMessage{}
+	}
+	cc.size--
+	if cc.optimistic != nil {
+		cc.optimistic.removeAt(i)
+	}
+	cc.account(removed, -1)
+	if cc.mirror != nil {
+		cc.mirror.remove(cc.id, removed.message.ID)
+	}
+	if cc.crossposts != nil {
+		cc.crossposts.remove(cc, removed.message.ID)
+	}
+	return removed
+}
+
+// grow doubles the buffer capacity, bounded by maxMessages, and unwraps the contents so head is zero.
+func (cc *ChannelCache) grow() {
+	capacity := min(max(2*len(cc.buffer), channelInitialCapacity), cc.maxMessages)
+	cc.resize(max(capacity, cc.size+1))
+}
+
+// resize moves the contents into a new buffer of the given capacity with head at zero.
+func (cc *ChannelCache) resize(capacity int) {
+	buffer := make([]cachedMessage, capacity)
+	for i := 0; i < cc.size; i++ {
+		buffer[i] = *cc.at(i)
+	}
+	cc.buffer = buffer
+	cc.head = 0
+}
+
+// dropOldest removes the n oldest entries, clearing their slots so the messages can be garbage collected.
+func (cc *ChannelCache) dropOldest(n int) {
+	for ; n > 0 && cc.size > 0; n-- {
+		cc.account(cc.buffer[cc.head], -1)
+		if cc.mirror != nil {
+			cc.mirror.remove(cc.id, cc.buffer[cc.head].message.ID)
+		}
+		if cc.crossposts != nil {
+			cc.crossposts.remove(cc, cc.buffer[cc.head].message.ID)
+		}
+		cc.buffer[cc.head] = cachedMessage{}
+		cc.head = (cc.head + 1) % len(cc.buffer)
+		cc.size--
+		if cc.optimistic != nil {
+			cc.optimistic.dropOldest()
+		}
+	}
+}
+
+// setMaxMessages changes the channel capacity, evicting messages if needed. The caller must hold the write lock.
+func (cc *ChannelCache) setMaxMessages(maxMessages int) {
+	cc.maxMessages = maxMessages
+	if cc.size > max(maxMessages, 0) {
+		cc.evict(cc.size - max(maxMessages, 0))
+	}
+	if len(cc.buffer) > max(maxMessages, 0) {
+		cc.resize(cc.size)
+	}
+	if cc.bloom != nil {
+		cc.bloom = newCountingBloom(maxMessages, cc.bloomRate)
+		for i := 0; i < cc.size; i++ {
+			cc.bloom.add(cc.at(i).message.ID)
+		}
+	}
+}
+
+// reset replaces the contents with entries in chronological order, keeping only the newest maxMessages.
+// The caller must hold the write lock.
+func (cc *ChannelCache) reset(entries []cachedMessage) {
+	cc.bumpGeneration()
+	for i := 0; i < cc.size; i++ {
+		if cc.globalIDs != nil {
+			cc.globalIDs.update(cc.at(i).message.ID, -1)
+		}
+		if cc.users != nil {
+			cc.users.update(cc, *cc.at(i), -1)
+		}
+		if cc.lru != nil {
+			cc.lru.update(cc, *cc.at(i), -1)
+		}
+		if cc.mirror != nil {
+			cc.mirror.remove(cc.id, cc.at(i).message.ID)
+		}
+		if cc.crossposts != nil {
+			cc.crossposts.remove(cc, cc.at(i).message.ID)
+		}
+	}
+	if overflow := len(entries) - max(cc.maxMessages, 0); overflow > 0 {
+		entries = entries[overflow:]
+		cc.wrapped = true
+	}
+	cc.buffer = entries
+	cc.head = 0
+	cc.size = len(entries)
+	cc.bytes = 0
+	cc.prioritized = 0
+	switch {
+	case cc.bloom != nil:
+		cc.bloom.clear()
+	case cc.messageIDs != nil:
+		cc.messageIDs = make(map[string]struct{}, len(entries))
+	}
+	if cc.authorCounts != nil {
+		cc.authorCounts = make(map[string]int)
+	}
+	cc.activity = nil
+	for _, entry := range entries {
+		cc.account(entry, 1)
+		if cc.mirror != nil {
+			cc.mirror.add(cc.id, entry.message)
+		}
+		if cc.crossposts != nil {
+			cc.crossposts.add(cc, entry.message)
+		}
+	}
+	if cc.optimistic != nil {
+		cc.optimistic.load(cc.messages(), len(cc.buffer))
+	}
+	cc.ageTiers()
+}
+
+// entries returns a copy of the stored entries in chronological order. The caller must hold at least the read lock.
+func (cc *ChannelCache) entries() []cachedMessage {
+	return cc.newest(cc.size)
+}
+
+// newest returns a copy of up to limit of the most recent entries. The caller must hold at least the read lock.
+func (cc *ChannelCache) newest(limit int) []cachedMessage {
+	n := min(max(limit, 0), cc.size)
+	entries := make([]cachedMessage, n)
+	for i := range entries {
+		entries[i] = *cc.at(cc.size - n + i)
+	}
+	return entries
+}
+
+// messages returns the stored messages in chronological order. The caller must hold at least the read lock.
+func (cc *ChannelCache) messages() []*discordgo.Message {
+	return cc.newestMessages(cc.size)
+}
+
+// newestMessages returns up to limit of the most recent messages. The caller must hold at least the read lock.
+func (cc *ChannelCache) newestMessages(limit int) []*discordgo.Message {
+	n := min(max(limit, 0), cc.size)
+	return cc.window(cc.size-n, n)
+}
+
+// oldestMessages returns up to limit of the oldest messages. The caller must hold at least the read lock.
+func (cc *ChannelCache) oldestMessages(limit int) []*discordgo.Message {
+	return cc.window(0, min(max(limit, 0), cc.size))
+}
+
+// find returns the logical position of the newest message with the given ID, or -1 if it is not stored.
+// The caller must hold at least the read lock.
+func (cc *ChannelCache) find(messageID string) int {
+	if cc.tracksIDs() && !cc.contains(messageID) {
+		return -1
+	}
+	for i := cc.size - 1; i >= 0; i-- {
+		if cc.at(i).message.ID == messageID {
+			return i
+		}
+	}
+	return -1
+}
+
+// window returns the n messages starting at logical position start, which must lie within the stored messages.
+// The caller must hold at least the read lock.
+func (cc *ChannelCache) window(start, n int) []*discordgo.Message {
+	msgs := make([]*discordgo.Message, n)
+	for i := range msgs {
+		msgs[i] = cc.at(start + i).message
+	}
+	return msgs
+}
+
+// messagesOf is an unexported helper that copies the message pointers out of a slice of cache entries.
+func messagesOf(entries []cachedMessage) []*discordgo.Message {
+	msgs := make([]*discordgo.Message, len(entries))
+	for i, entry := range entries {
+		msgs[i] = entry.message
+	}
+	return msgs
+}
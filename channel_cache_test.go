@@ -0,0 +1,84 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestChannelCacheGrowsLazily(t *testing.T) {
+	cache := NewMessageCache(10_000_000)
+	for i := 0; i < 3; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+
+	cc := cache.messages["channel1"]
+	if len(cc.buffer) > channelInitialCapacity {
+		t.Errorf("Expected a small backing array, got capacity %d", len(cc.buffer))
+	}
+
+	for i := 3; i < 100; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	if len(cc.buffer) != 128 {
+		t.Errorf("Expected the buffer to double up to 128 slots, got %d", len(cc.buffer))
+	}
+}
+
+func TestChannelCacheWrapAround(t *testing.T) {
+	cache := NewMessageCache(20)
+	for i := 0; i < 30; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+
+	cc := cache.messages["channel1"]
+	if len(cc.buffer) != 20 {
+		t.Errorf("Expected the buffer to stop growing at maxMessages, got %d", len(cc.buffer))
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if len(msgs) != 20 || msgs[0].ID != "10" || msgs[19].ID != "29" {
+		t.Errorf("Unexpected messages after wrap-around: %v", messageIDs(msgs))
+	}
+}
+
+func TestChannelCacheGrowAfterWrap(t *testing.T) {
+	cache := NewMessageCache(5)
+	for i := 0; i < 8; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	if cc := cache.messages["channel1"]; cc.head == 0 {
+		t.Fatal("Expected the buffer to have wrapped.")
+	}
+
+	cache.SetMaxMessages(40)
+	for i := 8; i < 50; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if len(msgs) != 40 {
+		t.Fatalf("Expected 40 messages, got %d", len(msgs))
+	}
+	for i, msg := range msgs {
+		if msg.ID != fmt.Sprint(10+i) {
+			t.Fatalf("Expected message %d at position %d, got %s", 10+i, i, msg.ID)
+		}
+	}
+}
+
+func TestChannelCacheZeroMaxMessages(t *testing.T) {
+	cache := NewMessageCache(0)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	if msgs, _ := cache.GetMessages("channel1"); len(msgs) != 0 {
+		t.Errorf("Expected no messages to be stored, got %d", len(msgs))
+	}
+}
+
+func BenchmarkNewChannelLargeMaxMessages(b *testing.B) {
+	cache := NewMessageCache(10_000_000)
+	msg := &discordgo.Message{ID: "1"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cache.AddMessage(fmt.Sprint(i), msg)
+	}
+}
@@ -0,0 +1,19 @@
+package dgocacheler
+
+import "time"
+
+// WithClock sets the source of the current time used by time-based features such as edit coalescing and
+// duplicate records, so that tests can control it. The default is time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(c *MessageCache) {
+		c.clock = now
+	}
+}
+
+// now returns the current time from the configured clock.
+func (c *MessageCache) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
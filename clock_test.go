@@ -0,0 +1,28 @@
+package dgocacheler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeClock is a manually advanced clock for WithClock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.now }
+
+func (f *fakeClock) Advance(d time.Duration) { f.now = f.now.Add(d) }
+
+func TestWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cache := NewMessageCache(10, WithClock(clock.Now), WithDuplicateTracking(1))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	records := cache.LastDuplicates("channel1", 1)
+	if len(records) != 1 || !records[0].Time.Equal(clock.now) {
+		t.Errorf("Expected duplicate records to use the configured clock, got %+v", records)
+	}
+}
@@ -0,0 +1,146 @@
+package dgocacheler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// minimalMessage is the trimmed form of a message kept by the test codec.
+type minimalMessage struct {
+	ID       string `json:"i"`
+	Content  string `json:"c,omitempty"`
+	AuthorID string `json:"a,omitempty"`
+}
+
+func encodeMinimal(msg *discordgo.Message) ([]byte, error) {
+	m := minimalMessage{ID: msg.ID, Content: msg.Content}
+	if msg.Author != nil {
+		m.AuthorID = msg.Author.ID
+	}
+	return json.Marshal(m)
+}
+
+func decodeMinimal(data []byte) (*discordgo.Message, error) {
+	var m minimalMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	msg := &discordgo.Message{ID: m.ID, Content: m.Content}
+	if m.AuthorID != "" {
+		msg.Author = &discordgo.User{ID: m.AuthorID}
+	}
+	return msg, nil
+}
+
+// codecHistory returns messages carrying fields the minimal codec drops.
+func codecHistory() []*discordgo.Message {
+	return []*discordgo.Message{
+		{ID: "100", Content: "hello", Author: &discordgo.User{ID: "u1", Username: "alice"}, ChannelID: "channel1"},
+		{ID: "101", Content: "world", Pinned: true},
+	}
+}
+
+func TestSetCodecSnapshotRoundTrip(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.SetCodec(encodeMinimal, decodeMinimal)
+	cache.AddMessages("channel1", codecHistory())
+
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	if err := cache.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile returned error: %v", err)
+	}
+	restored := NewMessageCache(5)
+	if err := restored.LoadFromFile(path); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("Expected ErrSnapshotFormat loading a codec snapshot without a codec, got %v", err)
+	}
+	restored.SetCodec(encodeMinimal, decodeMinimal)
+	if err := restored.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile returned error: %v", err)
+	}
+	msgs, _ := restored.GetMessages("channel1")
+	if len(msgs) != 2 {
+		t.Fatalf("Expected 2 restored messages, got %v", messageIDs(msgs))
+	}
+	if msgs[0].ID != "100" || msgs[0].Content != "hello" || msgs[0].Author == nil || msgs[0].Author.ID != "u1" {
+		t.Errorf("Expected ID, content and author ID to survive, got %+v", msgs[0])
+	}
+	if msgs[0].Author.Username != "" || msgs[0].ChannelID != "" || msgs[1].Pinned {
+		t.Error("Expected the fields the codec drops to be lost.")
+	}
+
+	cache.SetCodec(nil, nil)
+	var buf bytes.Buffer
+	if _, err := cache.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+	if _, err := restored.ReadFrom(&buf); err != nil {
+		t.Fatalf("Expected a JSON snapshot to load with a codec set, got %v", err)
+	}
+	if msg, _ := restored.GetMessage("channel1", "100"); msg == nil || msg.Author.Username != "alice" {
+		t.Errorf("Expected the full JSON message, got %+v", msg)
+	}
+}
+
+func TestSetCodecErrors(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", codecHistory())
+	failure := errors.New("encode failed")
+	cache.SetCodec(func(*discordgo.Message) ([]byte, error) { return nil, failure }, decodeMinimal)
+	if _, err := cache.WriteTo(&bytes.Buffer{}); !errors.Is(err, failure) {
+		t.Errorf("Expected WriteTo to return the encode error, got %v", err)
+	}
+	if _, err := cache.ExportAll(context.Background(), &bytes.Buffer{}, ExportOptions{}); !errors.Is(err, failure) {
+		t.Errorf("Expected ExportAll to return the encode error, got %v", err)
+	}
+
+	cache.SetCodec(encodeMinimal, decodeMinimal)
+	var buf bytes.Buffer
+	if _, err := cache.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+	cache.SetCodec(encodeMinimal, func([]byte) (*discordgo.Message, error) { return nil, failure })
+	if _, err := cache.ReadFrom(&buf); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("Expected ErrSnapshotFormat for a decode error, got %v", err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected a failed load to leave the cache unchanged, got %d messages", n)
+	}
+}
+
+func TestSetCodecExport(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.SetCodec(encodeMinimal, decodeMinimal)
+	cache.AddMessages("channel1", codecHistory())
+	var buf bytes.Buffer
+	if _, err := cache.ExportAll(context.Background(), &buf, ExportOptions{}); err != nil {
+		t.Fatalf("ExportAll returned error: %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	var header exportHeader
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &header) != nil || header.Messages != 2 {
+		t.Fatalf("Expected a header for 2 messages, got %q", scanner.Text())
+	}
+	var ids []string
+	for scanner.Scan() {
+		var data []byte
+		if err := json.Unmarshal(scanner.Bytes(), &data); err != nil {
+			t.Fatalf("Expected a base64 string line, got %q", scanner.Text())
+		}
+		msg, err := decodeMinimal(data)
+		if err != nil {
+			t.Fatalf("Failed to decode message line: %v", err)
+		}
+		ids = append(ids, msg.ID)
+	}
+	if len(ids) != 2 || ids[0] != "100" || ids[1] != "101" {
+		t.Errorf("Expected messages 100 and 101, got %v", ids)
+	}
+}
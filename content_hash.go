@@ -0,0 +1,71 @@
+package dgocacheler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// WithContentHashing stores messages without their text: on ingestion, messages are cloned, their Content is
+// replaced with the hex SHA-256 digest of the text and embed descriptions are dropped, in quoted replies too.
+// Everything else is kept. Exact-text checks still work on the digests: ContentSeen, DistinctContentCount and
+// Query.Content. Features that need the text do not: Query.ContentContains and AsMessageSends fail with
+// ErrUnsupported, SetSimilarityDedup only catches identical texts and ContentSizeBuckets measures digests.
+// Snapshots hold the digests and plaintext snapshots are hashed on load. Empty content stays empty.
+func WithContentHashing() Option {
+	return func(c *MessageCache) {
+		c.contentHashing = true
+	}
+}
+
+// ContentSeen reports whether a message with exactly content is cached in a channel, and returns the digest of
+// content as stored with WithContentHashing. It works with or without content hashing and returns false for
+// unknown channels.
+func (c *MessageCache) ContentSeen(channelID string, content string) (bool, string) {
+	digest := contentDigest(content)
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return false, digest
+	}
+	stored := c.storedContent(content)
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	for i := cc.size - 1; i >= 0; i-- {
+		if cc.at(i).message.Content == stored {
+			return true, digest
+		}
+	}
+	return false, digest
+}
+
+// storedContent returns content as the cache stores it.
+func (c *MessageCache) storedContent(content string) string {
+	if c.contentHashing {
+		return contentDigest(content)
+	}
+	return content
+}
+
+// contentDigest returns the hex SHA-256 digest of content, or "" for empty content.
+func contentDigest(content string) string {
+	if content == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashContent replaces the text of msg, which must be a clone made by cloneMessage, with its digest.
+func hashContent(msg *discordgo.Message) {
+	msg.Content = contentDigest(msg.Content)
+	for _, embed := range msg.Embeds {
+		if embed != nil {
+			embed.Description = ""
+		}
+	}
+	if msg.ReferencedMessage != nil {
+		msg.ReferencedMessage = cloneMessage(msg.ReferencedMessage)
+		hashContent(msg.ReferencedMessage)
+	}
+}
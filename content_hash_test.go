@@ -0,0 +1,103 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const hashedSecret = "the vault code is 1234"
+
+func secretMessage(id string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:                id,
+		Content:           hashedSecret,
+		Embeds:            []*discordgo.MessageEmbed{{Title: "note", Description: hashedSecret}},
+		ReferencedMessage: &discordgo.Message{ID: "0", Content: hashedSecret},
+	}
+}
+
+func TestContentHashingStoresNoPlaintext(t *testing.T) {
+	var spilled []*discordgo.Message
+	cache := NewMessageCache(2, WithContentHashing(), WithDebugContent(true),
+		OnEvict(func(_ string, message *discordgo.Message, _ EvictReason) { spilled = append(spilled, message) }))
+	original := secretMessage("1")
+	cache.AddMessage("channel1", original)
+	cache.AddMessages("channel1", []*discordgo.Message{secretMessage("2")})
+	cache.UpdateMessage("channel1", secretMessage("2"))
+	cache.AddMessage("channel1", secretMessage("3"))
+
+	if original.Content != hashedSecret || original.Embeds[0].Description != hashedSecret {
+		t.Error("Expected the caller's message to be left untouched")
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	var snapshot bytes.Buffer
+	cache.WriteTo(&snapshot)
+	stored, _ := json.Marshal(msgs)
+	evicted, _ := json.Marshal(spilled)
+	for name, data := range map[string][]byte{"stored messages": stored, "evicted messages": evicted, "snapshot": snapshot.Bytes()} {
+		if bytes.Contains(data, []byte(hashedSecret)) {
+			t.Errorf("Expected %s not to contain the plaintext", name)
+		}
+	}
+	if msgs[0].Embeds[0].Title != "note" || msgs[0].Embeds[0].Description != "" {
+		t.Errorf("Expected embed descriptions to be dropped and the rest kept, got %+v", msgs[0].Embeds[0])
+	}
+
+	seen, digest := cache.ContentSeen("channel1", hashedSecret)
+	if !seen || msgs[0].Content != digest || len(digest) != 64 {
+		t.Errorf("Expected ContentSeen to find the digest %s, got %v", digest, seen)
+	}
+	if seen, _ := cache.ContentSeen("channel1", "something else"); seen {
+		t.Error("Expected ContentSeen to miss other text")
+	}
+	if got, _ := cache.Query("channel1").Content(hashedSecret).Run(); len(got) != 2 {
+		t.Errorf("Expected exact content search to match both messages, got %d", len(got))
+	}
+	if _, err := cache.Query("channel1").ContentContains("vault").Run(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected substring search to be unsupported, got %v", err)
+	}
+	if _, err := cache.AsMessageSends("channel1"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected AsMessageSends to be unsupported, got %v", err)
+	}
+	if distinct, _ := cache.DistinctContentCount("channel1"); distinct != 1 {
+		t.Errorf("Expected equal texts to share a digest, got %d distinct contents", distinct)
+	}
+}
+
+func TestContentHashingHashesPlaintextSnapshots(t *testing.T) {
+	plain := NewMessageCache(10)
+	plain.AddMessage("channel1", secretMessage("1"))
+	var snapshot bytes.Buffer
+	plain.WriteTo(&snapshot)
+
+	hashing := NewMessageCache(10, WithContentHashing())
+	if _, err := hashing.ReadFrom(&snapshot); err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	var resaved bytes.Buffer
+	hashing.WriteTo(&resaved)
+	if bytes.Contains(resaved.Bytes(), []byte(hashedSecret)) {
+		t.Error("Expected a plaintext snapshot to be hashed on load")
+	}
+
+	reloaded := NewMessageCache(10, WithContentHashing())
+	reloaded.ReadFrom(&resaved)
+	if seen, _ := reloaded.ContentSeen("channel1", hashedSecret); !seen {
+		t.Error("Expected hashed snapshots to load without hashing again")
+	}
+}
+
+func TestContentSeenWithoutHashing(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1", Content: "hello"})
+	if seen, digest := cache.ContentSeen("channel1", "hello"); !seen || digest != contentDigest("hello") {
+		t.Errorf("Expected plaintext content to be found, got %v, %s", seen, digest)
+	}
+	if got, err := cache.Query("channel1").ContentContains("ell").Run(); err != nil || len(got) != 1 {
+		t.Errorf("Expected substring search to work on plaintext, got %d, %v", len(got), err)
+	}
+}
@@ -0,0 +1,24 @@
+module github.com/CreativeUnicorns/dgocacheler/contrib/otel
+
+go 1.23.0
+
+replace github.com/CreativeUnicorns/dgocacheler => ../..
+
+require (
+	github.com/CreativeUnicorns/dgocacheler v0.0.0-00010101000000-000000000000
+	github.com/bwmarrin/discordgo v0.28.1
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/sdk v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/gorilla/websocket v1.4.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
+	golang.org/x/sys v0.35.0 // indirect
+)
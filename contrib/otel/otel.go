@@ -0,0 +1,60 @@
+// Package dgocachelerotel adapts an OpenTelemetry tracer to the dgocacheler Tracer interface.
+package dgocachelerotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CreativeUnicorns/dgocacheler"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer wraps an OpenTelemetry tracer.
+type tracer struct {
+	tracer trace.Tracer
+}
+
+// span wraps an OpenTelemetry span.
+type span struct {
+	span trace.Span
+}
+
+// NewTracer returns a dgocacheler.Tracer that starts spans on t. Pass it to dgocacheler.WithTracer.
+func NewTracer(t trace.Tracer) dgocacheler.Tracer {
+	return tracer{tracer: t}
+}
+
+// Start begins an OpenTelemetry span named name.
+func (t tracer) Start(ctx context.Context, name string) (context.Context, dgocacheler.Span) {
+	ctx, s := t.tracer.Start(ctx, name)
+	return ctx, span{span: s}
+}
+
+// SetAttribute records value on the span as a typed attribute.
+func (s span) SetAttribute(key string, value any) {
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+// RecordError records err on the span and marks the span as failed.
+func (s span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End completes the span.
+func (s span) End() {
+	s.span.End()
+}
@@ -0,0 +1,71 @@
+package dgocachelerotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CreativeUnicorns/dgocacheler"
+	"github.com/bwmarrin/discordgo"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestCache(loader dgocacheler.Loader) (*dgocacheler.MessageCache, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	cache := dgocacheler.NewMessageCache(10,
+		dgocacheler.WithTracer(NewTracer(provider.Tracer("test"))),
+		dgocacheler.WithLoader(loader),
+	)
+	return cache, exporter
+}
+
+func TestSpanAttributes(t *testing.T) {
+	loader := func(ctx context.Context, channelID string, limit int) ([]*discordgo.Message, error) {
+		return []*discordgo.Message{{ID: "1"}, {ID: "2"}}, nil
+	}
+	cache, exporter := newTestCache(loader)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "0"})
+	if _, err := cache.LatestOrFetch("channel1", 5); err != nil {
+		t.Fatalf("LatestOrFetch returned error: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected one span, got %d", len(spans))
+	}
+	if spans[0].Name != "dgocacheler.LatestOrFetch" {
+		t.Errorf("Unexpected span name %q", spans[0].Name)
+	}
+	attrs := map[string]any{}
+	for _, kv := range spans[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attrs[dgocacheler.AttrChannelID] != "channel1" || attrs[dgocacheler.AttrResultCount] != int64(3) {
+		t.Errorf("Unexpected span attributes: %v", attrs)
+	}
+}
+
+func TestSpanRecordsError(t *testing.T) {
+	loader := func(ctx context.Context, channelID string, limit int) ([]*discordgo.Message, error) {
+		return nil, errors.New("fetch failed")
+	}
+	cache, exporter := newTestCache(loader)
+	cache.LatestOrFetch("channel1", 5)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error || len(spans[0].Events) == 0 {
+		t.Errorf("Expected the error to be recorded on the span, got %+v", spans)
+	}
+}
+
+func TestHotPathNotTraced(t *testing.T) {
+	cache, exporter := newTestCache(nil)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.GetMessages("channel1")
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Errorf("Hot-path operations must not create spans, got %d", n)
+	}
+}
@@ -0,0 +1,25 @@
+package dgocacheler
+
+// IncrCounter adds delta to a named counter kept alongside a channel's messages and returns the new value,
+// creating the channel if needed. Counters survive message eviction and are reset by ClearChannel.
+func (c *MessageCache) IncrCounter(channelID, name string, delta int64) (int64, error) {
+	cc := c.lockChannelForAdd(channelID)
+	defer cc.Unlock()
+	if cc.counters == nil {
+		cc.counters = make(map[string]int64)
+	}
+	cc.counters[name] += delta
+	return cc.counters[name], nil
+}
+
+// GetCounter returns the value of a named channel counter, 0 if it was never incremented. It returns
+// ErrCacheMiss for unknown channels.
+func (c *MessageCache) GetCounter(channelID, name string) (int64, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return 0, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	return cc.counters[name], nil
+}
@@ -0,0 +1,43 @@
+package dgocacheler
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestCounters(t *testing.T) {
+	cache := NewMessageCache(2)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				cache.IncrCounter("channel1", "commands", 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n, err := cache.GetCounter("channel1", "commands"); err != nil || n != 800 {
+		t.Errorf("Expected 800, got %d (err %v)", n, err)
+	}
+	if n, _ := cache.IncrCounter("channel1", "commands", -50); n != 750 {
+		t.Errorf("Expected 750 after a negative delta, got %d", n)
+	}
+	if n, _ := cache.GetCounter("channel1", "other"); n != 0 {
+		t.Errorf("Expected an unknown counter to be 0, got %d", n)
+	}
+
+	cache.AddMessages("channel1", testHistory(5)) // evicts messages
+	if n, _ := cache.GetCounter("channel1", "commands"); n != 750 {
+		t.Errorf("Expected counters to survive eviction, got %d", n)
+	}
+	cache.ClearChannel("channel1")
+	if n, _ := cache.GetCounter("channel1", "commands"); n != 0 {
+		t.Errorf("Expected ClearChannel to reset counters, got %d", n)
+	}
+	if _, err := cache.GetCounter("missing", "commands"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
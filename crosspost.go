@@ -0,0 +1,244 @@
+package dgocacheler
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// WithCrosspostDedup stores a message published to following channels only once. A crosspost, a message with
+// the discordgo.MessageFlagsIsCrossPosted flag and a MessageReference to its origin, is not stored when the
+// origin or another crosspost of it is already cached; instead the cache records a lightweight alias, so that
+// TotalCount and other cross-channel counts see it once while GetMessage on the following channel still finds
+// it, returning the stored copy. The first crosspost of an origin that is not cached is stored as usual and
+// becomes the copy later crossposts resolve to. Origins are recognized by the discordgo.MessageFlagsCrossPosted
+// flag; an origin cached after one of its crossposts is stored as well.
+//
+// Per-channel reads such as GetMessages leave aliases out; GetMessagesWithAliases includes them. Aliases live
+// as long as the copy they resolve to: once it is evicted or removed, they are dropped and GetMessage on the
+// following channels reports ErrMessageNotFound again.
+func WithCrosspostDedup() Option {
+	return func(c *MessageCache) {
+		c.crossposts = &crosspostIndex{
+			origins: make(map[messageKey]*crosspostOrigin),
+			copies:  make(map[crosspostCopy]messageKey),
+			aliases: make(map[string]map[string]messageKey),
+		}
+	}
+}
+
+// GetMessagesWithAliases is GetMessages including the crossposts aliased to a channel by WithCrosspostDedup.
+// Each alias yields the stored copy, which may carry another channel and message ID, placed among the
+// channel's messages by the ID of the crosspost. It returns false if the channel is neither cached nor has
+// aliases.
+func (c *MessageCache) GetMessagesWithAliases(channelID string) ([]*discordgo.Message, bool) {
+	msgs, ok := c.GetMessages(channelID)
+	if c.crossposts == nil {
+		return msgs, ok
+	}
+	aliases := c.crossposts.channelAliases(channelID)
+	if len(aliases) == 0 {
+		return msgs, ok
+	}
+	slices.SortFunc(aliases, func(a, b crosspostAlias) int { return c.compareIDs(a.messageID, b.messageID) })
+	merged := make([]*discordgo.Message, 0, len(msgs)+len(aliases))
+	for _, alias := range aliases {
+		copied, ok := c.resolveAlias(alias.origin)
+		if !ok {
+			continue
+		}
+		for len(msgs) > 0 && c.compareIDs(msgs[0].ID, alias.messageID) < 0 {
+			merged, msgs = append(merged, msgs[0]), msgs[1:]
+		}
+		merged = append(merged, copied)
+	}
+	return append(merged, msgs...), true
+}
+
+// crosspostIndex tracks the stored copy of every crossposted message and the aliases resolving to it. Its
+// lock is only ever taken last, so it may be acquired while holding the global or a channel lock.
+type crosspostIndex struct {
+	mu      sync.Mutex
+	origins map[messageKey]*crosspostOrigin  // origins holds the stored copy of each origin message
+	copies  map[crosspostCopy]messageKey     // copies maps each stored copy back to its origin
+	aliases map[string]map[string]messageKey // aliases maps channel and crosspost message IDs to their origin
+}
+
+// messageKey identifies a message by channel and message ID.
+type messageKey struct {
+	channelID string
+	messageID string
+}
+
+// crosspostCopy locates a stored copy, whatever ID its channel is stored under.
+type crosspostCopy struct {
+	channel   *ChannelCache
+	messageID string
+}
+
+// crosspostOrigin is the stored copy of an origin message with the aliases resolving to it.
+type crosspostOrigin struct {
+	copy    crosspostCopy
+	aliases []messageKey
+}
+
+// crosspostAlias is one alias of a channel.
+type crosspostAlias struct {
+	messageID string     // messageID is the ID of the crosspost
+	origin    messageKey // origin identifies the origin message
+}
+
+// crosspostOf returns the origin of a crosspost added to channelID, reporting false for other messages.
+func crosspostOf(channelID string, message *discordgo.Message) (messageKey, bool) {
+	ref := message.MessageReference
+	if message.Flags&discordgo.MessageFlagsIsCrossPosted == 0 || ref == nil || ref.ChannelID == "" ||
+		ref.MessageID == "" || ref.ChannelID == channelID {
+		return messageKey{}, false
+	}
+	return messageKey{channelID: ref.ChannelID, messageID: ref.MessageID}, true
+}
+
+// alias records message, added to channelID, as an alias when a copy of its origin is stored elsewhere,
+// reporting whether it did. The caller must hold the channel's write lock.
+func (x *crosspostIndex) alias(cc *ChannelCache, channelID string, message *discordgo.Message) bool {
+	origin, ok := crosspostOf(channelID, message)
+	if !ok {
+		return false
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o, ok := x.origins[origin]
+	if !ok || o.copy.channel == cc {
+		return false
+	}
+	if x.aliases[channelID] == nil {
+		x.aliases[channelID] = make(map[string]messageKey)
+	}
+	if _, ok := x.aliases[channelID][message.ID]; !ok {
+		x.aliases[channelID][message.ID] = origin
+		o.aliases = append(o.aliases, messageKey{channelID: channelID, messageID: message.ID})
+	}
+	return true
+}
+
+// add registers a stored message of cc as the copy of its origin, if it is an origin or a crosspost whose
+// origin has no copy yet.
+func (x *crosspostIndex) add(cc *ChannelCache, message *discordgo.Message) {
+	origin, ok := crosspostOf(cc.id, message)
+	if !ok {
+		if message.Flags&discordgo.MessageFlagsCrossPosted == 0 {
+			return
+		}
+		origin = messageKey{channelID: cc.id, messageID: message.ID}
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.origins[origin]; ok {
+		return
+	}
+	copied := crosspostCopy{channel: cc, messageID: message.ID}
+	x.origins[origin] = &crosspostOrigin{copy: copied}
+	x.copies[copied] = origin
+}
+
+// remove drops the aliases of a stored copy that left cc.
+func (x *crosspostIndex) remove(cc *ChannelCache, messageID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	copied := crosspostCopy{channel: cc, messageID: messageID}
+	origin, ok := x.copies[copied]
+	if !ok {
+		return
+	}
+	for _, alias := range x.origins[origin].aliases {
+		if delete(x.aliases[alias.channelID], alias.messageID); len(x.aliases[alias.channelID]) == 0 {
+			delete(x.aliases, alias.channelID)
+		}
+	}
+	delete(x.origins, origin)
+	delete(x.copies, copied)
+}
+
+// lookup returns the stored copy an alias resolves to.
+func (x *crosspostIndex) lookup(channelID, messageID string) (messageKey, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	origin, ok := x.aliases[channelID][messageID]
+	return origin, ok
+}
+
+// copyOf returns where the copy of an origin is stored.
+func (x *crosspostIndex) copyOf(origin messageKey) (crosspostCopy, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o, ok := x.origins[origin]
+	if !ok {
+		return crosspostCopy{}, false
+	}
+	return o.copy, true
+}
+
+// channelAliases returns the aliases of a channel.
+func (x *crosspostIndex) channelAliases(channelID string) []crosspostAlias {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	aliases := make([]crosspostAlias, 0, len(x.aliases[channelID]))
+	for messageID, origin := range x.aliases[channelID] {
+		aliases = append(aliases, crosspostAlias{messageID: messageID, origin: origin})
+	}
+	return aliases
+}
+
+// resolveCrosspost returns the stored copy of a crosspost aliased under channelID and messageID.
+func (c *MessageCache) resolveCrosspost(channelID, messageID string) (*discordgo.Message, bool) {
+	if c.crossposts == nil {
+		return nil, false
+	}
+	origin, ok := c.crossposts.lookup(channelID, messageID)
+	if !ok {
+		return nil, false
+	}
+	return c.resolveAlias(origin)
+}
+
+// resolveAlias returns the stored copy of an origin message.
+func (c *MessageCache) resolveAlias(origin messageKey) (*discordgo.Message, bool) {
+	copied, ok := c.crossposts.copyOf(origin)
+	if !ok {
+		return nil, false
+	}
+	cc := copied.channel
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	i := cc.find(copied.messageID)
+	if i < 0 {
+		return nil, false
+	}
+	cc.touch(i, 1)
+	return cc.at(i).message, true
+}
+
+// attachCrossposts registers the channel's copies, for a channel being stored in the cache. The caller must
+// hold the global and the channel's write lock.
+func (cc *ChannelCache) attachCrossposts(x *crosspostIndex) {
+	if cc.crossposts == x {
+		return
+	}
+	cc.crossposts = x
+	for i := 0; i < cc.size; i++ {
+		x.add(cc, cc.at(i).message)
+	}
+}
+
+// detachCrossposts drops the channel's copies and their aliases, for a channel being dropped from the cache.
+// The caller must hold the channel's write lock.
+func (cc *ChannelCache) detachCrossposts() {
+	if cc.crossposts == nil {
+		return
+	}
+	for i := 0; i < cc.size; i++ {
+		cc.crossposts.remove(cc, cc.at(i).message.ID)
+	}
+	cc.crossposts = nil
+}
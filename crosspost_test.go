@@ -0,0 +1,103 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// crosspost returns a crosspost of the origin message into another channel.
+func crosspost(id, originChannelID, originID string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:               id,
+		Content:          "announcement",
+		Flags:            discordgo.MessageFlagsIsCrossPosted,
+		MessageReference: &discordgo.MessageReference{ChannelID: originChannelID, MessageID: originID},
+	}
+}
+
+func TestCrosspostDedupStoresOnce(t *testing.T) {
+	cache := NewMessageCache(10, WithCrosspostDedup())
+	origin := &discordgo.Message{ID: "1", Content: "announcement", Flags: discordgo.MessageFlagsCrossPosted}
+	cache.AddMessage("news", origin)
+	cache.AddMessage("follower1", crosspost("2", "news", "1"))
+	cache.AddMessage("follower2", crosspost("3", "news", "1"))
+	cache.AddMessage("follower1", &discordgo.Message{ID: "4", Content: "chatter"})
+
+	if got := cache.TotalCount(); got != 2 {
+		t.Errorf("Expected the crossposts to be counted once, got %d messages", got)
+	}
+	for _, ref := range []struct{ channelID, messageID string }{{"follower1", "2"}, {"follower2", "3"}} {
+		msg, err := cache.GetMessage(ref.channelID, ref.messageID)
+		if err != nil || msg != origin {
+			t.Errorf("Expected %s/%s to resolve to the origin, got %v (err %v)", ref.channelID, ref.messageID, msg, err)
+		}
+	}
+	if msgs, _ := cache.GetMessages("follower1"); fmt.Sprint(messageIDs(msgs)) != "[4]" {
+		t.Errorf("Expected plain reads to leave aliases out, got %v", messageIDs(msgs))
+	}
+	if msgs, ok := cache.GetMessagesWithAliases("follower1"); !ok || fmt.Sprint(messageIDs(msgs)) != "[1 4]" {
+		t.Errorf("Expected the alias ahead of the newer message, got %v", messageIDs(msgs))
+	}
+	if msgs, ok := cache.GetMessagesWithAliases("follower2"); !ok || fmt.Sprint(messageIDs(msgs)) != "[1]" {
+		t.Errorf("Expected the alias of a channel without messages, got %v", messageIDs(msgs))
+	}
+}
+
+func TestCrosspostDedupWithoutOrigin(t *testing.T) {
+	cache := NewMessageCache(10, WithCrosspostDedup())
+	first := crosspost("2", "news", "1")
+	cache.AddMessage("follower1", first)
+	cache.AddMessage("follower2", crosspost("3", "news", "1"))
+
+	if got := cache.TotalCount(); got != 1 {
+		t.Errorf("Expected only the first crosspost to be stored, got %d messages", got)
+	}
+	if msg, err := cache.GetMessage("follower2", "3"); err != nil || msg != first {
+		t.Errorf("Expected the alias to resolve to the first crosspost, got %v (err %v)", msg, err)
+	}
+}
+
+func TestCrosspostDedupDropsAliasesWithOrigin(t *testing.T) {
+	cache := NewMessageCache(2, WithCrosspostDedup())
+	cache.AddMessage("news", &discordgo.Message{ID: "1", Flags: discordgo.MessageFlagsCrossPosted})
+	cache.AddMessage("follower1", crosspost("2", "news", "1"))
+	cache.AddMessage("follower2", crosspost("3", "news", "1"))
+	cache.AddMessage("follower1", &discordgo.Message{ID: "4"})
+
+	cache.AddMessage("news", &discordgo.Message{ID: "5"})
+	cache.AddMessage("news", &discordgo.Message{ID: "6"}) // evicts the origin
+	for _, ref := range []struct{ channelID, messageID string }{{"follower1", "2"}, {"follower2", "3"}} {
+		if _, err := cache.GetMessage(ref.channelID, ref.messageID); !errors.Is(err, ErrMessageNotFound) && !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Expected %s/%s to be gone with its origin, got %v", ref.channelID, ref.messageID, err)
+		}
+	}
+	if msgs, _ := cache.GetMessagesWithAliases("follower1"); fmt.Sprint(messageIDs(msgs)) != "[4]" {
+		t.Errorf("Expected the dangling alias to be dropped, got %v", messageIDs(msgs))
+	}
+	if n := len(cache.crossposts.aliases) + len(cache.crossposts.origins); n != 0 {
+		t.Errorf("Expected the index to be empty, got %d entries", n)
+	}
+
+	// The crosspost is stored again once its origin is gone.
+	cache.AddMessage("follower2", crosspost("3", "news", "1"))
+	if msgs, _ := cache.GetMessages("follower2"); fmt.Sprint(messageIDs(msgs)) != "[3]" {
+		t.Errorf("Expected the crosspost to be stored, got %v", messageIDs(msgs))
+	}
+}
+
+func TestCrosspostDedupDropsAliasesWithChannel(t *testing.T) {
+	cache := NewMessageCache(10, WithCrosspostDedup())
+	cache.AddMessage("follower1", crosspost("2", "news", "1"))
+	cache.AddMessage("follower2", crosspost("3", "news", "1"))
+	cache.DeleteChannel("follower1")
+
+	if _, err := cache.GetMessage("follower2", "3"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected the alias to go with the deleted copy, got %v", err)
+	}
+	if n := len(cache.crossposts.aliases) + len(cache.crossposts.origins) + len(cache.crossposts.copies); n != 0 {
+		t.Errorf("Expected the index to be empty, got %d entries", n)
+	}
+}
@@ -0,0 +1,35 @@
+package dgocacheler
+
+// DebugInfo describes the physical ring buffer layout of a channel.
+type DebugInfo struct {
+	Head        int      // Head is the physical index of the oldest message
+	Size        int      // Size is the number of stored messages
+	MaxMessages int      // MaxMessages is the channel capacity
+	Capacity    int      // Capacity is the number of slots currently allocated
+	Slots       []string // Slots holds the message ID of each slot in physical order, "" for empty slots
+}
+
+// DebugDump returns the raw ring buffer layout of a channel, for diagnosing ordering bugs. Unlike the read
+// methods, slots are listed in physical buffer order rather than chronologically. It returns ErrCacheMiss for
+// unknown channels.
+func (c *MessageCache) DebugDump(channelID string) (DebugInfo, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return DebugInfo{}, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	info := DebugInfo{
+		Head:        cc.head,
+		Size:        cc.size,
+		MaxMessages: cc.maxMessages,
+		Capacity:    len(cc.buffer),
+		Slots:       make([]string, len(cc.buffer)),
+	}
+	for i, entry := range cc.buffer {
+		if entry.message != nil {
+			info.Slots[i] = entry.message.ID
+		}
+	}
+	return info, nil
+}
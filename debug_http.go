@@ -0,0 +1,137 @@
+package dgocacheler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	debugDefaultLimit   = 50  // debugDefaultLimit is the number of messages listed when no limit is given
+	debugContentMaxRune = 200 // debugContentMaxRune is the length message content is truncated to
+)
+
+// WithDebugContent controls whether DebugHandler includes message content in its responses. Content is
+// omitted by default since it is user data.
+func WithDebugContent(enabled bool) Option {
+	return func(c *MessageCache) {
+		c.debugContent = enabled
+	}
+}
+
+// debugChannel is a /channels entry.
+type debugChannel struct {
+	ID           string    `json:"id"`
+	Messages     int       `json:"messages"`
+	MaxMessages  int       `json:"max_messages"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// debugMessage is a /channels/{id}/messages entry.
+type debugMessage struct {
+	ID        string    `json:"id"`
+	AuthorID  string    `json:"author_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Content   *string   `json:"content,omitempty"`
+}
+
+// DebugHandler returns an http.Handler serving the cache state as JSON:
+//
+//	GET /channels                      channels with message counts and last activity
+//	GET /channels/{id}/messages?limit=n the newest n messages of a channel (404 for unknown channels)
+//	GET /stats                         the Stats summary
+//	GET /validate                      the result of Validate (500 if an invariant is violated)
+//
+// Responses are built from copies taken under the locks, so slow clients never hold a lock. Message content
+// is only included with WithDebugContent. The handler is meant for an internal listener; mount it under a
+// prefix with http.StripPrefix, for example next to net/http/pprof.
+func DebugHandler(c *MessageCache) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /channels", c.serveDebugChannels)
+	mux.HandleFunc("GET /channels/{id}/messages", c.serveDebugMessages)
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		writeDebugJSON(w, http.StatusOK, c.Stats())
+	})
+	mux.HandleFunc("GET /validate", func(w http.ResponseWriter, r *http.Request) {
+		if err := c.Validate(); err != nil {
+			writeDebugJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
+			return
+		}
+		writeDebugJSON(w, http.StatusOK, map[string]any{"ok": true})
+	})
+	return mux
+}
+
+// serveDebugChannels lists the cached channels ordered by ID.
+func (c *MessageCache) serveDebugChannels(w http.ResponseWriter, r *http.Request) {
+	var channels []debugChannel
+	for _, cc := range c.channelCaches() {
+		c.rlockChannel(cc)
+		channel := debugChannel{ID: cc.id, Messages: cc.size, MaxMessages: cc.maxMessages}
+		if cc.size > 0 {
+			channel.LastActivity = messageTime(cc.at(cc.size - 1).message)
+		}
+		cc.RUnlock()
+		channels = append(channels, channel)
+	}
+	slices.SortFunc(channels, func(a, b debugChannel) int { return strings.Compare(a.ID, b.ID) })
+	writeDebugJSON(w, http.StatusOK, channels)
+}
+
+// serveDebugMessages lists the newest messages of one channel.
+func (c *MessageCache) serveDebugMessages(w http.ResponseWriter, r *http.Request) {
+	limit := debugDefaultLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			writeDebugError(w, http.StatusBadRequest, ErrInvalidLimit)
+			return
+		}
+		limit = n
+	}
+	cc, ok := c.channelCache(r.PathValue("id"))
+	if !ok {
+		writeDebugError(w, http.StatusNotFound, ErrCacheMiss)
+		return
+	}
+	c.rlockChannel(cc)
+	msgs := cc.newestMessages(limit)
+	cc.RUnlock()
+
+	out := make([]debugMessage, len(msgs))
+	for i, msg := range msgs {
+		out[i] = debugMessage{ID: msg.ID, Timestamp: msg.Timestamp}
+		if msg.Author != nil {
+			out[i].AuthorID = msg.Author.ID
+		}
+		if c.debugContent {
+			content := truncateRunes(msg.Content, debugContentMaxRune)
+			out[i].Content = &content
+		}
+	}
+	writeDebugJSON(w, http.StatusOK, out)
+}
+
+// truncateRunes shortens s to at most n runes, marking truncation with an ellipsis.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
+
+// writeDebugJSON writes v as a JSON response with the given status.
+func writeDebugJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeDebugError writes err as a JSON error response.
+func writeDebugError(w http.ResponseWriter, status int, err error) {
+	writeDebugJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,87 @@
+package dgocacheler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func debugGet(t *testing.T, handler http.Handler, path string, v any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s: invalid JSON %q: %v", path, rec.Body.String(), err)
+		}
+	}
+	return rec.Code
+}
+
+func TestDebugHandlerChannels(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel2", testHistory(3))
+	cache.AddMessages("channel1", testHistory(1))
+	handler := DebugHandler(cache)
+
+	var channels []debugChannel
+	if code := debugGet(t, handler, "/channels", &channels); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if len(channels) != 2 || channels[0].ID != "channel1" || channels[1].Messages != 3 || channels[1].MaxMessages != 10 {
+		t.Errorf("Unexpected channels: %+v", channels)
+	}
+}
+
+func TestDebugHandlerMessages(t *testing.T) {
+	long := strings.Repeat("x", debugContentMaxRune+10)
+	msg := &discordgo.Message{ID: "200", Content: long, Author: &discordgo.User{ID: "user1"}}
+
+	private := NewMessageCache(10)
+	private.AddMessages("channel1", testHistory(5))
+	private.AddMessage("channel1", msg)
+	var msgs []debugMessage
+	if code := debugGet(t, DebugHandler(private), "/channels/channel1/messages?limit=2", &msgs); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	if len(msgs) != 2 || msgs[1].ID != "200" || msgs[1].AuthorID != "user1" || msgs[1].Content != nil {
+		t.Errorf("Expected the two newest messages without content, got %+v", msgs)
+	}
+
+	public := NewMessageCache(10, WithDebugContent(true))
+	public.AddMessage("channel1", msg)
+	if debugGet(t, DebugHandler(public), "/channels/channel1/messages", &msgs); msgs[0].Content == nil ||
+		len([]rune(*msgs[0].Content)) != debugContentMaxRune+1 {
+		t.Errorf("Expected truncated content with WithDebugContent, got %+v", msgs)
+	}
+
+	if code := debugGet(t, DebugHandler(public), "/channels/missing/messages", nil); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown channel, got %d", code)
+	}
+	if code := debugGet(t, DebugHandler(public), "/channels/channel1/messages?limit=x", nil); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", code)
+	}
+}
+
+func TestDebugHandlerStatsAndValidate(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(4))
+	handler := DebugHandler(cache)
+
+	var stats Stats
+	if code := debugGet(t, handler, "/stats", &stats); code != http.StatusOK || stats.Messages != 4 || stats.Channels != 1 {
+		t.Errorf("Unexpected stats %+v (status %d)", stats, code)
+	}
+	var result map[string]any
+	if code := debugGet(t, handler, "/validate", &result); code != http.StatusOK || result["ok"] != true {
+		t.Errorf("Unexpected validate result %v (status %d)", result, code)
+	}
+	cache.messages["channel1"].bytes++
+	if code := debugGet(t, handler, "/validate", &result); code != http.StatusInternalServerError || result["ok"] != false {
+		t.Errorf("Expected a failed validation, got %v (status %d)", result, code)
+	}
+}
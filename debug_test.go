@@ -0,0 +1,31 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDebugDump(t *testing.T) {
+	cache := NewMessageCache(4)
+	cache.AddMessages("channel1", testHistory(6)) // 100..105, wrapped twice
+
+	info, err := cache.DebugDump("channel1")
+	if err != nil {
+		t.Fatalf("DebugDump returned error: %v", err)
+	}
+	if info.Head != 2 || info.Size != 4 || info.MaxMessages != 4 || info.Capacity != 4 {
+		t.Errorf("Unexpected layout: %+v", info)
+	}
+	if got := fmt.Sprint(info.Slots); got != "[104 105 102 103]" {
+		t.Errorf("Expected physical order [104 105 102 103], got %v", got)
+	}
+
+	cache.AddMessages("channel2", testHistory(2))
+	if info, _ := cache.DebugDump("channel2"); info.Capacity != 4 || info.Slots[2] != "" {
+		t.Errorf("Expected unused slots to be empty, got %+v", info)
+	}
+	if _, err := cache.DebugDump("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package dgocacheler
+
+import "github.com/bwmarrin/discordgo"
+
+// WithoutDedup stops the cache from tracking message IDs. AddMessage then always inserts, even when the ID is
+// already cached, which saves a map insert and delete per message and the memory of the ID maps. Use it for
+// high-throughput streams where duplicates cannot occur. The ID-based lookups Contains, GetMessage and
+// RemoveMessage return ErrDedupDisabled in this mode.
+func WithoutDedup() Option {
+	return func(c *MessageCache) {
+		c.dedupDisabled = true
+	}
+}
+
+// WithoutChannelDedup is WithoutDedup for the listed channels only, for example a high-volume log mirror whose
+// producer guarantees unique IDs while other channels keep duplicate detection. Contains, GetMessage and
+// RemoveMessage return ErrDedupDisabled for these channels rather than scanning them.
+func WithoutChannelDedup(channelIDs ...string) Option {
+	return func(c *MessageCache) {
+		if c.noDedupChannels == nil {
+			c.noDedupChannels = make(map[string]struct{}, len(channelIDs))
+		}
+		for _, channelID := range channelIDs {
+			c.noDedupChannels[channelID] = struct{}{}
+		}
+	}
+}
+
+// Contains reports whether a message with the given ID is cached in a channel. It returns ErrDedupDisabled
+// when the channel does not track message IDs and ErrCacheMiss for unknown channels.
+func (c *MessageCache) Contains(channelID, messageID string) (bool, error) {
+	cc, err := c.idTrackingChannel(channelID)
+	if err != nil {
+		return false, err
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	return cc.contains(messageID), nil
+}
+
+// idTrackingChannel looks up a channel for an ID-based operation, failing with ErrDedupDisabled when its IDs
+// are not tracked and with ErrCacheMiss when the channel is unknown.
+func (c *MessageCache) idTrackingChannel(channelID string) (*ChannelCache, error) {
+	if c.dedupDisabled {
+		return nil, ErrDedupDisabled
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	if cc.noDedup {
+		return nil, ErrDedupDisabled
+	}
+	return cc, nil
+}
+
+// BatchDuplicatePolicy decides which occurrence of a message ID is kept when one AddMessages batch contains
+// the same ID more than once.
+type BatchDuplicatePolicy int32
+
+const (
+	// FirstWins keeps the first occurrence and skips the later ones. This is the default.
+	FirstWins BatchDuplicatePolicy = iota
+	// LastWins keeps the last occurrence, replacing the stored message in place, for batches where later
+	// entries are edited versions of earlier ones.
+	LastWins
+)
+
+// SetIntraBatchDuplicatePolicy sets how AddMessages handles IDs repeated within a single batch. A message whose
+// ID was cached before the batch and appears only once in it is still skipped under either policy.
+func (c *MessageCache) SetIntraBatchDuplicatePolicy(policy BatchDuplicatePolicy) {
+	c.batchDuplicatePolicy.Store(int32(policy))
+}
+
+// MessagesEqual reports whether two messages agree on the fields that matter for caching: ID, content, author
+// ID, timestamp and edited timestamp. Two nil messages are equal; a nil and a non-nil one are not.
+func MessagesEqual(a, b *discordgo.Message) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.ID == b.ID && a.Content == b.Content && messageAuthorID(a) == messageAuthorID(b) &&
+		a.Timestamp.Equal(b.Timestamp) && editedEqual(a, b)
+}
+
+// editedEqual reports whether two messages have the same edited timestamp, or neither has one.
+func editedEqual(a, b *discordgo.Message) bool {
+	if a.EditedTimestamp == nil || b.EditedTimestamp == nil {
+		return a.EditedTimestamp == b.EditedTimestamp
+	}
+	return a.EditedTimestamp.Equal(*b.EditedTimestamp)
+}
+
+// messageAuthorID returns the ID of a message's author, or the empty string when it has none.
+func messageAuthorID(msg *discordgo.Message) string {
+	if msg.Author == nil {
+		return ""
+	}
+	return msg.Author.ID
+}
@@ -0,0 +1,67 @@
+package dgocacheler
+
+// idRing remembers the most recently added message IDs of a channel, up to a fixed number, independently of
+// which messages are still stored.
+type idRing struct {
+	ids  []string
+	head int            // head is the position of the oldest remembered ID
+	size int            // size is the number of remembered IDs
+	seen map[string]int // seen counts the occurrences of each remembered ID
+}
+
+// newIDRing returns an idRing remembering up to n IDs.
+func newIDRing(n int) *idRing {
+	return &idRing{ids: make([]string, n), seen: make(map[string]int)}
+}
+
+// WithDedupHorizon makes each channel reject re-adds of any of its last n added message IDs, even after the
+// messages were evicted, for example when a gateway resume redelivers them. The IDs are kept in a ring of n
+// entries per channel, so memory stays bounded. Without it, or with n not above the channel capacity, only
+// stored messages are recognized as duplicates. It has no effect together with WithoutDedup. Stats reports
+// the re-adds rejected this way as HorizonSuppressed.
+func WithDedupHorizon(n int) Option {
+	return func(c *MessageCache) {
+		c.dedupHorizon = n
+	}
+}
+
+// contains reports whether id is remembered. A nil ring remembers nothing.
+func (r *idRing) contains(id string) bool {
+	if r == nil {
+		return false
+	}
+	return r.seen[id] > 0
+}
+
+// push remembers id, forgetting the oldest remembered ID when the ring is full. A nil ring ignores it.
+func (r *idRing) push(id string) {
+	if r == nil || len(r.ids) == 0 {
+		return
+	}
+	if r.size == len(r.ids) {
+		oldest := r.ids[r.head]
+		if r.seen[oldest]--; r.seen[oldest] == 0 {
+			delete(r.seen, oldest)
+		}
+		r.head = (r.head + 1) % len(r.ids)
+		r.size--
+	}
+	r.ids[(r.head+r.size)%len(r.ids)] = id
+	r.size++
+	r.seen[id]++
+}
+
+// isDuplicate reports whether an incoming message ID must be skipped as a duplicate: it is stored, or it was
+// evicted but is still inside the dedup horizon. Duplicates are recorded for diagnostics. The caller must hold
+// the channel's write lock.
+func (c *MessageCache) isDuplicate(cc *ChannelCache, messageID string, call addCall) bool {
+	switch {
+	case cc.contains(messageID):
+	case cc.horizon.contains(messageID):
+		c.horizonSuppressed.Add(1)
+	default:
+		return false
+	}
+	c.recordDuplicate(cc, messageID, call)
+	return true
+}
@@ -0,0 +1,42 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDedupHorizon(t *testing.T) {
+	cache := NewMessageCache(3, WithDedupHorizon(5))
+	history := testHistory(7)
+	cache.AddMessages("channel1", history[:5]) // stores 102..104, remembers 100..104
+
+	cache.AddMessage("channel1", history[1])
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[102 103 104]" {
+		t.Errorf("Expected an evicted ID inside the horizon to be rejected, got %v", got)
+	}
+	if got := cache.Stats().HorizonSuppressed; got != 1 {
+		t.Errorf("Expected 1 horizon suppression, got %d", got)
+	}
+
+	cache.AddMessages("channel1", history[5:]) // remembers 102..106
+	cache.AddMessage("channel1", history[1])
+	if ok, _ := cache.Contains("channel1", "101"); !ok {
+		t.Error("Expected an ID that left the horizon to be accepted again.")
+	}
+	cache.AddMessage("channel1", history[5])
+	if got := cache.Stats().HorizonSuppressed; got != 1 {
+		t.Errorf("Expected stored duplicates not to count as horizon suppressions, got %d", got)
+	}
+}
+
+func TestDedupHorizonDefault(t *testing.T) {
+	cache := NewMessageCache(2, WithDedupHorizon(2))
+	cache.AddMessages("channel1", testHistory(3))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "100"})
+	if ok, _ := cache.Contains("channel1", "100"); !ok {
+		t.Error("Expected a horizon not above the capacity to keep the default behavior.")
+	}
+}
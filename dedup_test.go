@@ -0,0 +1,191 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestContainsGetRemoveMessage(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(8)) // wraps: 103..107 remain
+
+	if ok, err := cache.Contains("channel1", "105"); err != nil || !ok {
+		t.Errorf("Expected 105 to be cached, got %v (err %v)", ok, err)
+	}
+	if ok, _ := cache.Contains("channel1", "101"); ok {
+		t.Error("Evicted message 101 should not be reported as cached.")
+	}
+	if msg, err := cache.GetMessage("channel1", "106"); err != nil || msg.ID != "106" {
+		t.Errorf("Expected message 106, got %v (err %v)", msg, err)
+	}
+
+	if err := cache.RemoveMessage("channel1", "105"); err != nil {
+		t.Fatalf("RemoveMessage returned error: %v", err)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if fmt.Sprint(messageIDs(msgs)) != "[103 104 106 107]" {
+		t.Errorf("Unexpected messages after removal: %v", messageIDs(msgs))
+	}
+	cache.AddMessages("channel1", testHistory(10)[8:])
+	if msgs, _ := cache.GetMessages("channel1"); fmt.Sprint(messageIDs(msgs)) != "[104 106 107 108 109]" {
+		t.Errorf("Unexpected messages after adding past a removal: %v", messageIDs(msgs))
+	}
+	if stats, _ := cache.ChannelStats("channel1"); stats.EstimatedBytes != recomputeBytes(cache.messages["channel1"]) {
+		t.Error("Byte count drifted after removal.")
+	}
+
+	if err := cache.RemoveMessage("channel1", "105"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+	if _, err := cache.GetMessage("missing", "1"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestWithoutDedup(t *testing.T) {
+	cache := NewMessageCache(10, WithoutDedup())
+	cache.AddMessages("channel1", []*discordgo.Message{{ID: "1"}, {ID: "1"}, {ID: "2"}})
+	if msgs, _ := cache.GetMessages("channel1"); len(msgs) != 3 {
+		t.Errorf("Expected duplicates to be inserted, got %d messages", len(msgs))
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate should accept the missing ID map, got %v", err)
+	}
+	if cache.messages["channel1"].messageIDs != nil {
+		t.Error("No ID map should be kept without dedup.")
+	}
+
+	if _, err := cache.Contains("channel1", "1"); !errors.Is(err, ErrDedupDisabled) {
+		t.Errorf("Contains: expected ErrDedupDisabled, got %v", err)
+	}
+	if _, err := cache.GetMessage("channel1", "1"); !errors.Is(err, ErrDedupDisabled) {
+		t.Errorf("GetMessage: expected ErrDedupDisabled, got %v", err)
+	}
+	if err := cache.RemoveMessage("channel1", "1"); !errors.Is(err, ErrDedupDisabled) {
+		t.Errorf("RemoveMessage: expected ErrDedupDisabled, got %v", err)
+	}
+}
+
+func TestWithoutChannelDedup(t *testing.T) {
+	cache := NewMessageCache(10, WithoutChannelDedup("firehose"))
+	batch := []*discordgo.Message{{ID: "1"}, {ID: "1"}, {ID: "2"}}
+	cache.AddMessages("firehose", batch)
+	cache.AddMessages("channel1", batch)
+	if n, _ := cache.MessageCount("firehose"); n != 3 {
+		t.Errorf("Expected duplicates to be inserted in the firehose channel, got %d messages", n)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected duplicates to be skipped in other channels, got %d messages", n)
+	}
+	if _, err := cache.GetMessage("firehose", "1"); !errors.Is(err, ErrDedupDisabled) {
+		t.Errorf("GetMessage: expected ErrDedupDisabled, got %v", err)
+	}
+	if _, err := cache.GetMessage("channel1", "1"); err != nil {
+		t.Errorf("GetMessage: expected the tracked channel to work, got %v", err)
+	}
+
+	cache.ReplaceChannel("firehose", batch)
+	if n, _ := cache.MessageCount("firehose"); n != 2 {
+		t.Errorf("Expected ReplaceChannel to deduplicate its input, got %d messages", n)
+	}
+	if _, err := cache.Contains("firehose", "1"); !errors.Is(err, ErrDedupDisabled) {
+		t.Errorf("Expected the replaced channel to stay untracked, got %v", err)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate should accept untracked channels, got %v", err)
+	}
+}
+
+func BenchmarkAddMessageDedup(b *testing.B) {
+	msgs := make([]*discordgo.Message, 10000)
+	for i := range msgs {
+		msgs[i] = &discordgo.Message{ID: fmt.Sprint(i)}
+	}
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"dedup", nil},
+		{"without-dedup", []Option{WithoutDedup()}},
+		{"without-channel-dedup", []Option{WithoutChannelDedup("channel1")}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cache := NewMessageCache(5000, bc.opts...)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				cache.AddMessage("channel1", msgs[i%len(msgs)])
+			}
+		})
+	}
+}
+
+func TestIntraBatchDuplicatePolicy(t *testing.T) {
+	batch := []*discordgo.Message{
+		{ID: "100", Content: "first"},
+		{ID: "101", Content: "other"},
+		{ID: "100", Content: "second"},
+		{ID: "100", Content: "third"},
+	}
+
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", batch)
+	if msg, _ := cache.GetMessage("channel1", "100"); msg.Content != "first" {
+		t.Errorf("FirstWins: expected the first occurrence, got %q", msg.Content)
+	}
+
+	cache = NewMessageCache(10)
+	cache.SetIntraBatchDuplicatePolicy(LastWins)
+	cache.AddMessages("channel1", batch)
+	msgs, _ := cache.GetMessages("channel1")
+	if fmt.Sprint(messageIDs(msgs)) != "[100 101]" || msgs[0].Content != "third" {
+		t.Errorf("LastWins: expected the last occurrence in place, got %v %q", messageIDs(msgs), msgs[0].Content)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+
+	cache.AddMessages("channel1", []*discordgo.Message{{ID: "101", Content: "again"}})
+	if msg, _ := cache.GetMessage("channel1", "101"); msg.Content != "other" {
+		t.Errorf("LastWins should not replace messages cached by an earlier batch, got %q", msg.Content)
+	}
+}
+
+func TestMessagesEqual(t *testing.T) {
+	sent := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	edited := sent.Add(time.Minute)
+	base := func() *discordgo.Message {
+		return &discordgo.Message{ID: "1", Content: "hello", Author: &discordgo.User{ID: "u1"}, Timestamp: sent, EditedTimestamp: &edited}
+	}
+
+	if !MessagesEqual(base(), base()) {
+		t.Error("Expected identical messages to be equal.")
+	}
+	other := base()
+	other.Timestamp = sent.In(time.FixedZone("x", 3600))
+	other.Embeds = []*discordgo.MessageEmbed{{Title: "ignored"}}
+	if !MessagesEqual(base(), other) {
+		t.Error("Expected the same instant in another zone and uncompared fields not to matter.")
+	}
+
+	changes := map[string]func(*discordgo.Message){
+		"content":   func(m *discordgo.Message) { m.Content = "bye" },
+		"author":    func(m *discordgo.Message) { m.Author = nil },
+		"timestamp": func(m *discordgo.Message) { m.Timestamp = edited },
+		"edited":    func(m *discordgo.Message) { m.EditedTimestamp = nil },
+	}
+	for name, change := range changes {
+		other := base()
+		change(other)
+		if MessagesEqual(base(), other) {
+			t.Errorf("Expected messages differing in %s not to be equal.", name)
+		}
+	}
+
+	if !MessagesEqual(nil, nil) || MessagesEqual(base(), nil) || MessagesEqual(nil, base()) {
+		t.Error("Expected only two nil messages to be equal.")
+	}
+}
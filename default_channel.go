@@ -0,0 +1,23 @@
+package dgocacheler
+
+// SetDefaultChannel makes the add methods store messages with an empty channel ID under channelID instead of
+// rejecting them with ErrInvalidChannel. An empty channelID restores the default of rejecting them.
+func (c *MessageCache) SetDefaultChannel(channelID string) {
+	if channelID == "" {
+		c.defaultChannel.Store(nil)
+		return
+	}
+	c.defaultChannel.Store(&channelID)
+}
+
+// resolveChannel returns the channel an add should store into, substituting the default channel for an empty
+// ID. It returns ErrInvalidChannel for an empty ID without a default.
+func (c *MessageCache) resolveChannel(channelID string) (string, error) {
+	if channelID != "" {
+		return channelID, nil
+	}
+	if fallback := c.defaultChannel.Load(); fallback != nil {
+		return *fallback, nil
+	}
+	return "", ErrInvalidChannel
+}
@@ -0,0 +1,33 @@
+package dgocacheler
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultChannel(t *testing.T) {
+	cache := NewMessageCache(10)
+	if err := cache.AddMessage("", testHistory(1)[0]); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel without a default, got %v", err)
+	}
+	if err := cache.AddMessages("", testHistory(2)); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel from AddMessages without a default, got %v", err)
+	}
+	if _, ok := cache.GetMessages(""); ok {
+		t.Error("Nothing should be stored under the empty channel ID.")
+	}
+
+	cache.SetDefaultChannel("orphans")
+	if err := cache.AddMessage("", testHistory(1)[0]); err != nil {
+		t.Errorf("Expected the default channel to be used, got %v", err)
+	}
+	cache.AddMessages("", testHistory(3))
+	if n, _ := cache.MessageCount("orphans"); n != 3 {
+		t.Errorf("Expected 3 messages in the default channel, got %d", n)
+	}
+
+	cache.SetDefaultChannel("")
+	if err := cache.AddMessage("", testHistory(1)[0]); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel after clearing the default, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package dgocacheler
+
+import (
+	"hash/maphash"
+	"math"
+	"math/bits"
+)
+
+// hllPrecision is the number of index bits of the HyperLogLog behind DistinctContentCountApprox: 2^12 one-byte
+// registers, for a standard error of about 1.6%.
+const hllPrecision = 12
+
+// hllSeed seeds the content hashes of DistinctContentCountApprox.
+var hllSeed = maphash.MakeSeed()
+
+// DistinctContentCount returns the exact number of distinct message contents cached in a channel, counting
+// empty content as one value. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) DistinctContentCount(channelID string) (int, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return 0, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	contents := make(map[string]struct{}, cc.size)
+	for i := 0; i < cc.size; i++ {
+		contents[cc.at(i).message.Content] = struct{}{}
+	}
+	return len(contents), nil
+}
+
+// DistinctContentCountApprox estimates DistinctContentCount with a HyperLogLog sketch in 4 KiB of memory,
+// whatever the channel size, at a standard error of about 1.6%. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) DistinctContentCountApprox(channelID string) (int, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return 0, ErrCacheMiss
+	}
+	var registers [1 << hllPrecision]uint8
+	c.rlockChannel(cc)
+	for i := 0; i < cc.size; i++ {
+		h := maphash.String(hllSeed, cc.at(i).message.Content)
+		index := h >> (64 - hllPrecision)
+		rank := uint8(bits.LeadingZeros64(h<<hllPrecision|1<<(hllPrecision-1)) + 1)
+		registers[index] = max(registers[index], rank)
+	}
+	cc.RUnlock()
+	return hllEstimate(registers[:]), nil
+}
+
+// hllEstimate computes the HyperLogLog cardinality estimate of registers, with the linear counting correction
+// for small cardinalities.
+func hllEstimate(registers []uint8) int {
+	m := float64(len(registers))
+	sum, zeros := 0.0, 0
+	for _, r := range registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
@@ -0,0 +1,52 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDistinctContentCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		content func(i int) string
+		n       int
+		want    int
+	}{
+		{"distinct", func(i int) string { return fmt.Sprint("message ", i) }, 20000, 20000},
+		{"repeated", func(int) string { return "same" }, 20000, 1},
+		{"mixed", func(i int) string { return fmt.Sprint("message ", i%3000) }, 20000, 3000},
+		{"small", func(i int) string { return fmt.Sprint(i % 7) }, 50, 7},
+	}
+	for _, tt := range tests {
+		cache := NewMessageCache(tt.n)
+		msgs := make([]*discordgo.Message, tt.n)
+		for i := range msgs {
+			msgs[i] = &discordgo.Message{ID: fmt.Sprint(i), Content: tt.content(i)}
+		}
+		cache.AddMessages("channel1", msgs)
+
+		if got, err := cache.DistinctContentCount("channel1"); err != nil || got != tt.want {
+			t.Errorf("%s: exact count %d (err %v), want %d", tt.name, got, err, tt.want)
+		}
+		approx, err := cache.DistinctContentCountApprox("channel1")
+		if err != nil {
+			t.Fatalf("%s: DistinctContentCountApprox returned error: %v", tt.name, err)
+		}
+		// Five standard errors of the sketch, with a floor of one for tiny counts.
+		if bound := math.Max(5*0.016*float64(tt.want), 1); math.Abs(float64(approx-tt.want)) > bound {
+			t.Errorf("%s: approximate count %d is outside %d±%.0f", tt.name, approx, tt.want, bound)
+		}
+	}
+
+	cache := NewMessageCache(5)
+	if _, err := cache.DistinctContentCount("unknown"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+	if _, err := cache.DistinctContentCountApprox("unknown"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package dgocacheler
+
+import "time"
+
+// DuplicateRecord describes a message dropped because its ID was already cached.
+type DuplicateRecord struct {
+	MessageID string    // MessageID is the ID of the dropped message
+	Time      time.Time // Time is when the message was dropped
+	Source    string    // Source names the method that tried to add it, such as "AddMessage" or "AddMessages"
+}
+
+// duplicateRing keeps the most recent duplicate drops of a channel.
+type duplicateRing struct {
+	records []DuplicateRecord
+	head    int // head is the position of the oldest record
+	size    int // size is the number of records
+}
+
+// WithDuplicateTracking keeps, per channel, the last n messages dropped as duplicates for LastDuplicates, and
+// logs each drop at debug level when WithLogger is set. It is meant for diagnosing messages that seem to go
+// missing, for example after a gateway resume redelivers them, and does not change which messages are dropped.
+func WithDuplicateTracking(n int) Option {
+	return func(c *MessageCache) {
+		c.duplicateTracking = n
+	}
+}
+
+// LastDuplicates returns up to n of the most recent duplicate drops of a channel, oldest first. It returns nil
+// for unknown channels and when WithDuplicateTracking is not set.
+func (c *MessageCache) LastDuplicates(channelID string, n int) []DuplicateRecord {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	ring := cc.duplicates
+	if ring == nil || n <= 0 {
+		return nil
+	}
+	n = min(n, ring.size)
+	records := make([]DuplicateRecord, n)
+	for i := range records {
+		records[i] = ring.records[(ring.head+ring.size-n+i)%len(ring.records)]
+	}
+	return records
+}
+
+// recordDuplicate counts a duplicate drop and, with WithDuplicateTracking, records and logs it. The caller must
+// hold the channel's write lock.
+func (c *MessageCache) recordDuplicate(cc *ChannelCache, messageID string, call addCall) {
+	c.duplicatesDropped.Add(1)
+	if c.duplicateTracking <= 0 {
+		return
+	}
+	if cc.duplicates == nil {
+		cc.duplicates = &duplicateRing{records: make([]DuplicateRecord, c.duplicateTracking)}
+	}
+	ring := cc.duplicates
+	record := DuplicateRecord{MessageID: messageID, Time: c.now(), Source: call.source}
+	if ring.size == len(ring.records) {
+		ring.records[ring.head] = record
+		ring.head = (ring.head + 1) % len(ring.records)
+	} else {
+		ring.records[(ring.head+ring.size)%len(ring.records)] = record
+		ring.size++
+	}
+	if call.logger != nil {
+		call.logger.Debug("dgocacheler: dropped duplicate message", "channel_id", cc.id, "message_id", messageID, "source", call.source)
+	}
+}
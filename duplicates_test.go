@@ -0,0 +1,53 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateTracking(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cache := NewMessageCache(10, WithDuplicateTracking(2), WithLogger(logger))
+	history := testHistory(3)
+	cache.AddMessages("channel1", history)
+
+	cache.AddMessage("channel1", history[0])
+	cache.AddMessages("channel1", history[1:])
+	records := cache.LastDuplicates("channel1", 5)
+	if len(records) != 2 {
+		t.Fatalf("Expected the ring to keep the last 2 drops, got %d", len(records))
+	}
+	var got []string
+	for _, record := range records {
+		got = append(got, record.MessageID+":"+record.Source)
+		if record.Time.IsZero() {
+			t.Error("Expected drops to be timestamped.")
+		}
+	}
+	if fmt.Sprint(got) != "[101:AddMessages 102:AddMessages]" {
+		t.Errorf("Unexpected records: %v", got)
+	}
+	if records := cache.LastDuplicates("channel1", 1); len(records) != 1 || records[0].MessageID != "102" {
+		t.Errorf("Expected the newest drop, got %v", records)
+	}
+	if got := cache.Stats().DuplicatesDropped; got != 3 {
+		t.Errorf("Expected 3 dropped duplicates, got %d", got)
+	}
+	if n := strings.Count(logs.String(), "dropped duplicate message"); n != 3 {
+		t.Errorf("Expected a debug line per drop, got %d", n)
+	}
+
+	untracked := NewMessageCache(10)
+	untracked.AddMessages("channel1", history)
+	untracked.AddMessages("channel1", history)
+	if records := untracked.LastDuplicates("channel1", 5); records != nil {
+		t.Errorf("Expected no records without WithDuplicateTracking, got %v", records)
+	}
+	if records := cache.LastDuplicates("unknown", 5); records != nil {
+		t.Errorf("Expected no records for unknown channels, got %v", records)
+	}
+}
@@ -0,0 +1,131 @@
+package dgocacheler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// SetEditCoalesceWindow coalesces rapid edits. An UpdateMessage arriving within d of the previous update of the
+// same message is not stored right away: it overwrites a pending version instead, and the final version is
+// stored once no update has arrived for d, or on FlushEdits or Close. Until then reads see the last stored
+// version, and pending versions of channels sealed in the meantime are dropped. The window is measured with the
+// clock set by WithClock. A d of zero or less, the default, stores every update immediately and flushes any
+// pending edits.
+func (c *MessageCache) SetEditCoalesceWindow(d time.Duration) {
+	c.edits.mu.Lock()
+	c.edits.window = max(d, 0)
+	c.edits.mu.Unlock()
+	if d <= 0 {
+		c.FlushEdits()
+	}
+}
+
+// FlushEdits stores all pending coalesced edits now, without waiting for their windows to settle.
+func (c *MessageCache) FlushEdits() {
+	c.applyEdits(c.edits.take(func(time.Time) bool { return true }))
+}
+
+// editKey identifies a message across channels.
+type editKey struct {
+	channelID string
+	messageID string
+}
+
+// editCoalescer tracks recent updates per message and the versions held back by edit coalescing.
+type editCoalescer struct {
+	mu      sync.Mutex
+	window  time.Duration                  // window is the coalescing window, 0 when coalescing is off
+	last    map[editKey]time.Time          // last holds the time of the latest update of each recently updated message
+	pending map[editKey]*discordgo.Message // pending holds the newest held-back version of each message
+	timer   *time.Timer                    // timer settles pending edits, nil when none is scheduled
+}
+
+// coalesceEdit records an update of message at now, returning true if it was held back as a pending version.
+// settle is scheduled to run once the window passed.
+func (e *editCoalescer) coalesceEdit(channelID string, message *discordgo.Message, now time.Time, settle func()) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.window <= 0 {
+		return false
+	}
+	key := editKey{channelID: channelID, messageID: message.ID}
+	previous, recent := e.last[key]
+	if e.last == nil {
+		e.last = make(map[editKey]time.Time)
+		e.pending = make(map[editKey]*discordgo.Message)
+	}
+	e.last[key] = now
+	if e.timer == nil {
+		e.timer = time.AfterFunc(e.window, settle)
+	}
+	if !recent || now.Sub(previous) >= e.window {
+		delete(e.pending, key) // superseded by this update, which is stored right away
+		return false
+	}
+	e.pending[key] = message
+	return true
+}
+
+// take removes and returns the pending versions whose latest update time satisfies settled, forgetting the
+// update times that are no longer within the window.
+func (e *editCoalescer) take(settled func(last time.Time) bool) map[editKey]*discordgo.Message {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	taken := make(map[editKey]*discordgo.Message)
+	for key, last := range e.last {
+		if !settled(last) {
+			continue
+		}
+		if message, ok := e.pending[key]; ok {
+			taken[key] = message
+			delete(e.pending, key)
+		}
+		delete(e.last, key)
+	}
+	return taken
+}
+
+// settleEdits stores the pending edits whose window passed and reschedules itself while updates are tracked.
+func (c *MessageCache) settleEdits() {
+	now := c.now()
+	c.edits.mu.Lock()
+	window := c.edits.window
+	c.edits.mu.Unlock()
+	c.applyEdits(c.edits.take(func(last time.Time) bool { return now.Sub(last) >= window }))
+
+	c.edits.mu.Lock()
+	defer c.edits.mu.Unlock()
+	c.edits.timer = nil
+	if len(c.edits.last) > 0 && c.edits.window > 0 {
+		c.edits.timer = time.AfterFunc(c.edits.window, c.settleEdits)
+	}
+}
+
+// applyEdits stores pending versions in place. Messages that were removed and channels that were sealed in the
+// meantime are skipped.
+func (c *MessageCache) applyEdits(edits map[editKey]*discordgo.Message) {
+	for key, message := range edits {
+		cc, ok := c.channelCache(key.channelID)
+		if !ok {
+			continue
+		}
+		c.lockChannel(cc)
+		if i := cc.find(key.messageID); i >= 0 && !cc.sealed {
+			c.updateAt(cc, i, message)
+		}
+		cc.Unlock()
+	}
+	c.enforceGlobalCaps()
+}
+
+// stopEdits stops settling pending edits on a timer.
+func (e *editCoalescer) stopEdits() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+}
@@ -0,0 +1,115 @@
+package dgocacheler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEditCoalesceWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cache := NewMessageCache(10, WithClock(clock.Now))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1", Content: "v0"})
+	cache.SetEditCoalesceWindow(time.Second)
+	defer cache.Close()
+
+	content := func() string {
+		msg, _ := cache.GetMessage("channel1", "1")
+		return msg.Content
+	}
+
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v1"})
+	if got := content(); got != "v1" {
+		t.Errorf("Expected the first update to be stored right away, got %q", got)
+	}
+	for _, version := range []string{"v2", "v3", "v4"} {
+		clock.Advance(200 * time.Millisecond)
+		cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: version})
+	}
+	if got := content(); got != "v1" {
+		t.Errorf("Expected rapid edits to be held back, got %q", got)
+	}
+
+	clock.Advance(time.Second)
+	cache.settleEdits()
+	if got := content(); got != "v4" {
+		t.Errorf("Expected the final version once the window settled, got %q", got)
+	}
+
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v5"})
+	if got := content(); got != "v5" {
+		t.Errorf("Expected an update outside the window to be stored right away, got %q", got)
+	}
+	clock.Advance(100 * time.Millisecond)
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v6"})
+	clock.Advance(2 * time.Second)
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v7"})
+	if got := content(); got != "v7" {
+		t.Errorf("Expected an update after the window to supersede the pending one, got %q", got)
+	}
+	cache.FlushEdits()
+	if got := content(); got != "v7" {
+		t.Errorf("Expected the superseded pending edit to be dropped, got %q", got)
+	}
+
+	clock.Advance(100 * time.Millisecond)
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v8"})
+	cache.Close()
+	if got := content(); got != "v8" {
+		t.Errorf("Expected Close to store pending edits, got %q", got)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestEditCoalesceSettlesOnTimer(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1", Content: "v0"})
+	cache.SetEditCoalesceWindow(20 * time.Millisecond)
+	defer cache.Close()
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v1"})
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v2"})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if msg, _ := cache.GetMessage("channel1", "1"); msg.Content == "v2" {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Expected the pending edit to be stored after the window passed.")
+}
+
+func TestEditCoalesceDisabled(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cache := NewMessageCache(10, WithClock(clock.Now))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1", Content: "v0"})
+	cache.SetEditCoalesceWindow(time.Second)
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v1"})
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v2"})
+	cache.SetEditCoalesceWindow(0)
+	if msg, _ := cache.GetMessage("channel1", "1"); msg.Content != "v2" {
+		t.Errorf("Expected disabling coalescing to flush pending edits, got %q", msg.Content)
+	}
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v3"})
+	if msg, _ := cache.GetMessage("channel1", "1"); msg.Content != "v3" {
+		t.Errorf("Expected updates to be stored immediately, got %q", msg.Content)
+	}
+}
+
+func TestEditCoalesceRespectsSeal(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cache := NewMessageCache(10, WithClock(clock.Now))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1", Content: "v0"})
+	cache.SetEditCoalesceWindow(time.Second)
+	defer cache.Close()
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v1"})
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "v2"})
+	cache.SealChannel("channel1")
+	cache.FlushEdits()
+	if msg, _ := cache.GetMessage("channel1", "1"); msg.Content != "v1" {
+		t.Errorf("Expected the pending edit dropped once the channel is sealed, got %q", msg.Content)
+	}
+}
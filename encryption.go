@@ -0,0 +1,94 @@
+package dgocacheler
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"os"
+)
+
+// WithSnapshotEncryption encrypts snapshots written by SaveToFile and WriteTo with AES-GCM under key, which must
+// be 16, 24 or 32 bytes long. Every snapshot gets a fresh random nonce. With a key configured, LoadFromFile and
+// ReadFrom only accept encrypted snapshots; use ReencryptSnapshot to migrate plaintext ones. A key of another
+// length is reported when the cache is created, as an ErrInvalidKey error returned by NewMessageCacheChecked and
+// passed to the WithErrorHandler handler, and snapshots then fail with the same error.
+func WithSnapshotEncryption(key []byte) Option {
+	return func(c *MessageCache) {
+		c.snapshotKey = key
+		if _, err := newGCM(key); err != nil {
+			c.invalidOption(fmt.Errorf("dgocacheler: WithSnapshotEncryption: %w", err))
+		}
+	}
+}
+
+// ReencryptSnapshot rewrites the snapshot at path from oldKey to newKey, for key rotation. A nil oldKey reads
+// a plaintext snapshot and a nil newKey writes one. The file is replaced atomically.
+func ReencryptSnapshot(path string, oldKey, newKey []byte) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	payload, err := readSnapshotBody(data, oldKey)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".reencrypt"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if _, err := writeSnapshotBody(f, payload, newKey); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// newGCM creates an AES-GCM cipher for key, validating its length.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("%w: got %d bytes, want 16, 24 or 32", ErrInvalidKey, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext under key, prefixing the random nonce.
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a nonce-prefixed ciphertext produced by seal.
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, fmt.Errorf("%w: truncated ciphertext", ErrSnapshotDecrypt)
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSnapshotDecrypt, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,113 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	testKey      = bytes.Repeat([]byte{1}, 32)
+	testOtherKey = bytes.Repeat([]byte{2}, 32)
+)
+
+func TestSnapshotEncryptionRoundTrip(t *testing.T) {
+	cache := NewMessageCache(10, WithSnapshotEncryption(testKey))
+	cache.AddMessages("channel1", testHistory(3))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "200", Content: "secret content"})
+
+	var buf bytes.Buffer
+	if _, err := cache.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("secret content")) {
+		t.Error("Encrypted snapshot should not contain plaintext message content.")
+	}
+
+	restored := NewMessageCache(10, WithSnapshotEncryption(testKey))
+	if _, err := restored.ReadFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("ReadFrom returned error: %v", err)
+	}
+	if n, _ := restored.MessageCount("channel1"); n != 4 {
+		t.Errorf("Expected 4 restored messages, got %d", n)
+	}
+
+	wrongKey := NewMessageCache(10, WithSnapshotEncryption(testOtherKey))
+	if _, err := wrongKey.ReadFrom(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrSnapshotDecrypt) {
+		t.Errorf("Expected ErrSnapshotDecrypt with the wrong key, got %v", err)
+	}
+	truncated := buf.Bytes()[:buf.Len()-5]
+	if _, err := restored.ReadFrom(bytes.NewReader(truncated)); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("Expected ErrSnapshotCorrupt for truncated ciphertext, got %v", err)
+	}
+	if _, err := NewMessageCache(10).ReadFrom(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrSnapshotEncrypted) {
+		t.Errorf("Expected ErrSnapshotEncrypted without a key, got %v", err)
+	}
+}
+
+func TestSnapshotEncryptionRejectsPlaintext(t *testing.T) {
+	plain := NewMessageCache(10)
+	plain.AddMessages("channel1", testHistory(2))
+	var buf bytes.Buffer
+	plain.WriteTo(&buf)
+
+	cache := NewMessageCache(10, WithSnapshotEncryption(testKey))
+	if _, err := cache.ReadFrom(&buf); !errors.Is(err, ErrSnapshotNotEncrypted) {
+		t.Errorf("Expected ErrSnapshotNotEncrypted, got %v", err)
+	}
+}
+
+func TestSnapshotEncryptionInvalidKey(t *testing.T) {
+	var reported error
+	cache := NewMessageCache(10, WithSnapshotEncryption([]byte("short")), WithErrorHandler(func(err error) { reported = err }))
+	if !errors.Is(reported, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey reported at creation, got %v", reported)
+	}
+	if _, err := cache.WriteTo(&bytes.Buffer{}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+
+	if _, err := NewMessageCacheChecked(10, WithSnapshotEncryption([]byte("short"))); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey from NewMessageCacheChecked, got %v", err)
+	}
+	if _, err := NewMessageCacheChecked(10, WithSnapshotEncryption(testKey)); err != nil {
+		t.Errorf("Expected a valid key accepted, got %v", err)
+	}
+}
+
+func TestReencryptSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	plain := NewMessageCache(10)
+	plain.AddMessages("channel1", testHistory(3))
+	if err := plain.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile returned error: %v", err)
+	}
+
+	if err := ReencryptSnapshot(path, nil, testKey); err != nil {
+		t.Fatalf("Migrating to encryption returned error: %v", err)
+	}
+	if err := ReencryptSnapshot(path, testKey, testOtherKey); err != nil {
+		t.Fatalf("Rotating the key returned error: %v", err)
+	}
+	if err := ReencryptSnapshot(path, testKey, testOtherKey); !errors.Is(err, ErrSnapshotDecrypt) {
+		t.Errorf("Expected ErrSnapshotDecrypt with the retired key, got %v", err)
+	}
+
+	cache := NewMessageCache(10, WithSnapshotEncryption(testOtherKey))
+	if err := cache.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile returned error: %v", err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 3 {
+		t.Errorf("Expected 3 messages after rotation, got %d", n)
+	}
+	if matches, _ := filepath.Glob(path + ".*"); len(matches) != 0 {
+		t.Errorf("Temporary files left behind: %v", matches)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Snapshot missing after rotation: %v", err)
+	}
+}
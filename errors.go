@@ -0,0 +1,121 @@
+package dgocacheler
+
+import (
+	"errors"
+	"maps"
+	"slices"
+)
+
+// ErrInvalidLimit is returned when a limit or count argument is out of range.
+var ErrInvalidLimit = errors.New("dgocacheler: invalid limit")
+
+// ErrInvalidCursor is returned by BackfillFrom for a cursor that is not a message ID.
+var ErrInvalidCursor = errors.New("dgocacheler: invalid backfill cursor")
+
+// ErrSuppressedDuplicate is returned when a message is rejected as a near-duplicate of a recent message.
+var ErrSuppressedDuplicate = errors.New("dgocacheler: suppressed duplicate message")
+
+// ErrCacheMiss is returned when the requested channel is not in the cache.
+var ErrCacheMiss = errors.New("dgocacheler: channel not cached")
+
+// ErrInvalidMaxMessages is returned when a channel capacity is not positive.
+var ErrInvalidMaxMessages = errors.New("dgocacheler: invalid max messages")
+
+// ErrMessageNotFound is returned when the requested message is not in the cache.
+var ErrMessageNotFound = errors.New("dgocacheler: message not cached")
+
+// ErrEmptyChannel is returned when an operation needs a message but the channel holds none.
+var ErrEmptyChannel = errors.New("dgocacheler: channel is empty")
+
+// ErrReadPositionEvicted is returned by GetUnread, together with every cached message, when the consumer's
+// last-read message is no longer cached, so messages between it and the oldest cached one may have been missed.
+var ErrReadPositionEvicted = errors.New("dgocacheler: read position was evicted")
+
+// ErrUnsupported is returned when an operation is not available in the cache's configured mode.
+var ErrUnsupported = errors.New("dgocacheler: operation not supported in this mode")
+
+// ErrChannelExists is returned when a channel ID that must be free is already cached.
+var ErrChannelExists = errors.New("dgocacheler: channel already cached")
+
+// ErrDedupDisabled is returned by ID-based lookups when the cache was created WithoutDedup.
+var ErrDedupDisabled = errors.New("dgocacheler: message ID tracking is disabled")
+
+// ErrSnapshotFormat is returned when data is not a snapshot this version of the package can read.
+var ErrSnapshotFormat = errors.New("dgocacheler: invalid snapshot format")
+
+// ErrSnapshotEncrypted is returned when loading an encrypted snapshot without a key configured.
+var ErrSnapshotEncrypted = errors.New("dgocacheler: snapshot is encrypted but no key is configured")
+
+// ErrSnapshotNotEncrypted is returned when loading a plaintext snapshot while a key is configured.
+var ErrSnapshotNotEncrypted = errors.New("dgocacheler: snapshot is not encrypted but a key is configured")
+
+// ErrSnapshotDecrypt is returned when an encrypted snapshot cannot be decrypted, because the key is wrong or
+// the data was truncated or tampered with.
+var ErrSnapshotDecrypt = errors.New("dgocacheler: snapshot decryption failed")
+
+// ErrInvalidKey is returned when an encryption key has an unsupported length.
+var ErrInvalidKey = errors.New("dgocacheler: invalid encryption key")
+
+// ErrSnapshotCorrupt is returned when a snapshot fails its checksum or decodes into an inconsistent cache.
+var ErrSnapshotCorrupt = errors.New("dgocacheler: snapshot is corrupt")
+
+// ErrInvariant is returned by Validate when the internal state of a channel is inconsistent.
+var ErrInvariant = errors.New("dgocacheler: cache invariant violated")
+
+// ErrPaused is returned by the add methods for dropped messages while ingestion is paused, when
+// WithPauseErrors is set.
+var ErrPaused = errors.New("dgocacheler: ingestion is paused")
+
+// ErrChannelFrozen is returned when an operation would discard the contents of a frozen channel.
+var ErrChannelFrozen = errors.New("dgocacheler: channel is frozen")
+
+// ErrChannelSealed is returned when writing to a channel sealed by SealChannel.
+var ErrChannelSealed = errors.New("dgocacheler: channel is sealed")
+
+// ErrInvalidChannel is returned when adding messages with an empty channel ID and no default channel is set.
+var ErrInvalidChannel = errors.New("dgocacheler: invalid channel ID")
+
+// ErrInvalidBounds is returned when histogram bucket bounds are not in strictly ascending order.
+var ErrInvalidBounds = errors.New("dgocacheler: invalid bucket bounds")
+
+// ErrChannelGone is returned by a ChannelHandle whose channel was deleted, replaced, renamed or evicted.
+var ErrChannelGone = errors.New("dgocacheler: channel is no longer cached")
+
+// ErrChannelFull is returned in strict capacity mode when adding to a channel that holds its maximum number of
+// messages.
+var ErrChannelFull = errors.New("dgocacheler: channel is full")
+
+// ChannelError attributes an error of a batch operation to one channel. Batch operations that fail for several
+// channels return the ChannelErrors combined with errors.Join, so errors.Is and errors.As find the sentinels and
+// channels inside.
+type ChannelError struct {
+	ChannelID string
+	Err       error
+}
+
+// Error implements error.
+func (e *ChannelError) Error() string {
+	return "channel " + e.ChannelID + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ChannelError) Unwrap() error {
+	return e.Err
+}
+
+// joinChannelErrors combines per-channel errors, ordered by channel ID, into one error. It returns nil when
+// errs is empty.
+func joinChannelErrors(errs map[string]error) error {
+	channelIDs := slices.Sorted(maps.Keys(errs))
+	joined := make([]error, len(channelIDs))
+	for i, channelID := range channelIDs {
+		joined[i] = &ChannelError{ChannelID: channelID, Err: errs[channelID]}
+	}
+	return errors.Join(joined...)
+}
+
+// ErrWriterClosed is returned when adding to a BatchWriter after Close.
+var ErrWriterClosed = errors.New("dgocacheler: batch writer is closed")
+
+// ErrViewClosed is returned when a ChannelView is used after the Update call that handed it out returned.
+var ErrViewClosed = errors.New("dgocacheler: channel view used outside Update")
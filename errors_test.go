@@ -0,0 +1,23 @@
+package dgocacheler
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJoinChannelErrors(t *testing.T) {
+	if err := joinChannelErrors(nil); err != nil {
+		t.Errorf("Expected nil for no failures, got %v", err)
+	}
+	err := joinChannelErrors(map[string]error{"c": ErrCacheMiss, "a": ErrInvalidChannel})
+	if got, want := err.Error(), "channel a: "+ErrInvalidChannel.Error()+"\nchannel c: "+ErrCacheMiss.Error(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrCacheMiss) || !errors.Is(err, ErrInvalidChannel) {
+		t.Error("Expected the aggregate to unwrap to both sentinels.")
+	}
+	var channelErr *ChannelError
+	if !errors.As(err, &channelErr) || channelErr.ChannelID != "a" {
+		t.Errorf("Expected the first *ChannelError to be for channel a, got %v", channelErr)
+	}
+}
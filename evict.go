@@ -0,0 +1,125 @@
+package dgocacheler
+
+import "github.com/bwmarrin/discordgo"
+
+// EvictReason tells why a message was evicted.
+type EvictReason int
+
+const (
+	// EvictCapacity means the channel was full, or its capacity was lowered.
+	EvictCapacity EvictReason = iota
+	// EvictAuthorCap means the author reached the WithPerAuthorCap limit in the channel.
+	EvictAuthorCap
+	// EvictManual means the message was trimmed by EvictOldest or KeepLast.
+	EvictManual
+	// EvictExpired means the message aged past its guild's GuildPolicy MaxAge.
+	EvictExpired
+	// EvictUserRetention means the author went over the SetPerUserRetention cap.
+	EvictUserRetention
+	// EvictForgotten means the author was erased by ForgetUser or ForgetUserInGuild. Such messages are never
+	// passed to the spill handler.
+	EvictForgotten
+	// EvictReclaimed means the message was outside the SetHotWindow window while the cache was over its soft
+	// memory limit.
+	EvictReclaimed
+	// EvictGlobalLRU means the message was the least recently used while the cache was over its
+	// SetGlobalMaxMessages cap.
+	EvictGlobalLRU
+)
+
+// String returns a short name for the reason.
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictAuthorCap:
+		return "author_cap"
+	case EvictManual:
+		return "manual"
+	case EvictExpired:
+		return "expired"
+	case EvictUserRetention:
+		return "user_retention"
+	case EvictForgotten:
+		return "forgotten"
+	case EvictReclaimed:
+		return "reclaimed"
+	case EvictGlobalLRU:
+		return "global_lru"
+	}
+	return "unknown"
+}
+
+// EvictFunc is called for each message evicted from a channel.
+type EvictFunc func(channelID string, message *discordgo.Message, reason EvictReason)
+
+// OnEvict registers fn to be called for every message evicted, whether by a full channel or a lowered capacity
+// (EvictCapacity), WithPerAuthorCap (EvictAuthorCap), EvictOldest and KeepLast (EvictManual), a guild's MaxAge
+// (EvictExpired), SetPerUserRetention (EvictUserRetention), ForgetUser (EvictForgotten), memory reclamation
+// (EvictReclaimed) or SetGlobalMaxMessages (EvictGlobalLRU). Messages removed by ID or cleared are not reported.
+// fn runs while the channel lock is held, so it must be fast and must not call back into the cache.
+func OnEvict(fn EvictFunc) Option {
+	return func(c *MessageCache) {
+		c.onEvict = fn
+	}
+}
+
+// EvictOldest removes the n oldest messages of a channel, or all of them if it holds fewer, and returns how many
+// were removed. The channel keeps its capacity. It returns ErrInvalidLimit if n is not positive and ErrCacheMiss
+// for unknown channels.
+func (c *MessageCache) EvictOldest(channelID string, n int) (int, error) {
+	return c.trim("EvictOldest", channelID, n, func(size int) int { return min(n, size) })
+}
+
+// KeepLast trims a channel down to its n newest messages and returns how many were removed. The channel keeps
+// its capacity. It returns ErrInvalidLimit if n is not positive and ErrCacheMiss for unknown channels.
+func (c *MessageCache) KeepLast(channelID string, n int) (int, error) {
+	return c.trim("KeepLast", channelID, n, func(size int) int { return max(size-n, 0) })
+}
+
+// trim evicts the oldest messages of a channel for op, as many as count returns for the current size.
+func (c *MessageCache) trim(op, channelID string, n int, count func(size int) int) (int, error) {
+	if n <= 0 {
+		return 0, ErrInvalidLimit
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return 0, ErrCacheMiss
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if c.skipFrozen(cc) {
+		return 0, nil
+	}
+	evicted := count(cc.size)
+	for i := 0; i < evicted; i++ {
+		cc.evictAt(0, EvictManual)
+	}
+	c.auditRemoval(op, cc, evicted)
+	return evicted, nil
+}
+
+// evictHook returns the function channels call for each evicted message, combining OnEvict with the spill
+// handler, or nil when neither is configured.
+func (c *MessageCache) evictHook() EvictFunc {
+	switch {
+	case c.spill == nil && c.onEvict == nil:
+		return nil
+	case c.spill == nil:
+		return func(channelID string, message *discordgo.Message, reason EvictReason) {
+			c.guard("OnEvict", func() { c.onEvict(channelID, message, reason) })
+		}
+	case c.onEvict == nil:
+		return func(channelID string, message *discordgo.Message, reason EvictReason) {
+			if reason != EvictForgotten {
+				c.spill.add(channelID, message)
+			}
+		}
+	}
+	return func(channelID string, message *discordgo.Message, reason EvictReason) {
+		c.guard("OnEvict", func() { c.onEvict(channelID, message, reason) })
+		if reason != EvictForgotten {
+			c.spill.add(channelID, message)
+		}
+	}
+}
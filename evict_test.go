@@ -0,0 +1,71 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEvictOldest(t *testing.T) {
+	var evicted []string
+	cache := NewMessageCache(5, OnEvict(func(channelID string, msg *discordgo.Message, reason EvictReason) {
+		evicted = append(evicted, msg.ID+":"+reason.String())
+	}))
+	cache.AddMessages("channel1", testHistory(8)) // wrapped: 103..107
+
+	if n, err := cache.EvictOldest("channel1", 2); err != nil || n != 2 {
+		t.Fatalf("Expected 2 evictions, got %d (err %v)", n, err)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[105 106 107]" {
+		t.Errorf("Unexpected messages after EvictOldest: %v", got)
+	}
+	if got := fmt.Sprint(evicted[3:]); got != "[103:manual 104:manual]" {
+		t.Errorf("Expected manual eviction callbacks, got %v", got)
+	}
+	if ok, _ := cache.Contains("channel1", "103"); ok {
+		t.Error("Evicted IDs should be forgotten by dedup.")
+	}
+	cache.AddMessage("channel1", testHistory(4)[3])
+	if n, _ := cache.MessageCount("channel1"); n != 4 {
+		t.Errorf("Expected an evicted ID to be accepted again, got %d messages", n)
+	}
+
+	if n, _ := cache.EvictOldest("channel1", 10); n != 4 {
+		t.Errorf("Expected n >= size to empty the channel, got %d evictions", n)
+	}
+	if _, err := cache.EvictOldest("channel1", 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+	if _, err := cache.EvictOldest("missing", 1); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestKeepLast(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(7))
+
+	if n, err := cache.KeepLast("channel1", 2); err != nil || n != 3 {
+		t.Fatalf("Expected 3 evictions, got %d (err %v)", n, err)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[105 106]" {
+		t.Errorf("Unexpected messages after KeepLast: %v", got)
+	}
+	if n, _ := cache.KeepLast("channel1", 5); n != 0 {
+		t.Errorf("Expected nothing to be evicted when n >= size, got %d", n)
+	}
+	cache.AddMessages("channel1", testHistory(12)[7:])
+	if stats, _ := cache.ChannelStats("channel1"); stats.Messages != 5 || stats.MaxMessages != 5 {
+		t.Errorf("Expected KeepLast to leave the capacity unchanged, got %+v", stats)
+	}
+	if _, err := cache.KeepLast("channel1", -1); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package dgocacheler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// defaultExportCheckEvery is how many messages ExportAll writes between context checks by default.
+const defaultExportCheckEvery = 1000
+
+// ExportOptions configures ExportAll.
+type ExportOptions struct {
+	After      string             // After resumes an export: channels with IDs up to and including it are skipped
+	CheckEvery int                // CheckEvery is the number of messages between context checks, 1000 if not positive
+	Progress   func(ExportReport) // Progress is called after every channel and every CheckEvery messages
+}
+
+// ExportReport tells how far ExportAll got.
+type ExportReport struct {
+	Channels      int    // Channels is the number of channels written completely
+	Messages      int    // Messages is the number of messages written, including those of an unfinished channel
+	Bytes         int64  // Bytes is the number of bytes written
+	LastChannelID string // LastChannelID is the last channel written completely, to pass as ExportOptions.After to resume
+}
+
+// exportHeader is the line starting each channel of an export.
+type exportHeader struct {
+	ChannelID string `json:"channel_id"`
+	Messages  int    `json:"messages"` // Messages is the number of message lines that follow
+}
+
+// ExportAll streams every channel to w as JSON lines, in channel ID order: a header line with the channel ID
+// and message count, then one line per message in chronological order. With SetCodec, a message line is the
+// codec's bytes as a base64 JSON string instead of the message JSON. Channels are copied one at a time under a
+// brief read lock, so no more than one channel's messages are held at once and writing to a slow w never
+// blocks the cache.
+//
+// ctx is checked before every channel and every CheckEvery messages. When ctx is done or a write fails,
+// ExportAll stops and returns the error with a report of what was written; the stream then ends with a partial
+// channel, if any, after the one named by LastChannelID. Passing LastChannelID as ExportOptions.After to a new
+// export resumes with the next channel. Channels dropped while the export runs are skipped.
+func (c *MessageCache) ExportAll(ctx context.Context, w io.Writer, opts ExportOptions) (report ExportReport, err error) {
+	counter := &countingWriter{w: w}
+	ctx, span := c.startSpan(ctx, "ExportAll")
+	defer func() {
+		report.Bytes = counter.n
+		span.SetAttribute(AttrResultCount, report.Messages)
+		endSpan(span, err)
+	}()
+
+	c.profileOp(ctx, "export", "", func(ctx context.Context) {
+		err = c.exportAll(ctx, counter, opts, &report)
+	})
+	return report, err
+}
+
+// exportAll implements ExportAll, writing to counter and recording what was written in report.
+func (c *MessageCache) exportAll(ctx context.Context, counter *countingWriter, opts ExportOptions, report *ExportReport) error {
+	checkEvery := opts.CheckEvery
+	if checkEvery <= 0 {
+		checkEvery = defaultExportCheckEvery
+	}
+	enc := json.NewEncoder(counter)
+	codec := c.codec.Load()
+	progress := func() {
+		if opts.Progress != nil {
+			report.Bytes = counter.n
+			c.guard("ExportOptions.Progress", func() { opts.Progress(*report) })
+		}
+	}
+
+	c.rlockGlobal()
+	channelIDs := slices.Sorted(maps.Keys(c.messages))
+	c.RUnlock()
+	for _, channelID := range channelIDs {
+		if opts.After != "" && channelID <= opts.After {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msgs, ok := c.exportChannel(channelID)
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(exportHeader{ChannelID: channelID, Messages: len(msgs)}); err != nil {
+			return err
+		}
+		for i, msg := range msgs {
+			if i > 0 && i%checkEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				progress()
+			}
+			if err := encodeExported(enc, codec, msg); err != nil {
+				return err
+			}
+			report.Messages++
+		}
+		report.Channels++
+		report.LastChannelID = channelID
+		progress()
+	}
+	return nil
+}
+
+// exportChannel copies the messages of a channel, reporting false if it is no longer cached.
+func (c *MessageCache) exportChannel(channelID string) ([]*discordgo.Message, bool) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, false
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	return cc.messages(), true
+}
+
+// encodeExported writes the line of one message, encoded with codec when it is set.
+func encodeExported(enc *json.Encoder, codec *codecFuncs, msg *discordgo.Message) error {
+	if codec == nil {
+		return enc.Encode(msg)
+	}
+	data, err := codec.encode(msg)
+	if err != nil {
+		return fmt.Errorf("dgocacheler: encoding message %s: %w", msg.ID, err)
+	}
+	return enc.Encode(data)
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write implements io.Writer.
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,150 @@
+package dgocacheler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// readExport decodes an export stream into messages per channel, in stream order.
+func readExport(t *testing.T, r io.Reader) ([]string, map[string][]*discordgo.Message) {
+	t.Helper()
+	var order []string
+	channels := make(map[string][]*discordgo.Message)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var header exportHeader
+		if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.ChannelID == "" {
+			t.Fatalf("Expected a channel header, got %q", scanner.Text())
+		}
+		order = append(order, header.ChannelID)
+		msgs := []*discordgo.Message{}
+		for i := 0; i < header.Messages; i++ {
+			if !scanner.Scan() {
+				t.Fatalf("Channel %s ended after %d of %d messages", header.ChannelID, i, header.Messages)
+			}
+			var msg discordgo.Message
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+				t.Fatalf("Failed to decode message line: %v", err)
+			}
+			msgs = append(msgs, &msg)
+		}
+		channels[header.ChannelID] = msgs
+	}
+	return order, channels
+}
+
+func TestExportAllStreamsChannelsInOrder(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("b", &discordgo.Message{ID: "3", Content: "three"})
+	cache.AddMessage("a", &discordgo.Message{ID: "1", Content: "one"})
+	cache.AddMessage("a", &discordgo.Message{ID: "2", Content: "two"})
+	cache.Channel("empty")
+
+	var buf bytes.Buffer
+	report, err := cache.ExportAll(context.Background(), &buf, ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportAll failed: %v", err)
+	}
+	want := ExportReport{Channels: 3, Messages: 3, Bytes: int64(buf.Len()), LastChannelID: "empty"}
+	if report != want {
+		t.Errorf("Expected report %+v, got %+v", want, report)
+	}
+	order, channels := readExport(t, &buf)
+	if fmt.Sprint(order) != "[a b empty]" {
+		t.Errorf("Expected channels in ID order, got %v", order)
+	}
+	if a := channels["a"]; len(a) != 2 || a[0].ID != "1" || a[1].Content != "two" {
+		t.Errorf("Expected channel a in chronological order, got %v", a)
+	}
+	if len(channels["empty"]) != 0 {
+		t.Errorf("Expected the empty channel to have no messages, got %d", len(channels["empty"]))
+	}
+}
+
+func TestExportAllResumesAfterWriterError(t *testing.T) {
+	cache := NewMessageCache(10)
+	for i := 0; i < 4; i++ {
+		cache.AddMessage(fmt.Sprintf("channel%d", i), &discordgo.Message{ID: fmt.Sprint(i + 1)})
+	}
+	var full bytes.Buffer
+	cache.ExportAll(context.Background(), &full, ExportOptions{})
+
+	writeErr := errors.New("broken pipe")
+	first := &truncatingWriter{limit: full.Len() / 2, err: writeErr}
+	report, err := cache.ExportAll(context.Background(), first, ExportOptions{})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("Expected the writer error, got %v", err)
+	}
+	if report.Channels == 0 || report.Channels == 4 || report.Bytes != int64(first.buf.Len()) {
+		t.Fatalf("Expected a partial report matching the bytes written, got %+v", report)
+	}
+
+	var rest bytes.Buffer
+	if _, err := cache.ExportAll(context.Background(), &rest, ExportOptions{After: report.LastChannelID}); err != nil {
+		t.Fatalf("Resumed export failed: %v", err)
+	}
+	complete := full.String()[:bytes.Index(full.Bytes(), []byte(`{"channel_id":"channel`+fmt.Sprint(report.Channels)))]
+	if !bytes.HasPrefix(first.buf.Bytes(), []byte(complete)) {
+		t.Errorf("Expected the interrupted export to start with the completed channels, got %q", first.buf.String())
+	}
+	if got := complete + rest.String(); got != full.String() {
+		t.Errorf("Expected the completed channels plus the resumed export to match a full export:\n%s\nvs\n%s", got, full.String())
+	}
+}
+
+func TestExportAllChecksContextAndReportsProgress(t *testing.T) {
+	cache := NewMessageCache(100)
+	for i := 1; i <= 25; i++ {
+		cache.AddMessage("a", &discordgo.Message{ID: fmt.Sprint(i)})
+		cache.AddMessage("b", &discordgo.Message{ID: fmt.Sprint(100 + i)})
+	}
+
+	var reports []ExportReport
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	report, err := cache.ExportAll(ctx, io.Discard, ExportOptions{CheckEvery: 10, Progress: func(r ExportReport) {
+		reports = append(reports, r)
+		if r.Channels == 1 && r.Messages == 35 {
+			cancel()
+		}
+	}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if report.Channels != 1 || report.Messages != 45 || report.LastChannelID != "a" {
+		t.Errorf("Expected the export to stop within channel b, got %+v", report)
+	}
+	var messages []int
+	for _, r := range reports {
+		messages = append(messages, r.Messages)
+		if r.Bytes <= 0 {
+			t.Errorf("Expected progress to report bytes written, got %+v", r)
+		}
+	}
+	if fmt.Sprint(messages) != "[10 20 25 35]" {
+		t.Errorf("Expected progress every 10 messages and after each channel, got %v", messages)
+	}
+}
+
+// truncatingWriter accepts limit bytes, then fails with err.
+type truncatingWriter struct {
+	buf   bytes.Buffer
+	limit int
+	err   error
+}
+
+func (w *truncatingWriter) Write(p []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); len(p) > room {
+		w.buf.Write(p[:room])
+		return room, w.err
+	}
+	return w.buf.Write(p)
+}
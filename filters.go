@@ -0,0 +1,33 @@
+package dgocacheler
+
+import (
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// GetMessagesByType retrieves the messages of a channel whose Type is one of types, oldest first.
+// With no types, all messages are returned. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) GetMessagesByType(channelID string, types ...discordgo.MessageType) ([]*discordgo.Message, error) {
+	return c.filterMessages(channelID, func(msg *discordgo.Message) bool {
+		return len(types) == 0 || slices.Contains(types, msg.Type)
+	})
+}
+
+// filterMessages returns the messages of a channel that satisfy keep, in chronological order.
+// It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) filterMessages(channelID string, keep func(*discordgo.Message) bool) ([]*discordgo.Message, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	msgs := []*discordgo.Message{}
+	for i := 0; i < cc.size; i++ {
+		if msg := cc.at(i).message; keep(msg) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
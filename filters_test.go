@@ -0,0 +1,40 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGetMessagesByType(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", []*discordgo.Message{
+		{ID: "1", Type: discordgo.MessageTypeDefault},
+		{ID: "2", Type: discordgo.MessageTypeReply},
+		{ID: "3", Type: discordgo.MessageTypeGuildMemberJoin},
+		{ID: "4", Type: discordgo.MessageTypeDefault},
+		{ID: "5", Type: discordgo.MessageTypeChannelPinnedMessage},
+	})
+
+	for _, tc := range []struct {
+		types []discordgo.MessageType
+		want  string
+	}{
+		{[]discordgo.MessageType{discordgo.MessageTypeDefault}, "[1 4]"},
+		{[]discordgo.MessageType{discordgo.MessageTypeReply, discordgo.MessageTypeDefault}, "[1 2 4]"},
+		{[]discordgo.MessageType{discordgo.MessageTypeGuildMemberJoin, discordgo.MessageTypeChannelPinnedMessage}, "[3 5]"},
+		{[]discordgo.MessageType{discordgo.MessageTypeCall}, "[]"},
+		{nil, "[1 2 3 4 5]"},
+	} {
+		msgs, err := cache.GetMessagesByType("channel1", tc.types...)
+		if err != nil || fmt.Sprint(messageIDs(msgs)) != tc.want {
+			t.Errorf("GetMessagesByType(%v) = %v (err %v), want %s", tc.types, messageIDs(msgs), err, tc.want)
+		}
+	}
+
+	if _, err := cache.GetMessagesByType("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
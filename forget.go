@@ -0,0 +1,138 @@
+package dgocacheler
+
+import (
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ForgetUser erases a user from the cache, for example to honour a right-to-erasure request, and returns how
+// many of the user's messages were removed. Every cached message authored by userID is removed from every
+// channel and reported to OnEvict with EvictForgotten, without reaching the spill handler. Replies keep their
+// message reference but lose the quoted copy of the user's message, and other messages drop the user from
+// their Mentions. Messages of the user held while ingestion is paused and edits held back by coalescing are
+// discarded as well. Each channel with removals is recorded in the audit log.
+//
+// Channels are processed one at a time, without holding the global lock throughout. Frozen and sealed
+// channels holding traces of the user are left untouched and reported as *ChannelError values wrapping
+// ErrChannelFrozen or ErrChannelSealed, joined with errors.Join. With WithAnonymizedAuthors, userID is the
+// original ID, which is hashed the same way. An empty userID forgets nothing.
+func (c *MessageCache) ForgetUser(userID string) (int, error) {
+	return c.forgetUser("ForgetUser", userID, "")
+}
+
+// ForgetUserInGuild is like ForgetUser but only erases the user from the channels of guildID, as attributed
+// from the guild IDs of their messages. Channels whose guild is unknown are left alone.
+func (c *MessageCache) ForgetUserInGuild(userID, guildID string) (int, error) {
+	if guildID == "" {
+		return 0, nil
+	}
+	return c.forgetUser("ForgetUserInGuild", userID, guildID)
+}
+
+// forgetUser implements ForgetUser and ForgetUserInGuild. An empty guildID covers every channel.
+func (c *MessageCache) forgetUser(op, userID, guildID string) (int, error) {
+	if userID == "" {
+		return 0, nil
+	}
+	userID = c.AnonymizedID(userID)
+	defer c.forgetHeld(userID, guildID)
+	removed := 0
+	failed := make(map[string]error)
+	for _, cc := range c.channelCaches() {
+		removed += c.forgetInChannel(op, cc, userID, guildID, failed)
+	}
+	return removed, joinChannelErrors(failed)
+}
+
+// forgetInChannel erases the user from one channel, returning how many of the user's messages were removed.
+// A channel that cannot be changed is recorded in failed.
+func (c *MessageCache) forgetInChannel(op string, cc *ChannelCache, userID, guildID string, failed map[string]error) int {
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if cc.retired || guildID != "" && cc.guildID != guildID {
+		return 0
+	}
+	traced := false
+	for i := 0; i < cc.size && !traced; i++ {
+		traced = mentionsUser(cc.at(i).message, userID)
+	}
+	switch {
+	case !traced:
+		return 0
+	case cc.frozen.Load():
+		failed[cc.id] = ErrChannelFrozen
+		return 0
+	case cc.sealed:
+		failed[cc.id] = ErrChannelSealed
+		return 0
+	}
+	removed := 0
+	for i := cc.size - 1; i >= 0; i-- {
+		entry := cc.at(i)
+		switch {
+		case messageAuthorID(entry.message) == userID:
+			cc.evictAt(i, EvictForgotten)
+			removed++
+		case mentionsUser(entry.message, userID):
+			scrubbed := c.entryFor(scrubUser(entry.message, userID), entry.insertSeq)
+			scrubbed.priority = entry.priority
+			cc.replace(i, scrubbed)
+		}
+	}
+	if removed > 0 && c.audit != nil {
+		c.audit.add(AuditRecord{Time: c.now(), Op: op, ChannelID: cc.id, GuildID: cc.guildID, UserID: userID, Count: removed})
+	}
+	return removed
+}
+
+// forgetHeld erases the user from the messages held while paused and the edits held back by coalescing.
+func (c *MessageCache) forgetHeld(userID, guildID string) {
+	inScope := func(message *discordgo.Message) bool {
+		return guildID == "" || message.GuildID == guildID
+	}
+	c.pause.mu.Lock()
+	c.pause.held = slices.DeleteFunc(c.pause.held, func(h heldMessage) bool {
+		return inScope(h.message) && messageAuthorID(h.message) == userID
+	})
+	for i, h := range c.pause.held {
+		if inScope(h.message) && mentionsUser(h.message, userID) {
+			c.pause.held[i].message = scrubUser(h.message, userID)
+		}
+	}
+	c.pause.mu.Unlock()
+
+	c.edits.mu.Lock()
+	for key, message := range c.edits.pending {
+		switch {
+		case !inScope(message):
+		case messageAuthorID(message) == userID:
+			delete(c.edits.pending, key)
+		case mentionsUser(message, userID):
+			c.edits.pending[key] = scrubUser(message, userID)
+		}
+	}
+	c.edits.mu.Unlock()
+}
+
+// mentionsUser reports whether message carries any trace of the user: its author, a quoted reply or a mention.
+func mentionsUser(message *discordgo.Message, userID string) bool {
+	if messageAuthorID(message) == userID {
+		return true
+	}
+	if ref := message.ReferencedMessage; ref != nil && messageAuthorID(ref) == userID {
+		return true
+	}
+	return slices.ContainsFunc(message.Mentions, func(u *discordgo.User) bool { return u != nil && u.ID == userID })
+}
+
+// scrubUser returns a shallow copy of a message by someone else without the quoted copy of the user's message
+// and without the user in its mentions. The original is left untouched, as readers may still hold it.
+func scrubUser(message *discordgo.Message, userID string) *discordgo.Message {
+	clone := *message
+	if ref := clone.ReferencedMessage; ref != nil && messageAuthorID(ref) == userID {
+		clone.ReferencedMessage = nil
+	}
+	clone.Mentions = slices.DeleteFunc(slices.Clone(clone.Mentions), func(u *discordgo.User) bool { return u != nil && u.ID == userID })
+	return &clone
+}
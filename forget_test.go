@@ -0,0 +1,134 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const forgottenSecret = "my home address is 221B"
+
+// forgetFixture caches messages by alice, who is forgotten, and bob, who quotes and mentions her.
+func forgetFixture(cache *MessageCache) {
+	alice := &discordgo.User{ID: "alice", Username: "alice"}
+	secret := &discordgo.Message{ID: "1", GuildID: "guild1", Author: alice, Content: forgottenSecret}
+	cache.AddMessage("channel1", secret)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "2", GuildID: "guild1", Author: &discordgo.User{ID: "bob"}, Content: "replying",
+		ReferencedMessage: secret, MessageReference: &discordgo.MessageReference{MessageID: "1"}, Mentions: []*discordgo.User{alice}})
+	cache.AddMessage("channel2", &discordgo.Message{ID: "3", GuildID: "guild2", Author: alice, Content: forgottenSecret})
+	cache.AddMessage("channel2", &discordgo.Message{ID: "4", GuildID: "guild2", Author: &discordgo.User{ID: "bob"}, Content: "hi"})
+}
+
+// assertForgotten fails if any message reachable through the read APIs carries a trace of userID.
+func assertForgotten(t *testing.T, cache *MessageCache, userID string, channelIDs ...string) {
+	t.Helper()
+	for _, channelID := range channelIDs {
+		if msgs, _ := cache.GetMessagesByAuthor(channelID, userID); len(msgs) != 0 {
+			t.Errorf("GetMessagesByAuthor still returns %d messages in %s", len(msgs), channelID)
+		}
+		if msgs, _ := cache.Query(channelID).Author(userID).Run(); len(msgs) != 0 {
+			t.Errorf("Query still returns %d messages in %s", len(msgs), channelID)
+		}
+		seq, _ := cache.IterateSnapshot(channelID)
+		for msg := range seq {
+			if mentionsUser(msg, userID) || strings.Contains(msg.Content, forgottenSecret) {
+				t.Errorf("Message %s in %s still carries a trace of %s", msg.ID, channelID, userID)
+			}
+		}
+	}
+}
+
+func TestForgetUserErasesEveryTrace(t *testing.T) {
+	var evicted []string
+	var audit bytes.Buffer
+	cache := NewMessageCache(10, WithAuditLog(&audit), OnEvict(func(channelID string, message *discordgo.Message, reason EvictReason) {
+		if reason != EvictForgotten {
+			t.Errorf("Expected reason %q, got %q", EvictForgotten, reason)
+		}
+		evicted = append(evicted, message.ID)
+	}))
+	forgetFixture(cache)
+	original, _ := cache.GetMessage("channel1", "2")
+
+	removed, err := cache.ForgetUser("alice")
+	if err != nil || removed != 2 {
+		t.Fatalf("Expected 2 messages removed, got %d, %v", removed, err)
+	}
+	slices.Sort(evicted)
+	if !slices.Equal(evicted, []string{"1", "3"}) {
+		t.Errorf("Expected OnEvict for alice's messages, got %v", evicted)
+	}
+	assertForgotten(t, cache, "alice", "channel1", "channel2")
+
+	reply, err := cache.GetMessage("channel1", "2")
+	if err != nil || reply.MessageReference == nil || reply.Content != "replying" {
+		t.Errorf("Expected bob's reply to be kept with its reference, got %+v, %v", reply, err)
+	}
+	if original.ReferencedMessage == nil || len(original.Mentions) != 1 {
+		t.Error("Expected messages already handed out to be left untouched")
+	}
+
+	var snapshot bytes.Buffer
+	if _, err := cache.WriteTo(&snapshot); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	if bytes.Contains(snapshot.Bytes(), []byte(forgottenSecret)) {
+		t.Error("Expected snapshots taken afterwards not to contain the user's content")
+	}
+	restored := NewMessageCache(10)
+	if _, err := restored.ReadFrom(&snapshot); err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	assertForgotten(t, restored, "alice", "channel1", "channel2")
+
+	cache.Close()
+	if got := strings.Count(audit.String(), `"op":"ForgetUser"`); got != 2 {
+		t.Errorf("Expected one audit record per channel, got %d in %s", got, audit.String())
+	}
+}
+
+func TestForgetUserInGuild(t *testing.T) {
+	cache := NewMessageCache(10)
+	forgetFixture(cache)
+	removed, err := cache.ForgetUserInGuild("alice", "guild2")
+	if err != nil || removed != 1 {
+		t.Fatalf("Expected 1 message removed, got %d, %v", removed, err)
+	}
+	assertForgotten(t, cache, "alice", "channel2")
+	if msgs, _ := cache.GetMessagesByAuthor("channel1", "alice"); len(msgs) != 1 {
+		t.Errorf("Expected other guilds to be left alone, got %d messages", len(msgs))
+	}
+}
+
+func TestForgetUserReportsFrozenChannels(t *testing.T) {
+	cache := NewMessageCache(10)
+	forgetFixture(cache)
+	cache.FreezeChannel("channel1")
+	removed, err := cache.ForgetUser("alice")
+	var channelErr *ChannelError
+	if !errors.As(err, &channelErr) || channelErr.ChannelID != "channel1" || !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected ErrChannelFrozen for channel1, got %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected the other channel to be erased, got %d removed", removed)
+	}
+}
+
+func TestForgetUserDiscardsHeldMessages(t *testing.T) {
+	cache := NewMessageCache(10, PauseBuffering(10))
+	cache.Pause()
+	cache.AddMessage("channel1", authoredMessage("1", "alice", forgottenSecret))
+	cache.AddMessage("channel1", authoredMessage("2", "bob", "hi"))
+	if removed, _ := cache.ForgetUser("alice"); removed != 0 {
+		t.Errorf("Expected held messages not to be counted, got %d", removed)
+	}
+	cache.Resume()
+	msgs, _ := cache.GetMessages("channel1")
+	if len(msgs) != 1 || msgs[0].ID != "2" {
+		t.Errorf("Expected alice's held message to be discarded, got %v", messageIDs(msgs))
+	}
+}
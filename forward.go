@@ -0,0 +1,62 @@
+package dgocacheler
+
+import (
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// AsMessageSends converts the cached messages of a channel, oldest first, into payloads for re-posting with
+// ChannelMessageSendComplex. Content and embeds are copied; as MessageSend cannot reference remote files,
+// attachment URLs are appended to the content, one per line. Mentions are disabled so re-posting pings nobody.
+// System messages such as joins and pins are skipped. It returns ErrCacheMiss for unknown channels and
+// ErrUnsupported with WithContentHashing, as the text is not stored.
+func (c *MessageCache) AsMessageSends(channelID string) ([]*discordgo.MessageSend, error) {
+	if c.contentHashing {
+		return nil, ErrUnsupported
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	msgs := cc.messages()
+	cc.RUnlock()
+
+	sends := make([]*discordgo.MessageSend, 0, len(msgs))
+	for _, msg := range msgs {
+		if isSystemMessage(msg) {
+			continue
+		}
+		sends = append(sends, messageSend(msg))
+	}
+	return sends, nil
+}
+
+// messageSend builds the payload re-posting msg.
+func messageSend(msg *discordgo.Message) *discordgo.MessageSend {
+	lines := make([]string, 0, 1+len(msg.Attachments))
+	if msg.Content != "" {
+		lines = append(lines, msg.Content)
+	}
+	for _, attachment := range msg.Attachments {
+		if attachment != nil && attachment.URL != "" {
+			lines = append(lines, attachment.URL)
+		}
+	}
+	return &discordgo.MessageSend{
+		Content:         strings.Join(lines, "\n"),
+		Embeds:          cloneMessage(msg).Embeds,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+}
+
+// isSystemMessage reports whether msg was generated by Discord rather than sent by a user or an application.
+func isSystemMessage(msg *discordgo.Message) bool {
+	switch msg.Type {
+	case discordgo.MessageTypeDefault, discordgo.MessageTypeReply,
+		discordgo.MessageTypeChatInputCommand, discordgo.MessageTypeContextMenuCommand:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package dgocacheler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAsMessageSends(t *testing.T) {
+	cache := NewMessageCache(10)
+	embed := &discordgo.MessageEmbed{Title: "title", Fields: []*discordgo.MessageEmbedField{{Name: "n", Value: "v"}}}
+	cache.AddMessages("channel1", []*discordgo.Message{
+		{ID: "1", Content: "hello", Embeds: []*discordgo.MessageEmbed{embed}},
+		{ID: "2", Type: discordgo.MessageTypeGuildMemberJoin},
+		{ID: "3", Type: discordgo.MessageTypeReply, Content: "see", Attachments: []*discordgo.MessageAttachment{{URL: "https://cdn.example/a.png"}}},
+	})
+
+	sends, err := cache.AsMessageSends("channel1")
+	if err != nil {
+		t.Fatalf("AsMessageSends returned error: %v", err)
+	}
+	if len(sends) != 2 {
+		t.Fatalf("Expected the system message to be skipped, got %d sends", len(sends))
+	}
+	if sends[0].Content != "hello" || len(sends[0].Embeds) != 1 || sends[0].Embeds[0].Title != "title" || sends[0].Embeds[0].Fields[0].Value != "v" {
+		t.Errorf("Unexpected first send: %+v", sends[0])
+	}
+	if sends[0].Embeds[0] == embed {
+		t.Error("Expected embeds to be copied, not shared with the cache.")
+	}
+	if sends[1].Content != "see\nhttps://cdn.example/a.png" {
+		t.Errorf("Expected the attachment URL after the content, got %q", sends[1].Content)
+	}
+	if sends[1].AllowedMentions == nil || len(sends[1].AllowedMentions.Parse) != 0 {
+		t.Error("Expected mentions to be disabled.")
+	}
+
+	if _, err := cache.AsMessageSends("unknown"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
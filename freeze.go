@@ -0,0 +1,73 @@
+package dgocacheler
+
+// FreezeChannel makes a channel read-only, for example to preserve a conversation under investigation exactly
+// as cached. Adds, updates, removes, fetched merges and capacity changes become no-ops counted in
+// Stats.FrozenSkipped, while reads work as usual. Frozen channels are never evicted by WithMaxChannels, and
+// ClearChannel and DeleteChannel fail with ErrChannelFrozen. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) FreezeChannel(channelID string) error {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return ErrCacheMiss
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	cc.frozen.Store(true)
+	return nil
+}
+
+// UnfreezeChannel makes a frozen channel writable again. A channel without its own capacity picks up the
+// cache-wide limit if it changed meanwhile, and authors over the SetPerUserRetention cap lose their oldest
+// messages. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) UnfreezeChannel(channelID string) error {
+	c.rlockGlobal()
+	maxMessages := c.maxMessages
+	cc, ok := c.messages[channelID]
+	c.RUnlock()
+	if !ok {
+		return ErrCacheMiss
+	}
+	c.lockChannel(cc)
+	if cc.frozen.Swap(false) && !cc.customMax {
+		cc.setMaxMessages(maxMessages)
+	}
+	cc.Unlock()
+	if idx := c.users.Load(); idx != nil {
+		idx.recheck()
+	}
+	c.enforceGlobalCaps()
+	return nil
+}
+
+// SealChannel makes a channel read-only like FreezeChannel, except that writes fail loudly: AddMessage,
+// AddMessages and the other adds, UpdateMessage, RemoveMessage and ReplaceChannel return ErrChannelSealed, while
+// reads work as usual. Fetched merges are skipped. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) SealChannel(channelID string) error {
+	return c.setSealed(channelID, true)
+}
+
+// UnsealChannel makes a sealed channel writable again. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) UnsealChannel(channelID string) error {
+	return c.setSealed(channelID, false)
+}
+
+// setSealed implements SealChannel and UnsealChannel.
+func (c *MessageCache) setSealed(channelID string, sealed bool) error {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return ErrCacheMiss
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	cc.sealed = sealed
+	return nil
+}
+
+// skipFrozen reports whether a mutation of cc must be skipped because the channel is frozen, counting the skip.
+// The caller must hold the channel's write lock.
+func (c *MessageCache) skipFrozen(cc *ChannelCache) bool {
+	if !cc.frozen.Load() {
+		return false
+	}
+	c.frozenSkips.Add(1)
+	return true
+}
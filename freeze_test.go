@@ -0,0 +1,131 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestFreezeChannel(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(5))
+	if err := cache.FreezeChannel("channel1"); err != nil {
+		t.Fatalf("FreezeChannel returned error: %v", err)
+	}
+	before, _ := cache.GetMessages("channel1")
+	beforeDump := fmt.Sprintf("%+v", before)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprintf("%d-%d", w, i)})
+			}
+		}(w)
+	}
+	wg.Wait()
+	cache.AddMessageWithPriority("channel1", &discordgo.Message{ID: "999"}, 10)
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "102", Content: "edited"})
+	cache.RemoveMessage("channel1", "103")
+	cache.SetMaxMessages(2)
+
+	after, _ := cache.GetMessages("channel1")
+	if !reflect.DeepEqual(before, after) || fmt.Sprintf("%+v", after) != beforeDump {
+		t.Errorf("Frozen channel changed: %v -> %v", messageIDs(before), messageIDs(after))
+	}
+	if skipped := cache.Stats().FrozenSkipped; skipped != 204 {
+		t.Errorf("Expected 204 skipped mutations, got %d", skipped)
+	}
+	if err := cache.ClearChannel("channel1"); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected ErrChannelFrozen from ClearChannel, got %v", err)
+	}
+	if err := cache.DeleteChannel("channel1"); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected ErrChannelFrozen from DeleteChannel, got %v", err)
+	}
+	if err := cache.SetChannelMaxMessages("channel1", 1); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected ErrChannelFrozen from SetChannelMaxMessages, got %v", err)
+	}
+
+	if err := cache.UnfreezeChannel("channel1"); err != nil {
+		t.Fatalf("UnfreezeChannel returned error: %v", err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected the cache-wide limit to apply after unfreezing, got %d messages", n)
+	}
+	if err := cache.FreezeChannel("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestFreezeForceAndEviction(t *testing.T) {
+	cache := NewMessageCache(5, WithMaxChannels(2))
+	cache.AddMessages("frozen", testHistory(3))
+	cache.FreezeChannel("frozen")
+	cache.AddMessages("b", testHistory(1))
+	cache.AddMessages("c", testHistory(1))
+	if _, ok := cache.GetMessages("frozen"); !ok {
+		t.Error("Frozen channel should be exempt from channel eviction.")
+	}
+
+	if err := cache.ForceClearChannel("frozen"); err != nil {
+		t.Errorf("ForceClearChannel returned error: %v", err)
+	}
+	if n, _ := cache.MessageCount("frozen"); n != 0 {
+		t.Errorf("Expected a forced clear to empty the channel, got %d messages", n)
+	}
+	if err := cache.ForceDeleteChannel("frozen"); err != nil {
+		t.Errorf("ForceDeleteChannel returned error: %v", err)
+	}
+	if _, ok := cache.GetMessages("frozen"); ok {
+		t.Error("Expected a forced delete to remove the channel.")
+	}
+}
+
+func TestSealChannel(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(3))
+	cache.AddMessages("channel2", testHistory(3))
+	if err := cache.SealChannel("channel1"); err != nil {
+		t.Fatalf("SealChannel returned error: %v", err)
+	}
+
+	for name, err := range map[string]error{
+		"AddMessage":     cache.AddMessage("channel1", &discordgo.Message{ID: "1"}),
+		"AddMessages":    cache.AddMessages("channel1", []*discordgo.Message{{ID: "2"}}),
+		"UpdateMessage":  cache.UpdateMessage("channel1", &discordgo.Message{ID: "101", Content: "edited"}),
+		"RemoveMessage":  cache.RemoveMessage("channel1", "101"),
+		"ReplaceChannel": cache.ReplaceChannel("channel1", nil),
+	} {
+		if !errors.Is(err, ErrChannelSealed) {
+			t.Errorf("%s: expected ErrChannelSealed, got %v", name, err)
+		}
+	}
+	if msgs, ok := cache.GetMessages("channel1"); !ok || fmt.Sprint(messageIDs(msgs)) != "[100 101 102]" || msgs[1].Content != "" {
+		t.Errorf("Expected reads to work and the contents to be unchanged, got %v", messageIDs(msgs))
+	}
+	if err := cache.AddMessage("channel2", &discordgo.Message{ID: "1"}); err != nil {
+		t.Errorf("Expected other channels to stay writable, got %v", err)
+	}
+
+	if err := cache.UnsealChannel("channel1"); err != nil {
+		t.Fatalf("UnsealChannel returned error: %v", err)
+	}
+	if err := cache.AddMessage("channel1", &discordgo.Message{ID: "1"}); err != nil {
+		t.Errorf("Expected adds to resume after unsealing, got %v", err)
+	}
+	if err := cache.RemoveMessage("channel1", "101"); err != nil {
+		t.Errorf("Expected removes to resume after unsealing, got %v", err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 3 {
+		t.Errorf("Expected 3 messages, got %d", n)
+	}
+	if err := cache.SealChannel("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package dgocacheler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fetchGroup deduplicates concurrent fetches per channel: while a fetch for a channel is running, other callers
+// wait for its result instead of starting their own. The zero value is ready to use.
+type fetchGroup struct {
+	mu    sync.Mutex
+	calls map[string]*fetchCall
+}
+
+// fetchCall is a fetch in flight. err is written before done is closed.
+type fetchCall struct {
+	done chan struct{}
+	err  error
+}
+
+// do runs fn for key unless a call for key is already in flight, in which case it waits for that call and
+// returns its error. Waiting stops early when ctx is done. Results are not remembered once the call finishes,
+// so a failed fetch does not affect later ones.
+func (g *fetchGroup) do(ctx context.Context, key string, fn func() error) error {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &fetchCall{done: make(chan struct{})}
+	if g.calls == nil {
+		g.calls = make(map[string]*fetchCall)
+	}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	call.err = fn()
+	return call.err
+}
+
+// GetOrFetch returns the limit newest messages of a channel. If fewer are cached, fetch is called to load more,
+// its results are merged into the cache and the newest limit messages are returned. Concurrent calls for the
+// same channel share a single fetch: the callers that arrive while it runs wait for it, and a fetch error is
+// returned to all of them. The fetch runs with the ctx of the caller that started it. It returns
+// ErrInvalidChannel for an empty channel ID without calling fetch.
+func (c *MessageCache) GetOrFetch(ctx context.Context, channelID string, limit int, fetch func(ctx context.Context) ([]*discordgo.Message, error)) (msgs []*discordgo.Message, err error) {
+	ctx, span := c.startSpan(ctx, "GetOrFetch")
+	defer func() {
+		span.SetAttribute(AttrResultCount, len(msgs))
+		endSpan(span, err)
+	}()
+
+	channelID, err = c.resolveChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttribute(AttrChannelID, channelID)
+	if limit <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	if cached, ok := c.GetMessagesLimit(channelID, limit); ok && len(cached) == limit {
+		return cached, nil
+	}
+	err = c.fetches.do(ctx, channelID, func() (err error) {
+		c.profileOp(ctx, "fetch", channelID, func(ctx context.Context) {
+			var fetched []*discordgo.Message
+			if panicErr := c.guard("GetOrFetch", func() { fetched, err = fetch(ctx) }); panicErr != nil {
+				err = panicErr
+			}
+			if err == nil {
+				err = c.mergeMessages(channelID, fetched, "GetOrFetch")
+			}
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	msgs, _ = c.GetMessagesLimit(channelID, limit)
+	if msgs == nil {
+		msgs = []*discordgo.Message{}
+	}
+	return msgs, nil
+}
@@ -0,0 +1,134 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGetOrFetchSingleflight(t *testing.T) {
+	cache := NewMessageCache(100)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) ([]*discordgo.Message, error) {
+		calls.Add(1)
+		<-release
+		return testHistory(20), nil
+	}
+
+	const callers = 50
+	var started, done sync.WaitGroup
+	results := make([][]*discordgo.Message, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			results[i], errs[i] = cache.GetOrFetch(context.Background(), "channel1", 10, fetch)
+		}(i)
+	}
+	started.Wait()
+	for calls.Load() == 0 {
+		runtime.Gosched() // wait for the leader to enter fetch before letting it finish
+	}
+	close(release)
+	done.Wait()
+
+	// Callers that arrived after the fetch finished are served from the cache.
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected exactly one fetch, got %d", n)
+	}
+	for i := range results {
+		if errs[i] != nil || len(results[i]) != 10 || results[i][9].ID != "119" {
+			t.Fatalf("Caller %d got %d messages (err %v)", i, len(results[i]), errs[i])
+		}
+	}
+}
+
+func TestGetOrFetchCacheHit(t *testing.T) {
+	cache := NewMessageCache(100)
+	cache.AddMessages("channel1", testHistory(5))
+	fetch := func(ctx context.Context) ([]*discordgo.Message, error) {
+		t.Error("fetch should not be called when enough messages are cached")
+		return nil, nil
+	}
+	if msgs, err := cache.GetOrFetch(context.Background(), "channel1", 5, fetch); err != nil || len(msgs) != 5 {
+		t.Errorf("Expected 5 cached messages, got %d (err %v)", len(msgs), err)
+	}
+	if _, err := cache.GetOrFetch(context.Background(), "channel1", 0, fetch); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+}
+
+func TestGetOrFetchEmptyChannel(t *testing.T) {
+	cache := NewMessageCache(100)
+	fetch := func(ctx context.Context) ([]*discordgo.Message, error) {
+		t.Error("fetch should not be called for an empty channel ID")
+		return testHistory(1), nil
+	}
+	if _, err := cache.GetOrFetch(context.Background(), "", 1, fetch); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel, got %v", err)
+	}
+	if n, _ := cache.MessageCount(""); n != 0 {
+		t.Errorf("Expected nothing stored under the empty channel ID, got %d", n)
+	}
+
+	cache.SetDefaultChannel("orphans")
+	working := func(ctx context.Context) ([]*discordgo.Message, error) { return testHistory(1), nil }
+	if msgs, err := cache.GetOrFetch(context.Background(), "", 1, working); err != nil || len(msgs) != 1 {
+		t.Errorf("Expected the default channel fetched, got %d messages (err %v)", len(msgs), err)
+	}
+	if n, _ := cache.MessageCount("orphans"); n != 1 {
+		t.Errorf("Expected the fetched message in the default channel, got %d", n)
+	}
+}
+
+func TestGetOrFetchErrorDoesNotPoison(t *testing.T) {
+	cache := NewMessageCache(100)
+	errFetch := errors.New("discord unavailable")
+	failing := func(ctx context.Context) ([]*discordgo.Message, error) { return nil, errFetch }
+	if _, err := cache.GetOrFetch(context.Background(), "channel1", 5, failing); !errors.Is(err, errFetch) {
+		t.Fatalf("Expected the fetch error, got %v", err)
+	}
+	working := func(ctx context.Context) ([]*discordgo.Message, error) { return testHistory(5), nil }
+	if msgs, err := cache.GetOrFetch(context.Background(), "channel1", 5, working); err != nil || len(msgs) != 5 {
+		t.Errorf("Expected a retry to succeed, got %d messages (err %v)", len(msgs), err)
+	}
+}
+
+func TestGetOrFetchErrorSharedByWaiters(t *testing.T) {
+	cache := NewMessageCache(100)
+	errFetch := errors.New("discord unavailable")
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) ([]*discordgo.Message, error) {
+		calls.Add(1)
+		<-release
+		return nil, errFetch
+	}
+
+	const callers = 10
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, err := cache.GetOrFetch(context.Background(), "channel1", 5, fetch)
+			errs <- err
+		}()
+	}
+	for calls.Load() == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+	for i := 0; i < callers; i++ {
+		if err := <-errs; !errors.Is(err, errFetch) {
+			t.Errorf("Expected every caller to get the fetch error, got %v", err)
+		}
+	}
+}
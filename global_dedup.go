@@ -0,0 +1,69 @@
+package dgocacheler
+
+import "sync"
+
+// WithGlobalDedup rejects messages whose ID is already cached in any channel, for relays that can see the same
+// message under several channels. A message is skipped as silently as a duplicate within one channel, and once
+// the last cached copy is evicted or removed the ID can be added again.
+//
+// The cache keeps a set with one entry per cached message, costing roughly 50 bytes plus the ID per message. It
+// is bounded by what the cache holds: the number of channels times their capacity, so combine it with
+// WithMaxChannels to bound it firmly. Two channels adding the same ID at the same instant may both store it.
+func WithGlobalDedup() Option {
+	return func(c *MessageCache) {
+		c.globalIDs = &globalIDSet{ids: make(map[string]int)}
+	}
+}
+
+// ContainsGlobal reports whether a message with the given ID is cached in any channel. It is always false
+// without WithGlobalDedup.
+func (c *MessageCache) ContainsGlobal(messageID string) bool {
+	return c.globalIDs != nil && c.globalIDs.contains(messageID)
+}
+
+// globalIDSet counts the cached copies of each message ID across channels.
+type globalIDSet struct {
+	mu  sync.Mutex
+	ids map[string]int
+}
+
+// contains reports whether any copy of messageID is cached.
+func (s *globalIDSet) contains(messageID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ids[messageID] > 0
+}
+
+// update adds delta copies of messageID.
+func (s *globalIDSet) update(messageID string, delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ids[messageID] += delta; s.ids[messageID] <= 0 {
+		delete(s.ids, messageID)
+	}
+}
+
+// attachGlobalIDs counts the channel's messages in the global ID set s, for a channel built detached from it.
+// The caller must hold the channel's write lock.
+func (cc *ChannelCache) attachGlobalIDs(s *globalIDSet) {
+	if cc.globalIDs == s {
+		return
+	}
+	cc.releaseGlobalIDs()
+	for i := 0; i < cc.size && s != nil; i++ {
+		s.update(cc.at(i).message.ID, 1)
+	}
+	cc.globalIDs = s
+}
+
+// releaseGlobalIDs forgets the channel's messages in the global ID set, for a channel being dropped from the
+// cache, and detaches the channel from the set. The caller must hold the channel's write lock.
+func (cc *ChannelCache) releaseGlobalIDs() {
+	if cc.globalIDs == nil {
+		return
+	}
+	for i := 0; i < cc.size; i++ {
+		cc.globalIDs.update(cc.at(i).message.ID, -1)
+	}
+	cc.globalIDs = nil
+}
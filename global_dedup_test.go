@@ -0,0 +1,63 @@
+package dgocacheler
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGlobalDedup(t *testing.T) {
+	cache := NewMessageCache(2, WithGlobalDedup())
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.AddMessage("channel2", &discordgo.Message{ID: "1"})
+	if n, _ := cache.MessageCount("channel2"); n != 0 {
+		t.Errorf("Expected the ID cached in channel1 to be rejected in channel2, got %d messages", n)
+	}
+	if !cache.ContainsGlobal("1") || cache.ContainsGlobal("2") {
+		t.Error("ContainsGlobal does not reflect the cached IDs.")
+	}
+
+	cache.AddMessages("channel1", []*discordgo.Message{{ID: "2"}, {ID: "3"}}) // evicts 1
+	if cache.ContainsGlobal("1") {
+		t.Error("An evicted ID should be forgotten globally.")
+	}
+	cache.AddMessage("channel2", &discordgo.Message{ID: "1"})
+	if n, _ := cache.MessageCount("channel2"); n != 1 {
+		t.Errorf("Expected the evicted ID to be accepted again, got %d messages", n)
+	}
+
+	cache.RenameChannel("channel2", "channel3")
+	if !cache.ContainsGlobal("1") {
+		t.Error("Renaming a channel should keep its IDs.")
+	}
+	cache.DeleteChannel("channel3")
+	cache.RemoveMessage("channel1", "2")
+	cache.ClearChannel("channel1")
+	if cache.ContainsGlobal("1") || cache.ContainsGlobal("3") || len(cache.globalIDs.ids) != 0 {
+		t.Errorf("Expected every ID to be released, got %v", cache.globalIDs.ids)
+	}
+
+	if NewMessageCache(2).ContainsGlobal("1") {
+		t.Error("ContainsGlobal should be false without WithGlobalDedup.")
+	}
+}
+
+func TestDetachedChannelGlobalIDs(t *testing.T) {
+	cache := NewMessageCache(5, WithGlobalDedup())
+	cc := cache.newDetachedChannel("channel1", 5, 0)
+	cc.reset(cache.prepareEntries(cc, testHistory(2), func(string) bool { return false }))
+	if cache.ContainsGlobal("100") {
+		t.Errorf("A detached channel must not count its messages globally")
+	}
+
+	cache.lockGlobal()
+	cache.storeChannelLocked("channel1", cc)
+	cache.Unlock()
+	if !cache.ContainsGlobal("100") || !cache.ContainsGlobal("101") {
+		t.Errorf("Expected the messages counted once the channel is stored")
+	}
+	cache.AddMessage("channel2", &discordgo.Message{ID: "100"})
+	if n, _ := cache.MessageCount("channel2"); n != 0 {
+		t.Errorf("Expected the stored channel's IDs to be global duplicates")
+	}
+}
@@ -0,0 +1,229 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// SetGlobalMaxMessages caps how many messages the cache retains across all channels, on top of the channel
+// capacities. Over the cap, the least recently used message anywhere in the cache is evicted first, reported to
+// OnEvict with EvictGlobalLRU, before the adding call returns. Storing a message counts as a use, and so do
+// GetMessage, GetMessages, GetMessagesLimit, GetRecentWindow, GetContext and the ChannelHandle Get and GetLimit
+// methods for every message they return; other reads leave recency alone. Lowering n evicts right away. Messages in frozen
+// channels count toward the cap but are never evicted. n <= 0 removes the cap.
+//
+// The cap is backed by a list threaded through every cached message, costing roughly 80 bytes per message.
+// While it is set, reads take the channel read lock and GetMessages returns a freshly allocated slice.
+func (c *MessageCache) SetGlobalMaxMessages(n int) {
+	c.lockGlobal()
+	l := c.lru.Load()
+	switch {
+	case n <= 0 && l != nil:
+		for _, cc := range c.messages {
+			c.lockChannel(cc)
+			cc.lru = nil
+			cc.Unlock()
+		}
+		c.lru.Store(nil)
+	case n > 0 && l == nil:
+		l = c.buildLRULocked()
+		c.lru.Store(l)
+	}
+	if n > 0 {
+		l.setLimit(n)
+	}
+	c.Unlock()
+	c.enforceGlobalMax()
+}
+
+// buildLRULocked attaches every channel to a new list holding their messages in insertion order. The channels
+// stay locked until all of them are attached, so no change slips in between. The caller must hold the global
+// write lock.
+func (c *MessageCache) buildLRULocked() *messageLRU {
+	l := newMessageLRU()
+	var entries []lruEntry
+	for _, channelID := range slices.Sorted(maps.Keys(c.messages)) {
+		cc := c.messages[channelID]
+		c.lockChannel(cc)
+		defer cc.Unlock()
+		cc.lru = l
+		for i := 0; i < cc.size; i++ {
+			entries = append(entries, lruEntry{cc: cc, entry: *cc.at(i)})
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b lruEntry) int { return cmp.Compare(a.entry.insertSeq, b.entry.insertSeq) })
+	for _, e := range entries {
+		l.update(e.cc, e.entry, 1)
+	}
+	return l
+}
+
+// messageLRU orders the cached messages of every channel by recency of use. Its lock is only ever taken last,
+// so it may be acquired while holding the global or a channel lock.
+type messageLRU struct {
+	mu    sync.Mutex
+	limit int                 // limit is the number of messages retained across channels
+	root  lruNode             // root.next is the most recently used node and root.prev the least
+	nodes map[lruKey]*lruNode // nodes holds the node of every cached message
+}
+
+// lruKey identifies one cached message across channels.
+type lruKey struct {
+	channel *ChannelCache // channel holds the message, whatever ID it is stored under
+	seq     uint64        // seq is the insertion sequence of the entry
+}
+
+// lruNode is one message in the recency list.
+type lruNode struct {
+	key        lruKey
+	messageID  string // messageID is the ID of the message
+	prev, next *lruNode
+}
+
+// lruEntry pairs an entry with its channel while the list is built.
+type lruEntry struct {
+	cc    *ChannelCache
+	entry cachedMessage
+}
+
+// newMessageLRU returns an empty list.
+func newMessageLRU() *messageLRU {
+	l := &messageLRU{nodes: make(map[lruKey]*lruNode)}
+	l.root.prev, l.root.next = &l.root, &l.root
+	return l
+}
+
+// update adds (sign 1) an entry of cc as the most recently used message, or removes it (sign -1).
+func (l *messageLRU) update(cc *ChannelCache, entry cachedMessage, sign int) {
+	key := lruKey{channel: cc, seq: entry.insertSeq}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if node, ok := l.nodes[key]; ok {
+		l.unlink(node)
+		if sign < 0 {
+			delete(l.nodes, key)
+			return
+		}
+	}
+	if sign > 0 {
+		node := &lruNode{key: key, messageID: entry.message.ID}
+		l.nodes[key] = node
+		l.pushFront(node)
+	}
+}
+
+// setLimit changes the number of messages retained.
+func (l *messageLRU) setLimit(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = n
+}
+
+// victims returns the least recently used messages in excess of the limit, skipping those in frozen channels.
+func (l *messageLRU) victims() []*lruNode {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var victims []*lruNode
+	excess := len(l.nodes) - l.limit
+	for node := l.root.prev; node != &l.root && len(victims) < excess; node = node.prev {
+		if !node.key.channel.frozen.Load() {
+			victims = append(victims, &lruNode{key: node.key, messageID: node.messageID})
+		}
+	}
+	return victims
+}
+
+// unlink takes node out of the list. The caller must hold l.mu.
+func (l *messageLRU) unlink(node *lruNode) {
+	node.prev.next, node.next.prev = node.next, node.prev
+	node.prev, node.next = nil, nil
+}
+
+// pushFront makes node the most recently used. The caller must hold l.mu.
+func (l *messageLRU) pushFront(node *lruNode) {
+	node.prev, node.next = &l.root, l.root.next
+	l.root.next.prev = node
+	l.root.next = node
+}
+
+// touch marks the n entries starting at logical position start as used, oldest first, so that the newest ends
+// up the most recently used. The caller must hold at least the read lock.
+func (cc *ChannelCache) touch(start, n int) {
+	l := cc.lru
+	if l == nil || n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := start; i < start+n; i++ {
+		if node, ok := l.nodes[lruKey{channel: cc, seq: cc.at(i).insertSeq}]; ok {
+			l.unlink(node)
+			l.pushFront(node)
+		}
+	}
+}
+
+// attachLRU moves the channel's entries from its current list, if any, to l, which may be nil, where they
+// become the most recently used. Like attachUsers, channels join when stored and leave when dropped. The caller
+// must hold the global and the channel's write lock.
+func (cc *ChannelCache) attachLRU(l *messageLRU) {
+	if cc.lru == l {
+		return
+	}
+	for i := 0; i < cc.size; i++ {
+		if cc.lru != nil {
+			cc.lru.update(cc, *cc.at(i), -1)
+		}
+		if l != nil {
+			l.update(cc, *cc.at(i), 1)
+		}
+	}
+	cc.lru = l
+}
+
+// enforceGlobalMax evicts the least recently used messages while the cache is over the global cap. It must be
+// called without holding any cache lock.
+func (c *MessageCache) enforceGlobalMax() {
+	l := c.lru.Load()
+	if l == nil {
+		return
+	}
+	for {
+		victims := l.victims()
+		evicted := 0
+		for _, node := range victims {
+			if c.evictEntry(node.key.channel, node.key.seq, node.messageID, EvictGlobalLRU) {
+				evicted++
+			}
+		}
+		if evicted == 0 {
+			return
+		}
+	}
+}
+
+// enforceGlobalCaps applies the per-user and global message caps. It must be called without holding any cache
+// lock, typically deferred by the methods that add messages.
+func (c *MessageCache) enforceGlobalCaps() {
+	c.enforceUserRetention()
+	c.enforceGlobalMax()
+}
+
+// evictEntry evicts the entry of cc with the given insertion sequence and message ID, returning false if it is
+// gone or the channel is retired or frozen.
+func (c *MessageCache) evictEntry(cc *ChannelCache, seq uint64, messageID string, reason EvictReason) bool {
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if cc.retired || cc.frozen.Load() {
+		return false
+	}
+	for i := cc.size - 1; i >= 0; i-- {
+		if entry := cc.at(i); entry.insertSeq == seq && entry.message.ID == messageID {
+			cc.evictAt(i, reason)
+			return true
+		}
+	}
+	return false
+}
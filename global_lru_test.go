@@ -0,0 +1,204 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGlobalMaxMessagesEvictsLeastRecentlyUsed(t *testing.T) {
+	var evicted []string
+	cache := NewMessageCache(10, OnEvict(func(channelID string, message *discordgo.Message, reason EvictReason) {
+		if reason != EvictGlobalLRU {
+			t.Errorf("Expected reason %q, got %q", EvictGlobalLRU, reason)
+		}
+		evicted = append(evicted, channelID+"/"+message.ID)
+	}))
+	cache.SetGlobalMaxMessages(3)
+
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.AddMessage("channel2", &discordgo.Message{ID: "2"})
+	cache.AddMessage("channel3", &discordgo.Message{ID: "3"})
+	if _, err := cache.GetMessage("channel1", "1"); err != nil {
+		t.Fatalf("GetMessage failed: %v", err)
+	}
+	cache.AddMessage("channel3", &discordgo.Message{ID: "4"})
+	cache.GetMessages("channel3")
+	cache.AddMessage("channel2", &discordgo.Message{ID: "5"})
+
+	if want := []string{"channel2/2", "channel1/1"}; !slices.Equal(evicted, want) {
+		t.Errorf("Expected eviction order %v, got %v", want, evicted)
+	}
+	if got := cache.TotalCount(); got != 3 {
+		t.Errorf("Expected 3 messages, got %d", got)
+	}
+}
+
+func TestGlobalMaxMessagesReadsBumpRecency(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetGlobalMaxMessages(4)
+	for i := 1; i <= 4; i++ {
+		cache.AddMessage(fmt.Sprintf("channel%d", i%2), &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	// channel1 holds 1 and 3, channel0 holds 2 and 4. Reading channel1 leaves 2 and 4 the least recently used.
+	if msgs, ok := cache.GetMessagesLimit("channel1", 2); !ok || len(msgs) != 2 {
+		t.Fatalf("Expected 2 messages, got %v", msgs)
+	}
+	cache.AddMessage("channel2", &discordgo.Message{ID: "5"})
+	cache.AddMessage("channel2", &discordgo.Message{ID: "6"})
+
+	if msgs, _ := cache.GetMessages("channel0"); len(msgs) != 0 {
+		t.Errorf("Expected channel0 to be emptied, got %d messages", len(msgs))
+	}
+	for _, id := range []string{"1", "3"} {
+		if _, err := cache.GetMessage("channel1", id); err != nil {
+			t.Errorf("Expected message %s to survive, got %v", id, err)
+		}
+	}
+}
+
+func TestGlobalMaxMessagesLoweringEvictsImmediately(t *testing.T) {
+	cache := NewMessageCache(10)
+	for i := 1; i <= 6; i++ {
+		cache.AddMessage(fmt.Sprintf("channel%d", i%3), &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	cache.SetGlobalMaxMessages(4)
+	if got := cache.TotalCount(); got != 4 {
+		t.Errorf("Expected 4 messages after setting the cap, got %d", got)
+	}
+	if _, err := cache.GetMessage("channel1", "1"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected the oldest message to be evicted, got %v", err)
+	}
+	cache.SetGlobalMaxMessages(1)
+	if _, err := cache.GetMessage("channel0", "6"); err != nil {
+		t.Errorf("Expected the newest message to survive, got %v", err)
+	}
+
+	cache.SetGlobalMaxMessages(0)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "7"})
+	cache.AddMessage("channel2", &discordgo.Message{ID: "8"})
+	if got := cache.TotalCount(); got != 3 {
+		t.Errorf("Expected no cap after removing it, got %d messages", got)
+	}
+}
+
+func TestGlobalMaxMessagesSkipsFrozenChannels(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetGlobalMaxMessages(2)
+	cache.AddMessage("frozen", &discordgo.Message{ID: "1"})
+	cache.AddMessage("frozen", &discordgo.Message{ID: "2"})
+	cache.FreezeChannel("frozen")
+
+	cache.AddMessage("channel1", &discordgo.Message{ID: "3"})
+	if got, _ := cache.GetMessages("frozen"); len(got) != 2 {
+		t.Errorf("Expected the frozen channel to keep its messages, got %d", len(got))
+	}
+	if _, err := cache.GetMessage("channel1", "3"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected the only evictable message to go instead, got %v", err)
+	}
+
+	cache.UnfreezeChannel("frozen")
+	if got := cache.TotalCount(); got != 2 {
+		t.Errorf("Expected the cap to apply after unfreezing, got %d messages", got)
+	}
+}
+
+func TestGlobalMaxMessagesMatchesModel(t *testing.T) {
+	const limit = 12
+	rng := rand.New(rand.NewSource(1))
+	cache := NewMessageCache(6)
+	cache.SetGlobalMaxMessages(limit)
+	channels := []string{"c0", "c1", "c2", "c3"}
+	nextID := 0
+	message := func() *discordgo.Message {
+		nextID++
+		return &discordgo.Message{ID: fmt.Sprint(nextID)}
+	}
+
+	for op := 0; op < 5000; op++ {
+		channelID := channels[rng.Intn(len(channels))]
+		switch rng.Intn(12) {
+		case 0, 1, 2, 3:
+			cache.AddMessage(channelID, message())
+		case 4:
+			cache.AddMessages(channelID, []*discordgo.Message{message(), message(), message()})
+		case 5:
+			if msgs, _ := cache.GetMessages(channelID); len(msgs) > 0 {
+				cache.RemoveMessage(channelID, msgs[rng.Intn(len(msgs))].ID)
+			}
+		case 6:
+			if msgs, _ := cache.GetMessages(channelID); len(msgs) > 0 {
+				cache.GetMessage(channelID, msgs[rng.Intn(len(msgs))].ID)
+			}
+		case 7:
+			cache.GetRecentWindow(channelID, rng.Intn(3), 1+rng.Intn(3))
+		case 8:
+			if rng.Intn(4) == 0 {
+				cache.DeleteChannel(channelID)
+			} else {
+				cache.EvictOldest(channelID, rng.Intn(3))
+			}
+		case 9:
+			cache.ReplaceChannel(channelID, []*discordgo.Message{message(), message(), message()})
+		case 10:
+			cache.RenameChannel(channelID, channels[rng.Intn(len(channels))])
+		case 11:
+			if rng.Intn(2) == 0 {
+				cache.FreezeChannel(channelID)
+			} else {
+				cache.UnfreezeChannel(channelID)
+			}
+		}
+		checkGlobalLRU(t, cache, limit)
+		if t.Failed() {
+			t.Fatalf("List diverged after operation %d", op)
+		}
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
+
+// checkGlobalLRU compares the recency list with the messages actually cached.
+func checkGlobalLRU(t *testing.T, cache *MessageCache, limit int) {
+	t.Helper()
+	live := make(map[lruKey]string)
+	frozen := 0
+	for _, cc := range cache.channelCaches() {
+		cc.RLock()
+		for i := 0; i < cc.size; i++ {
+			live[lruKey{channel: cc, seq: cc.at(i).insertSeq}] = cc.at(i).message.ID
+		}
+		if cc.frozen.Load() {
+			frozen += cc.size
+		}
+		cc.RUnlock()
+	}
+
+	l := cache.lru.Load()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.nodes) != len(live) {
+		t.Errorf("List holds %d messages, cache holds %d", len(l.nodes), len(live))
+	}
+	linked := 0
+	for node := l.root.next; node != &l.root; node = node.next {
+		linked++
+		if id, ok := live[node.key]; !ok || id != node.messageID {
+			t.Errorf("List refers to message %s, which is not cached", node.messageID)
+		}
+		if l.nodes[node.key] != node {
+			t.Errorf("Node of message %s is linked but not indexed", node.messageID)
+		}
+	}
+	if linked != len(l.nodes) {
+		t.Errorf("List links %d nodes, indexes %d", linked, len(l.nodes))
+	}
+	if len(live) > limit && len(live)-frozen > 0 {
+		t.Errorf("Cache holds %d messages, above the cap of %d", len(live), limit)
+	}
+}
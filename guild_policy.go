@@ -0,0 +1,204 @@
+package dgocacheler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// GuildPolicy is the retention policy for the channels of one guild, for example to honor a data-retention
+// agreement.
+type GuildPolicy struct {
+	Enabled               bool          // Enabled allows caching the guild's messages; a disabled guild caches nothing
+	MaxMessagesPerChannel int           // MaxMessagesPerChannel caps each channel of the guild, 0 for the cache-wide limit
+	MaxAge                time.Duration // MaxAge drops messages sent longer ago than this, 0 to keep messages of any age
+}
+
+// guildPolicies holds the policies set by SetGuildPolicy. Its lock is only ever taken last, so it may be
+// acquired while holding the global or a channel lock.
+type guildPolicies struct {
+	mu       sync.RWMutex
+	policies map[string]GuildPolicy
+}
+
+// lookup returns the policy of a guild, if one is set.
+func (g *guildPolicies) lookup(guildID string) (GuildPolicy, bool) {
+	if guildID == "" {
+		return GuildPolicy{}, false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	p, ok := g.policies[guildID]
+	return p, ok
+}
+
+// SetGuildPolicy sets the retention policy for the channels of a guild, replacing any previous one. A channel
+// belongs to a guild once a message carrying the guild ID is added to it; channels whose guild is unknown or
+// has no policy follow the cache-wide settings. The policy applies right away to the guild's cached channels
+// and to every later add: a disabled guild's channels are emptied and ignore adds, messages older than MaxAge
+// are dropped, and MaxMessagesPerChannel takes precedence over SetMaxMessages but not over a capacity set by
+// SetChannelMaxMessages or WithChannels; a MaxMessagesPerChannel of 0 returns the guild's channels to the
+// cache-wide limit. Messages aging past MaxAge afterwards are dropped by RunJanitor. Frozen channels are left as
+// they are.
+func (c *MessageCache) SetGuildPolicy(guildID string, p GuildPolicy) {
+	c.guilds.mu.Lock()
+	if c.guilds.policies == nil {
+		c.guilds.policies = make(map[string]GuildPolicy)
+	}
+	c.guilds.policies[guildID] = p
+	c.guilds.mu.Unlock()
+
+	c.rlockGlobal()
+	defer c.RUnlock()
+	for _, cc := range c.messages {
+		c.lockChannel(cc)
+		if cc.guildID == guildID {
+			removed := 0
+			if p.Enabled && p.MaxMessagesPerChannel <= 0 && !cc.customMax && !cc.frozen.Load() && cc.maxMessages != c.maxMessages {
+				before := cc.size
+				cc.setMaxMessages(c.maxMessages)
+				removed = before - cc.size
+			}
+			c.auditRemoval("SetGuildPolicy", cc, removed+c.applyGuildPolicy(cc, p))
+		}
+		cc.Unlock()
+	}
+}
+
+// GuildPolicyFor returns the policy in effect for a channel, with MaxMessagesPerChannel set to the channel's
+// actual capacity. Channels whose guild is unknown or has no policy report an enabled policy without MaxAge.
+// It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) GuildPolicyFor(channelID string) (GuildPolicy, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return GuildPolicy{}, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	p, ok := c.guilds.lookup(cc.guildID)
+	if !ok {
+		p = GuildPolicy{Enabled: true}
+	}
+	p.MaxMessagesPerChannel = cc.maxMessages
+	return p, nil
+}
+
+// RunJanitor applies the guild policies to every cached channel once, dropping messages that aged past their
+// guild's MaxAge, and returns how many messages it removed. WithJanitor runs it periodically.
+func (c *MessageCache) RunJanitor() int {
+	removed := 0
+	for _, cc := range c.channelCaches() {
+		c.lockChannel(cc)
+		if p, ok := c.guilds.lookup(cc.guildID); ok {
+			removed += c.applyGuildPolicy(cc, p)
+		}
+		cc.Unlock()
+	}
+	c.auditSweep("RunJanitor", removed)
+	return removed
+}
+
+// WithJanitor runs RunJanitor every interval on a background goroutine until Close. Non-positive intervals
+// are ignored.
+func WithJanitor(interval time.Duration) Option {
+	return func(c *MessageCache) {
+		if interval > 0 {
+			c.janitor = &janitor{interval: interval, stop: make(chan struct{}), done: make(chan struct{})}
+		}
+	}
+}
+
+// janitor runs RunJanitor on a ticker.
+type janitor struct {
+	interval time.Duration
+	stop     chan struct{} // stop is closed to end the goroutine
+	done     chan struct{} // done is closed once the goroutine returned
+	once     sync.Once
+}
+
+// start runs the janitor goroutine for c.
+func (j *janitor) start(c *MessageCache) {
+	go func() {
+		defer close(j.done)
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.RunJanitor()
+			case <-j.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close stops the janitor goroutine and waits for it. It is safe to call more than once.
+func (j *janitor) close() {
+	j.once.Do(func() { close(j.stop) })
+	<-j.done
+}
+
+// attributeGuild records the guild of a channel from the first message that names it, applying the guild's
+// policy, and returns the policy in effect for the channel. The caller must hold the channel's write lock.
+func (c *MessageCache) attributeGuild(cc *ChannelCache, message *discordgo.Message) (GuildPolicy, bool) {
+	if cc.guildID == "" && message.GuildID != "" {
+		cc.guildID = message.GuildID
+		if p, ok := c.guilds.lookup(cc.guildID); ok {
+			c.auditRemoval("SetGuildPolicy", cc, c.applyGuildPolicy(cc, p))
+			return p, true
+		}
+		return GuildPolicy{}, false
+	}
+	return c.guilds.lookup(cc.guildID)
+}
+
+// admits reports whether a policy allows storing message at now.
+func (p GuildPolicy) admits(message *discordgo.Message, now time.Time) bool {
+	return p.Enabled && !p.expired(message, now)
+}
+
+// expired reports whether message is older than MaxAge at now. Messages without a known send time never expire.
+func (p GuildPolicy) expired(message *discordgo.Message, now time.Time) bool {
+	if p.MaxAge <= 0 {
+		return false
+	}
+	sent := messageTime(message)
+	return !sent.IsZero() && now.Sub(sent) > p.MaxAge
+}
+
+// applyGuildPolicy brings a channel in line with its guild's policy and returns how many messages were
+// removed. Frozen channels are skipped. The caller must hold the channel's write lock.
+func (c *MessageCache) applyGuildPolicy(cc *ChannelCache, p GuildPolicy) int {
+	if cc.frozen.Load() {
+		return 0
+	}
+	before := cc.size
+	if !p.Enabled {
+		if cc.size > 0 {
+			cc.reset(nil)
+		}
+		return before
+	}
+	if p.MaxMessagesPerChannel > 0 && !cc.customMax && cc.maxMessages != p.MaxMessagesPerChannel {
+		cc.setMaxMessages(p.MaxMessagesPerChannel)
+	}
+	now := c.now()
+	for i := 0; i < cc.size; {
+		if p.expired(cc.at(i).message, now) {
+			cc.evictAt(i, EvictExpired)
+		} else {
+			i++
+		}
+	}
+	return before - cc.size
+}
+
+// guildCapacity returns the per-channel capacity set by a guild policy, or 0 when none applies.
+func (c *MessageCache) guildCapacity(guildID string) int {
+	if p, ok := c.guilds.lookup(guildID); ok && p.Enabled {
+		return p.MaxMessagesPerChannel
+	}
+	return 0
+}
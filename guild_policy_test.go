@@ -0,0 +1,159 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// guildHistory returns n messages of a guild sent one minute apart, the last one at end.
+func guildHistory(guildID string, n int, end time.Time) []*discordgo.Message {
+	msgs := make([]*discordgo.Message, n)
+	for i := range msgs {
+		msgs[i] = &discordgo.Message{
+			ID:        fmt.Sprint(100 + i),
+			GuildID:   guildID,
+			Timestamp: end.Add(time.Duration(i-n+1) * time.Minute),
+		}
+	}
+	return msgs
+}
+
+func TestGuildPolicyRetrofitsExistingChannels(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	cache := NewMessageCache(10, WithClock(clock.Now))
+	cache.AddMessages("a1", guildHistory("A", 8, clock.now))
+	cache.AddMessages("b1", guildHistory("B", 8, clock.now))
+	cache.AddMessages("c1", guildHistory("C", 8, clock.now))
+
+	cache.SetGuildPolicy("A", GuildPolicy{Enabled: true, MaxAge: 5*time.Minute + time.Second})
+	cache.SetGuildPolicy("B", GuildPolicy{Enabled: true, MaxMessagesPerChannel: 3})
+	cache.SetGuildPolicy("C", GuildPolicy{Enabled: false})
+
+	for channelID, want := range map[string]string{
+		"a1": "[102 103 104 105 106 107]",
+		"b1": "[105 106 107]",
+		"c1": "[]",
+	} {
+		msgs, _ := cache.GetMessages(channelID)
+		if got := fmt.Sprint(messageIDs(msgs)); got != want {
+			t.Errorf("Expected %s to hold %s after SetGuildPolicy, got %s", channelID, want, got)
+		}
+	}
+
+	clock.Advance(2 * time.Minute)
+	if removed := cache.RunJanitor(); removed != 2 {
+		t.Errorf("Expected the janitor to remove 2 aged messages, removed %d", removed)
+	}
+	if msgs, _ := cache.GetMessages("a1"); fmt.Sprint(messageIDs(msgs)) != "[104 105 106 107]" {
+		t.Errorf("Unexpected messages after the janitor pass: %v", messageIDs(msgs))
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestGuildPolicyAtIngestion(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	var evicted []EvictReason
+	cache := NewMessageCache(10, WithClock(clock.Now), OnEvict(func(_ string, _ *discordgo.Message, reason EvictReason) {
+		evicted = append(evicted, reason)
+	}))
+	cache.SetGuildPolicy("A", GuildPolicy{Enabled: true, MaxMessagesPerChannel: 4, MaxAge: time.Hour})
+	cache.SetGuildPolicy("C", GuildPolicy{Enabled: false})
+
+	cache.AddMessages("a1", guildHistory("A", 6, clock.now))
+	if stats, _ := cache.ChannelStats("a1"); stats.MaxMessages != 4 || stats.Messages != 4 {
+		t.Errorf("Expected a new channel to pick up the guild capacity, got %+v", stats)
+	}
+	old := &discordgo.Message{ID: "1", GuildID: "A", Timestamp: clock.now.Add(-2 * time.Hour)}
+	cache.AddMessage("a1", old)
+	if ok, _ := cache.Contains("a1", "1"); ok {
+		t.Error("Expected a message older than MaxAge to be dropped at ingestion.")
+	}
+
+	cache.AddMessages("c1", guildHistory("C", 3, clock.now))
+	if n, _ := cache.MessageCount("c1"); n != 0 {
+		t.Errorf("Expected a disabled guild to cache nothing, got %d messages", n)
+	}
+
+	clock.Advance(2 * time.Hour)
+	cache.RunJanitor()
+	if n, _ := cache.MessageCount("a1"); n != 0 {
+		t.Errorf("Expected every message to expire, got %d", n)
+	}
+	if evicted[len(evicted)-1] != EvictExpired {
+		t.Errorf("Expected expired messages to be reported as %v, got %v", EvictExpired, evicted[len(evicted)-1])
+	}
+}
+
+func TestGuildPolicyCapacityPrecedence(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetGuildPolicy("A", GuildPolicy{Enabled: true, MaxMessagesPerChannel: 4})
+	cache.AddMessages("a1", guildHistory("A", 1, time.Now()))
+	cache.SetChannelMaxMessages("a2", 7)
+	cache.AddMessages("a2", guildHistory("A", 1, time.Now()))
+	cache.AddMessages("other", testHistory(1))
+
+	cache.SetMaxMessages(20)
+	for channelID, want := range map[string]int{"a1": 4, "a2": 7, "other": 20} {
+		p, err := cache.GuildPolicyFor(channelID)
+		if err != nil || p.MaxMessagesPerChannel != want || !p.Enabled {
+			t.Errorf("Expected %s to be enabled with capacity %d, got %+v (err %v)", channelID, want, p, err)
+		}
+	}
+	if p, _ := cache.GuildPolicyFor("other"); p.MaxAge != 0 {
+		t.Errorf("Expected channels of unknown guilds to fall back to the global settings, got %+v", p)
+	}
+	if _, err := cache.GuildPolicyFor("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestGuildPolicyFrozenChannel(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("c1", guildHistory("C", 3, time.Now()))
+	cache.FreezeChannel("c1")
+	cache.SetGuildPolicy("C", GuildPolicy{Enabled: false})
+	if n, _ := cache.MessageCount("c1"); n != 3 {
+		t.Errorf("Expected a frozen channel to be left as is, got %d messages", n)
+	}
+}
+
+func TestWithJanitor(t *testing.T) {
+	cache := NewMessageCache(10, WithJanitor(5*time.Millisecond))
+	defer cache.Close()
+	cache.SetGuildPolicy("A", GuildPolicy{Enabled: true, MaxAge: 20 * time.Millisecond})
+	cache.AddMessages("a1", guildHistory("A", 1, time.Now()))
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if n, _ := cache.MessageCount("a1"); n == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Expected the janitor to drop the aged message.")
+}
+
+func TestGuildPolicyCapacityRestored(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetGuildPolicy("A", GuildPolicy{Enabled: true, MaxMessagesPerChannel: 3})
+	cache.AddMessages("a1", guildHistory("A", 5, time.Now()))
+	cache.SetChannelMaxMessages("a2", 2)
+	cache.AddMessages("a2", guildHistory("A", 1, time.Now()))
+
+	cache.SetGuildPolicy("A", GuildPolicy{Enabled: true})
+	for channelID, want := range map[string]int{"a1": 10, "a2": 2} {
+		if p, _ := cache.GuildPolicyFor(channelID); p.MaxMessagesPerChannel != want {
+			t.Errorf("Expected %s to have capacity %d after the policy cap was removed, got %d", channelID, want, p.MaxMessagesPerChannel)
+		}
+	}
+	cache.AddMessages("a1", guildHistory("A", 10, time.Now()))
+	if n, _ := cache.MessageCount("a1"); n != 10 {
+		t.Errorf("Expected the cache-wide limit to apply again, got %d messages", n)
+	}
+}
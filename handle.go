@@ -0,0 +1,131 @@
+package dgocacheler
+
+import "github.com/bwmarrin/discordgo"
+
+// ChannelHandle is pinned to one cached channel so that repeated operations on it skip the channel map lookup.
+// A handle stays safe to use after its channel is deleted, replaced by ReplaceChannel or ReplaceAll, renamed or
+// evicted: every method then returns ErrChannelGone, and Channel must be called again for a fresh handle. A
+// ChannelHandle is safe for concurrent use.
+type ChannelHandle struct {
+	cache     *MessageCache
+	channelID string
+	cc        *ChannelCache
+}
+
+// Channel returns a handle for a channel, creating the channel if it is not cached yet. An empty channel ID is
+// resolved as AddMessage does, and rejected with ErrInvalidChannel unless SetDefaultChannel is configured.
+func (c *MessageCache) Channel(channelID string) (*ChannelHandle, error) {
+	channelID, err := c.resolveChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	cc := c.lockChannelForAdd(channelID)
+	cc.Unlock()
+	return &ChannelHandle{cache: c, channelID: channelID, cc: cc}, nil
+}
+
+// ChannelID returns the ID of the channel the handle was created for.
+func (h *ChannelHandle) ChannelID() string {
+	return h.channelID
+}
+
+// Add is AddMessage on the handle's channel.
+func (h *ChannelHandle) Add(message *discordgo.Message) error {
+	if held, err := h.cache.holdIfPaused(h.channelID, 0, "ChannelHandle.Add", message); held {
+		return err
+	}
+	defer h.cache.enforceGlobalCaps()
+	if err := h.lock(); err != nil {
+		return err
+	}
+	defer h.cc.Unlock()
+	return h.cache.addMessageInternal(h.cc, message, "ChannelHandle.Add")
+}
+
+// AddBatch is AddMessages on the handle's channel.
+func (h *ChannelHandle) AddBatch(messages []*discordgo.Message) error {
+	if held, err := h.cache.holdIfPaused(h.channelID, 0, "ChannelHandle.AddBatch", messages...); held {
+		return err
+	}
+	defer h.cache.enforceGlobalCaps()
+	if err := h.lock(); err != nil {
+		return err
+	}
+	defer h.cc.Unlock()
+	_, err := h.cache.addBatchLocked(h.cc, messages, "ChannelHandle.AddBatch")
+	return err
+}
+
+// Get returns all messages of the handle's channel in chronological order, in a freshly allocated slice.
+func (h *ChannelHandle) Get() ([]*discordgo.Message, error) {
+	if err := h.rlock(); err != nil {
+		return nil, err
+	}
+	defer h.cc.RUnlock()
+	h.cc.touch(0, h.cc.size)
+	return h.cc.messages(), nil
+}
+
+// GetLimit returns up to limit of the newest messages of the handle's channel in chronological order. It returns
+// ErrInvalidLimit if limit is not positive.
+func (h *ChannelHandle) GetLimit(limit int) ([]*discordgo.Message, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	if err := h.rlock(); err != nil {
+		return nil, err
+	}
+	defer h.cc.RUnlock()
+	h.cc.touch(h.cc.size-min(limit, h.cc.size), min(limit, h.cc.size))
+	return h.cc.newestMessages(limit), nil
+}
+
+// Clear is ClearChannel on the handle's channel.
+func (h *ChannelHandle) Clear() error {
+	if err := h.lock(); err != nil {
+		return err
+	}
+	defer h.cc.Unlock()
+	if h.cc.frozen.Load() {
+		return ErrChannelFrozen
+	}
+	h.cache.clearLocked("ClearChannel", h.cc)
+	return nil
+}
+
+// Stats is ChannelStats on the handle's channel.
+func (h *ChannelHandle) Stats() (ChannelStats, error) {
+	if err := h.rlock(); err != nil {
+		return ChannelStats{}, err
+	}
+	defer h.cc.RUnlock()
+	return h.cc.stats(), nil
+}
+
+// lock takes the channel's write lock, returning ErrChannelGone without holding it if the channel is no longer
+// cached under the handle's ID.
+func (h *ChannelHandle) lock() error {
+	h.cache.lockChannel(h.cc)
+	if h.gone() {
+		h.cc.Unlock()
+		return ErrChannelGone
+	}
+	h.cache.touchChannel(h.cc)
+	return nil
+}
+
+// rlock is lock with the channel's read lock.
+func (h *ChannelHandle) rlock() error {
+	h.cache.rlockChannel(h.cc)
+	if h.gone() {
+		h.cc.RUnlock()
+		return ErrChannelGone
+	}
+	h.cache.touchChannel(h.cc)
+	return nil
+}
+
+// gone reports whether the channel was retired or renamed. The caller must hold the channel lock.
+func (h *ChannelHandle) gone() bool {
+	return h.cc.retired || h.cc.id != h.channelID
+}
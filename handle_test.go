@@ -0,0 +1,171 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestChannelHandle(t *testing.T) {
+	cache := NewMessageCache(3)
+	h, err := cache.Channel("channel1")
+	if err != nil {
+		t.Fatalf("Channel returned error: %v", err)
+	}
+	if h.ChannelID() != "channel1" {
+		t.Errorf("Expected channel1, got %s", h.ChannelID())
+	}
+	if _, err := cache.MessageCount("channel1"); err != nil {
+		t.Errorf("Expected Channel to create the channel, got %v", err)
+	}
+
+	if err := h.Add(&discordgo.Message{ID: "100"}); err != nil {
+		t.Errorf("Add returned error: %v", err)
+	}
+	if err := h.AddBatch(testHistory(4)[1:]); err != nil {
+		t.Errorf("AddBatch returned error: %v", err)
+	}
+	if err := h.Add(&discordgo.Message{ID: "103"}); err != nil {
+		t.Errorf("Expected a duplicate add to be skipped, got %v", err)
+	}
+	msgs, err := h.Get()
+	if err != nil || fmt.Sprint(messageIDs(msgs)) != "[101 102 103]" {
+		t.Errorf("Get = %v (err %v), want [101 102 103]", messageIDs(msgs), err)
+	}
+	if msgs, err := h.GetLimit(2); err != nil || fmt.Sprint(messageIDs(msgs)) != "[102 103]" {
+		t.Errorf("GetLimit(2) = %v (err %v), want [102 103]", messageIDs(msgs), err)
+	}
+	if _, err := h.GetLimit(0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+	if stats, err := h.Stats(); err != nil || stats.Messages != 3 || stats.MaxMessages != 3 {
+		t.Errorf("Unexpected stats %+v (err %v)", stats, err)
+	}
+	if cached, _ := cache.GetMessages("channel1"); len(cached) != 3 {
+		t.Errorf("Expected handle adds to be visible through the cache, got %d messages", len(cached))
+	}
+
+	if err := h.Clear(); err != nil {
+		t.Errorf("Clear returned error: %v", err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 0 {
+		t.Errorf("Expected an empty channel after Clear, got %d messages", n)
+	}
+
+	if _, err := cache.Channel(""); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel, got %v", err)
+	}
+}
+
+func TestChannelHandleFrozen(t *testing.T) {
+	cache := NewMessageCache(3)
+	h, _ := cache.Channel("channel1")
+	h.Add(&discordgo.Message{ID: "1"})
+	cache.FreezeChannel("channel1")
+	if err := h.Clear(); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected ErrChannelFrozen, got %v", err)
+	}
+	h.Add(&discordgo.Message{ID: "2"})
+	if stats, _ := h.Stats(); stats.Messages != 1 {
+		t.Errorf("Expected adds to a frozen channel to be ignored, got %d messages", stats.Messages)
+	}
+}
+
+func TestChannelHandleGone(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		remove func(cache *MessageCache) error
+	}{
+		{"DeleteChannel", func(cache *MessageCache) error { return cache.DeleteChannel("channel1") }},
+		{"ReplaceChannel", func(cache *MessageCache) error { return cache.ReplaceChannel("channel1", testHistory(2)) }},
+		{"ReplaceAll", func(cache *MessageCache) error { return cache.ReplaceAll(nil) }},
+		{"RenameChannel", func(cache *MessageCache) error { return cache.RenameChannel("channel1", "channel2") }},
+	} {
+		cache := NewMessageCache(3)
+		h, _ := cache.Channel("channel1")
+		h.Add(&discordgo.Message{ID: "1"})
+		if err := tc.remove(cache); err != nil {
+			t.Fatalf("%s returned error: %v", tc.name, err)
+		}
+		if err := h.Add(&discordgo.Message{ID: "2"}); !errors.Is(err, ErrChannelGone) {
+			t.Errorf("%s: expected ErrChannelGone from Add, got %v", tc.name, err)
+		}
+		if err := h.AddBatch(testHistory(1)); !errors.Is(err, ErrChannelGone) {
+			t.Errorf("%s: expected ErrChannelGone from AddBatch, got %v", tc.name, err)
+		}
+		if _, err := h.Get(); !errors.Is(err, ErrChannelGone) {
+			t.Errorf("%s: expected ErrChannelGone from Get, got %v", tc.name, err)
+		}
+		if _, err := h.GetLimit(1); !errors.Is(err, ErrChannelGone) {
+			t.Errorf("%s: expected ErrChannelGone from GetLimit, got %v", tc.name, err)
+		}
+		if err := h.Clear(); !errors.Is(err, ErrChannelGone) {
+			t.Errorf("%s: expected ErrChannelGone from Clear, got %v", tc.name, err)
+		}
+		if _, err := h.Stats(); !errors.Is(err, ErrChannelGone) {
+			t.Errorf("%s: expected ErrChannelGone from Stats, got %v", tc.name, err)
+		}
+		if err := cache.Validate(); err != nil {
+			t.Errorf("%s: Validate failed: %v", tc.name, err)
+		}
+	}
+
+	cache := NewMessageCache(3, WithMaxChannels(1))
+	h, _ := cache.Channel("channel1")
+	cache.AddMessage("channel2", &discordgo.Message{ID: "1"})
+	if err := h.Add(&discordgo.Message{ID: "2"}); !errors.Is(err, ErrChannelGone) {
+		t.Errorf("Expected ErrChannelGone after the channel was evicted, got %v", err)
+	}
+}
+
+func TestChannelHandleConcurrentReplace(t *testing.T) {
+	cache := NewMessageCache(100)
+	h, _ := cache.Channel("channel1")
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 200 {
+			if err := h.Add(&discordgo.Message{ID: strconv.Itoa(i)}); err != nil && !errors.Is(err, ErrChannelGone) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			cache.ReplaceChannel("channel1", testHistory(5))
+		}
+	}()
+	wg.Wait()
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
+
+func BenchmarkChannelHandleGetLimit(b *testing.B) {
+	cache := NewMessageCache(100)
+	for i := range 1000 {
+		cache.AddMessage("channel"+strconv.Itoa(i), &discordgo.Message{ID: "1"})
+	}
+	h, _ := cache.Channel("channel500")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h.GetLimit(1)
+	}
+}
+
+func BenchmarkGetMessagesLimitLookup(b *testing.B) {
+	cache := NewMessageCache(100)
+	for i := range 1000 {
+		cache.AddMessage("channel"+strconv.Itoa(i), &discordgo.Message{ID: "1"})
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cache.GetMessagesLimit("channel500", 1)
+	}
+}
@@ -0,0 +1,111 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"runtime"
+	"slices"
+	"sync/atomic"
+)
+
+// SetHotWindow enables memory reclamation for extreme memory pressure. The newest n messages of every channel
+// are hot and always kept; older ones are cold and may be evicted whenever the estimated size of the cache is
+// above the limit set by SetSoftMemoryLimit. Reclamation runs after every garbage collection and on Reclaim,
+// and reports evicted messages to OnEvict with EvictReclaimed. Frozen channels are never reclaimed.
+//
+// Reclaimed messages are not cached anymore: reads return only what is left, while LatestOrFetch and GetOrFetch
+// see the channel as short and fetch the missing messages again through the loader. A non-positive n turns
+// reclamation off, the default.
+func (c *MessageCache) SetHotWindow(n int) {
+	c.hotWindow.Store(int64(max(n, 0)))
+	if n <= 0 {
+		c.stopReclaimer()
+		return
+	}
+	if r := new(reclaimer); c.reclaimer.CompareAndSwap(nil, r) {
+		c.armReclaimer(r)
+	}
+}
+
+// SetSoftMemoryLimit sets the estimated size in bytes, as reported by Stats.EstimatedBytes, that reclamation
+// tries to keep the cache under by evicting cold messages. The limit is soft: hot messages are kept even if they
+// alone exceed it. A non-positive limit disables reclamation until set again.
+func (c *MessageCache) SetSoftMemoryLimit(bytes int64) {
+	c.softLimit.Store(max(bytes, 0))
+}
+
+// Reclaim evicts cold messages, oldest first and starting with the largest channels, until the cache is back
+// under its soft memory limit or only hot messages are left, and returns how many were evicted. It does
+// nothing unless both SetHotWindow and SetSoftMemoryLimit are set.
+func (c *MessageCache) Reclaim() int {
+	hot, limit := int(c.hotWindow.Load()), c.softLimit.Load()
+	if hot <= 0 || limit <= 0 {
+		return 0
+	}
+	type channelBytes struct {
+		cc    *ChannelCache
+		bytes int64
+	}
+	var total int64
+	caches := c.channelCaches()
+	sizes := make([]channelBytes, len(caches))
+	for i, cc := range caches {
+		c.rlockChannel(cc)
+		sizes[i] = channelBytes{cc: cc, bytes: cc.bytes}
+		cc.RUnlock()
+		total += sizes[i].bytes
+	}
+	slices.SortFunc(sizes, func(a, b channelBytes) int { return cmp.Compare(b.bytes, a.bytes) })
+	reclaimed := 0
+	for _, size := range sizes {
+		if total <= limit {
+			break
+		}
+		cc := size.cc
+		c.lockChannel(cc)
+		for !cc.retired && !cc.frozen.Load() && cc.size > hot && total > limit {
+			before := cc.bytes
+			cc.evictAt(0, EvictReclaimed)
+			total -= before - cc.bytes
+			reclaimed++
+		}
+		cc.Unlock()
+	}
+	c.auditSweep("Reclaim", reclaimed)
+	return reclaimed
+}
+
+// reclaimer runs Reclaim after garbage collections until stopped.
+type reclaimer struct {
+	stopped atomic.Bool // stopped ends the re-arming of the collection sentinel
+	running atomic.Bool // running is set while a Reclaim started by a collection is in progress
+}
+
+// gcSentinel is an unreachable object whose finalizer runs once the next garbage collection found it. It holds
+// a pointer so that it is never batched with other tiny allocations, which could delay its finalizer.
+type gcSentinel struct {
+	r *reclaimer
+}
+
+// armReclaimer schedules a Reclaim for the next garbage collection, re-arming itself every time until r is
+// stopped.
+func (c *MessageCache) armReclaimer(r *reclaimer) {
+	runtime.SetFinalizer(&gcSentinel{r: r}, func(s *gcSentinel) {
+		if s.r.stopped.Load() {
+			return
+		}
+		if s.r.running.CompareAndSwap(false, true) {
+			go func() {
+				defer s.r.running.Store(false)
+				c.Reclaim()
+			}()
+		}
+		c.armReclaimer(s.r)
+	})
+}
+
+// stopReclaimer stops running Reclaim after garbage collections. It is safe to call more than once.
+func (c *MessageCache) stopReclaimer() {
+	if r := c.reclaimer.Swap(nil); r != nil {
+		r.stopped.Store(true)
+	}
+}
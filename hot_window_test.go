@@ -0,0 +1,113 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func addBulky(cache *MessageCache, channelID string, first, n int) {
+	for i := first; i < first+n; i++ {
+		cache.AddMessage(channelID, &discordgo.Message{ID: fmt.Sprint(i), Content: strings.Repeat("x", 1000)})
+	}
+}
+
+func TestReclaimEvictsColdMessagesOnly(t *testing.T) {
+	var reasons []EvictReason
+	cache := NewMessageCache(100, OnEvict(func(_ string, _ *discordgo.Message, reason EvictReason) {
+		reasons = append(reasons, reason)
+	}))
+	addBulky(cache, "channel1", 100, 10)
+	addBulky(cache, "channel2", 200, 2)
+
+	if got := cache.Reclaim(); got != 0 {
+		t.Errorf("Expected no reclamation before SetHotWindow, got %d", got)
+	}
+	cache.SetHotWindow(3)
+	defer cache.Close()
+	if got := cache.Reclaim(); got != 0 {
+		t.Errorf("Expected no reclamation without a soft limit, got %d", got)
+	}
+	cache.SetSoftMemoryLimit(1)
+	if got := cache.Reclaim(); got != 7 {
+		t.Errorf("Expected the 7 cold messages to be reclaimed, got %d", got)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if ids := messageIDs(msgs); strings.Join(ids, ",") != "107,108,109" {
+		t.Errorf("Expected the hot window to be kept, got %v", ids)
+	}
+	if msgs, _ := cache.GetMessages("channel2"); len(msgs) != 2 {
+		t.Errorf("Expected channels within the hot window to be untouched, got %d messages", len(msgs))
+	}
+	for _, reason := range reasons {
+		if reason != EvictReclaimed {
+			t.Errorf("Expected reason %q, got %q", EvictReclaimed, reason)
+		}
+	}
+}
+
+func TestReclaimStopsUnderSoftLimit(t *testing.T) {
+	cache := NewMessageCache(100)
+	addBulky(cache, "channel1", 100, 10)
+	cache.SetHotWindow(1)
+	defer cache.Close()
+	limit := cache.Stats().EstimatedBytes * 6 / 10
+	cache.SetSoftMemoryLimit(limit)
+	if got := cache.Reclaim(); got != 4 {
+		t.Errorf("Expected 4 messages reclaimed to get under the limit, got %d", got)
+	}
+	if got := cache.Stats().EstimatedBytes; got > limit {
+		t.Errorf("Expected the cache under %d bytes, got %d", limit, got)
+	}
+}
+
+func TestReclaimedMessagesAreFetchedAgain(t *testing.T) {
+	history := testHistory(10)
+	for _, msg := range history {
+		msg.Content = strings.Repeat("x", 1000)
+	}
+	loader := &fakeLoader{history: history}
+	cache := NewMessageCache(100, WithLoader(loader.load))
+	cache.AddMessages("channel1", history)
+	cache.SetHotWindow(2)
+	defer cache.Close()
+	cache.SetSoftMemoryLimit(1)
+	cache.Reclaim()
+
+	msgs, err := cache.LatestOrFetch("channel1", 10)
+	if err != nil || len(msgs) != 10 {
+		t.Fatalf("Expected the reclaimed messages to be fetched again, got %d, %v", len(msgs), err)
+	}
+	if loader.calls != 1 {
+		t.Errorf("Expected one loader call, got %d", loader.calls)
+	}
+}
+
+func TestReclaimRunsAfterGarbageCollection(t *testing.T) {
+	cache := NewMessageCache(100)
+	addBulky(cache, "channel1", 100, 5)
+	cache.SetSoftMemoryLimit(1)
+	cache.SetHotWindow(1)
+	defer cache.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.Stats().Messages > 1 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if got := cache.Stats().Messages; got != 1 {
+		t.Errorf("Expected a garbage collection to reclaim the cold messages, got %d left", got)
+	}
+
+	cache.SetHotWindow(0)
+	addBulky(cache, "channel1", 200, 5)
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+	if got := cache.Stats().Messages; got != 6 {
+		t.Errorf("Expected no reclamation once the hot window is off, got %d left", got)
+	}
+}
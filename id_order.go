@@ -0,0 +1,95 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CompareSnowflakes orders message IDs as Discord snowflakes, numerically, returning -1, 0 or 1. IDs that do
+// not both parse as snowflakes compare lexically. It is the default ID comparator.
+func CompareSnowflakes(a, b string) int {
+	x, errA := strconv.ParseUint(a, 10, 64)
+	y, errB := strconv.ParseUint(b, 10, 64)
+	if errA != nil || errB != nil {
+		return cmp.Compare(a, b)
+	}
+	return cmp.Compare(x, y)
+}
+
+// WithIDComparator sets how message IDs are ordered wherever the cache sorts by ID, such as when merging fetched
+// messages or replacing a channel's contents. compare returns a negative number when a is older than b, zero
+// when they are equal and a positive number otherwise. The default is CompareSnowflakes.
+func WithIDComparator(compare func(a, b string) int) Option {
+	return func(c *MessageCache) {
+		c.idComparator = compare
+	}
+}
+
+// sortByID stably sorts entries by message ID. If the comparator panics and the panic is recovered, entries are
+// left partially sorted and the *CallbackPanicError is returned.
+func (c *MessageCache) sortByID(entries []cachedMessage) error {
+	return c.guard("IDComparator", func() {
+		slices.SortStableFunc(entries, func(a, b cachedMessage) int {
+			return c.compareIDs(a.message.ID, b.message.ID)
+		})
+	})
+}
+
+// sortMessagesByID is sortByID for messages, which must not be nil.
+func (c *MessageCache) sortMessagesByID(msgs []*discordgo.Message) error {
+	return c.guard("IDComparator", func() {
+		slices.SortStableFunc(msgs, func(a, b *discordgo.Message) int {
+			return c.compareIDs(a.ID, b.ID)
+		})
+	})
+}
+
+// compareIDs orders two message IDs with the configured comparator.
+func (c *MessageCache) compareIDs(a, b string) int {
+	if c.idComparator == nil {
+		return CompareSnowflakes(a, b)
+	}
+	return c.idComparator(a, b)
+}
+
+// IsOrdered reports whether a channel's messages are stored in strictly increasing ID order, as ordered by the
+// ID comparator. Adding messages out of order, for example from a backfill, breaks this. It returns
+// ErrCacheMiss for unknown channels.
+func (c *MessageCache) IsOrdered(channelID string) (bool, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return false, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	ordered := true
+	err := c.guard("IDComparator", func() {
+		for i := 1; i < cc.size && ordered; i++ {
+			ordered = c.compareIDs(cc.at(i-1).message.ID, cc.at(i).message.ID) < 0
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+	return ordered, nil
+}
+
+// ReorderChannel sorts a channel's messages by ID, keeping the relative order of equal IDs, so that the
+// newest-first queries see them chronologically again. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) ReorderChannel(channelID string) error {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return ErrCacheMiss
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	entries := cc.entries()
+	if err := c.sortByID(entries); err != nil {
+		return err
+	}
+	cc.reset(entries)
+	return nil
+}
@@ -0,0 +1,110 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCompareSnowflakes(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"2", "10", -1},
+		{"10", "2", 1},
+		{"175928847299117063", "175928847299117063", 0},
+		{"abc", "abd", -1},
+		{"9", "abc", -1},
+		{"", "1", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareSnowflakes(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareSnowflakes(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIDComparatorOrdering(t *testing.T) {
+	ids := func(values ...string) []*discordgo.Message {
+		msgs := make([]*discordgo.Message, len(values))
+		for i, id := range values {
+			msgs[i] = &discordgo.Message{ID: id}
+		}
+		return msgs
+	}
+	comparators := []struct {
+		name    string
+		compare func(a, b string) int
+		want    string
+	}{
+		{"snowflake", CompareSnowflakes, "[1 2 3 10]"},
+		{"string", cmp.Compare[string], "[1 10 2 3]"},
+	}
+	for _, tt := range comparators {
+		cache := NewMessageCache(10, WithIDComparator(tt.compare), WithLoader(func(context.Context, string, int) ([]*discordgo.Message, error) {
+			return ids("10", "1"), nil
+		}))
+
+		// Fetched messages are placed around the cached ones by the comparator; cached ones keep their order.
+		cache.AddMessages("merged", ids("2", "3"))
+		cache.LatestOrFetch("merged", 4)
+		msgs, _ := cache.GetMessages("merged")
+		if got := fmt.Sprint(messageIDs(msgs)); got != tt.want {
+			t.Errorf("%s: merged messages ordered %v, want %v", tt.name, got, tt.want)
+		}
+
+		cache.ReplaceChannel("replaced", ids("10", "3", "1", "2"))
+		msgs, _ = cache.GetMessages("replaced")
+		if got := fmt.Sprint(messageIDs(msgs)); got != tt.want {
+			t.Errorf("%s: replaced messages ordered %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReorderChannel(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(3))
+	if ok, err := cache.IsOrdered("channel1"); !ok || err != nil {
+		t.Errorf("Expected chronological adds to be ordered, got %v (err %v)", ok, err)
+	}
+
+	// Wrap the ring with out-of-order adds, evicting 100 and 101: 102 104 103 99 98.
+	history := testHistory(5)
+	cache.AddMessages("channel1", []*discordgo.Message{history[4], history[3], {ID: "99"}, {ID: "98"}})
+	if ok, _ := cache.IsOrdered("channel1"); ok {
+		t.Error("Expected out-of-order adds to be detected.")
+	}
+
+	if err := cache.ReorderChannel("channel1"); err != nil {
+		t.Fatalf("ReorderChannel returned error: %v", err)
+	}
+	if ok, _ := cache.IsOrdered("channel1"); !ok {
+		t.Error("Expected the channel to be ordered after ReorderChannel.")
+	}
+	msgs, _ := cache.GetMessagesLimit("channel1", 2)
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[103 104]" {
+		t.Errorf("Expected the newest messages by ID after repair, got %v", got)
+	}
+	msgs, _ = cache.GetMessagesRange("channel1", 2, 10)
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[98 99 102]" {
+		t.Errorf("Unexpected older messages after repair: %v", got)
+	}
+	if ok, _ := cache.Contains("channel1", "98"); !ok {
+		t.Error("Expected the ID map to survive the reorder.")
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Expected a valid cache after reorder, got %v", err)
+	}
+
+	if _, err := cache.IsOrdered("unknown"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+	if err := cache.ReorderChannel("unknown"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package dgocacheler
+
+import "github.com/bwmarrin/discordgo"
+
+// WithTagInteractions flags interaction responses (see isInteractionResponse) as they are cached, so they can be
+// queried with IsInteractionResponse and filtered with Query.InteractionsOnly and Query.ExcludeInteractions.
+func WithTagInteractions() Option {
+	return func(c *MessageCache) {
+		c.tagInteractions = true
+	}
+}
+
+// WithIgnoreInteractions drops interaction responses instead of caching them.
+func WithIgnoreInteractions() Option {
+	return func(c *MessageCache) {
+		c.ignoreInteractions = true
+	}
+}
+
+// WithInteractionBotID treats every message authored by the user botUserID, normally the bot's own user, as an
+// interaction response for WithTagInteractions and WithIgnoreInteractions. An empty ID disables the rule.
+func WithInteractionBotID(botUserID string) Option {
+	return func(c *MessageCache) {
+		c.interactionBotID = botUserID
+	}
+}
+
+// isInteractionResponse reports whether a message was produced by an application: it carries interaction
+// metadata, has one of the command response message types, carries application data, or was authored by the
+// user set with WithInteractionBotID. discordgo does not expose a message's application ID, so the application
+// rule checks the Application field instead. This is the single place these rules live.
+func (c *MessageCache) isInteractionResponse(msg *discordgo.Message) bool {
+	if msg.Interaction != nil || msg.Application != nil {
+		return true
+	}
+	if c.interactionBotID != "" && msg.Author != nil && msg.Author.ID == c.interactionBotID {
+		return true
+	}
+	switch msg.Type {
+	case discordgo.MessageTypeChatInputCommand, discordgo.MessageTypeContextMenuCommand:
+		return true
+	}
+	return false
+}
+
+// skipInteraction reports whether a message must be dropped because WithIgnoreInteractions is set.
+func (c *MessageCache) skipInteraction(msg *discordgo.Message) bool {
+	return c.ignoreInteractions && c.isInteractionResponse(msg)
+}
+
+// IsInteractionResponse reports whether a cached message was flagged as an interaction response. It returns
+// ErrUnsupported unless WithTagInteractions is set, ErrCacheMiss for unknown channels and ErrMessageNotFound
+// if the message is not cached.
+func (c *MessageCache) IsInteractionResponse(channelID, messageID string) (bool, error) {
+	if !c.tagInteractions {
+		return false, ErrUnsupported
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return false, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	i := cc.find(messageID)
+	if i < 0 {
+		return false, ErrMessageNotFound
+	}
+	return cc.at(i).interaction, nil
+}
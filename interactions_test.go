@@ -0,0 +1,91 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// interactionFixtures are representative messages: a slash command response, a context menu response,
+// a deferred follow-up carrying interaction metadata, a reply, a plain message, an application message, a
+// message by the bot user "bot" and one by another bot.
+func interactionFixtures() []*discordgo.Message {
+	return []*discordgo.Message{
+		{ID: "1", Type: discordgo.MessageTypeChatInputCommand},
+		{ID: "2", Type: discordgo.MessageTypeContextMenuCommand},
+		{ID: "3", Type: discordgo.MessageTypeDefault, Interaction: &discordgo.MessageInteraction{ID: "9", Name: "ping"}},
+		{ID: "4", Type: discordgo.MessageTypeReply},
+		{ID: "5", Type: discordgo.MessageTypeDefault},
+		{ID: "6", Type: discordgo.MessageTypeDefault, Application: &discordgo.MessageApplication{ID: "10", Name: "game"}},
+		{ID: "7", Type: discordgo.MessageTypeDefault, Author: &discordgo.User{ID: "bot", Bot: true}},
+		{ID: "8", Type: discordgo.MessageTypeDefault, Author: &discordgo.User{ID: "other", Bot: true}},
+	}
+}
+
+func TestIsInteractionResponseRules(t *testing.T) {
+	want := map[string]bool{"1": true, "2": true, "3": true, "4": false, "5": false, "6": true, "7": true, "8": false}
+	cache := NewMessageCache(10, WithInteractionBotID("bot"))
+	for _, msg := range interactionFixtures() {
+		if got := cache.isInteractionResponse(msg); got != want[msg.ID] {
+			t.Errorf("isInteractionResponse(%s) = %v, want %v", msg.ID, got, want[msg.ID])
+		}
+	}
+	want["7"] = false
+	cache = NewMessageCache(10)
+	for _, msg := range interactionFixtures() {
+		if got := cache.isInteractionResponse(msg); got != want[msg.ID] {
+			t.Errorf("Without a bot ID, isInteractionResponse(%s) = %v, want %v", msg.ID, got, want[msg.ID])
+		}
+	}
+}
+
+func TestTagInteractions(t *testing.T) {
+	cache := NewMessageCache(10, WithTagInteractions())
+	cache.AddMessages("channel1", interactionFixtures())
+
+	if ok, err := cache.IsInteractionResponse("channel1", "3"); err != nil || !ok {
+		t.Errorf("Expected message 3 to be tagged, got %v (err %v)", ok, err)
+	}
+	if ok, err := cache.IsInteractionResponse("channel1", "5"); err != nil || ok {
+		t.Errorf("Expected message 5 not to be tagged, got %v (err %v)", ok, err)
+	}
+	if _, err := cache.IsInteractionResponse("channel1", "99"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+
+	msgs, err := cache.Query("channel1").InteractionsOnly().Run()
+	if err != nil || fmt.Sprint(messageIDs(msgs)) != "[1 2 3 6]" {
+		t.Errorf("Unexpected interactions: %v (err %v)", messageIDs(msgs), err)
+	}
+	msgs, err = cache.Query("channel1").ExcludeInteractions().Run()
+	if err != nil || fmt.Sprint(messageIDs(msgs)) != "[4 5 7 8]" {
+		t.Errorf("Unexpected non-interactions: %v (err %v)", messageIDs(msgs), err)
+	}
+
+	// Editing a message re-evaluates its tag.
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "5", Interaction: &discordgo.MessageInteraction{ID: "8"}})
+	if ok, _ := cache.IsInteractionResponse("channel1", "5"); !ok {
+		t.Error("Expected the updated message to be tagged.")
+	}
+}
+
+func TestTagInteractionsDisabled(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", interactionFixtures())
+	if _, err := cache.IsInteractionResponse("channel1", "1"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	if _, err := cache.Query("channel1").InteractionsOnly().Run(); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+}
+
+func TestIgnoreInteractions(t *testing.T) {
+	cache := NewMessageCache(10, WithIgnoreInteractions(), WithInteractionBotID("bot"))
+	cache.AddMessages("channel1", interactionFixtures())
+	if msgs, _ := cache.GetMessages("channel1"); fmt.Sprint(messageIDs(msgs)) != "[4 5 8]" {
+		t.Errorf("Expected interaction responses to be dropped, got %v", messageIDs(msgs))
+	}
+}
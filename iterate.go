@@ -0,0 +1,31 @@
+package dgocacheler
+
+import (
+	"iter"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// IterateSnapshot returns an iterator over a channel's messages in chronological order.
+//
+// The sequence is write-stable: the message pointers are captured once, under a brief read lock, when
+// IterateSnapshot is called. Iterating takes no locks at all, and messages added, evicted or removed afterwards
+// never affect the sequence, so it is safe for long scans of busy channels. The iterator may be used repeatedly
+// and always yields the same messages. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) IterateSnapshot(channelID string) (iter.Seq[*discordgo.Message], error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	snapshot := cc.messages()
+	cc.RUnlock()
+
+	return func(yield func(*discordgo.Message) bool) {
+		for _, msg := range snapshot {
+			if !yield(msg) {
+				return
+			}
+		}
+	}, nil
+}
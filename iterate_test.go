@@ -0,0 +1,65 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestIterateSnapshotIsWriteStable(t *testing.T) {
+	cache := NewMessageCache(5)
+	for i := 0; i < 5; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+
+	seq, err := cache.IterateSnapshot("channel1")
+	if err != nil {
+		t.Fatalf("IterateSnapshot returned error: %v", err)
+	}
+
+	var ids []string
+	for msg := range seq {
+		ids = append(ids, msg.ID)
+		// Every add evicts the oldest message; none of this may leak into the running iteration.
+		cache.AddMessage("channel1", &discordgo.Message{ID: "new" + msg.ID})
+		cache.SetMaxMessages(2)
+	}
+	if fmt.Sprint(ids) != "[0 1 2 3 4]" {
+		t.Errorf("Expected the original snapshot, got %v", ids)
+	}
+
+	// A second pass over the same iterator yields the same snapshot.
+	ids = ids[:0]
+	for msg := range seq {
+		ids = append(ids, msg.ID)
+	}
+	if fmt.Sprint(ids) != "[0 1 2 3 4]" {
+		t.Errorf("Expected the snapshot to be reusable, got %v", ids)
+	}
+}
+
+func TestIterateSnapshotEarlyExit(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(5))
+	seq, _ := cache.IterateSnapshot("channel1")
+
+	count := 0
+	for range seq {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+	if count != 2 {
+		t.Errorf("Expected to stop after 2 messages, got %d", count)
+	}
+}
+
+func TestIterateSnapshotUnknownChannel(t *testing.T) {
+	cache := NewMessageCache(5)
+	if _, err := cache.IterateSnapshot("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
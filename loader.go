@@ -0,0 +1,117 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Loader fetches up to limit of the most recent messages of a channel, typically from the Discord API.
+// Messages may be returned in any order; the cache stores them chronologically.
+type Loader func(ctx context.Context, channelID string, limit int) ([]*discordgo.Message, error)
+
+// discordMaxMessagesPerRequest is the largest page the Discord API returns for a channel messages request.
+const discordMaxMessagesPerRequest = 100
+
+// SessionLoader returns a Loader that fetches messages through a discordgo session, paging backwards as needed.
+func SessionLoader(s *discordgo.Session) Loader {
+	return func(ctx context.Context, channelID string, limit int) ([]*discordgo.Message, error) {
+		var msgs []*discordgo.Message
+		beforeID := ""
+		for len(msgs) < limit {
+			page, err := s.ChannelMessages(channelID, min(limit-len(msgs), discordMaxMessagesPerRequest), beforeID, "", "", discordgo.WithContext(ctx))
+			if err != nil {
+				return nil, err
+			}
+			msgs = append(msgs, page...)
+			if len(page) < discordMaxMessagesPerRequest {
+				break
+			}
+			beforeID = page[len(page)-1].ID
+		}
+		return msgs, nil
+	}
+}
+
+// WithLoader configures the Loader used to fetch messages that are missing from the cache.
+func WithLoader(loader Loader) Option {
+	return func(c *MessageCache) {
+		c.loader = loader
+	}
+}
+
+// SetLoader sets the Loader used to fetch messages that are missing from the cache. A nil loader disables fetching.
+func (c *MessageCache) SetLoader(loader Loader) {
+	c.lockGlobal()
+	defer c.Unlock()
+	c.loader = loader
+}
+
+// LatestOrFetch returns the n newest messages of a channel. If fewer than n messages are cached, the configured
+// loader is used to fetch and store more first. Without a loader, whatever is cached is returned. It returns
+// ErrInvalidChannel for an empty channel ID without calling the loader.
+func (c *MessageCache) LatestOrFetch(channelID string, n int) ([]*discordgo.Message, error) {
+	return c.LatestOrFetchContext(context.Background(), channelID, n)
+}
+
+// LatestOrFetchContext is like LatestOrFetch but passes ctx to the loader.
+func (c *MessageCache) LatestOrFetchContext(ctx context.Context, channelID string, n int) (msgs []*discordgo.Message, err error) {
+	ctx, span := c.startSpan(ctx, "LatestOrFetch")
+	defer func() {
+		span.SetAttribute(AttrResultCount, len(msgs))
+		endSpan(span, err)
+	}()
+
+	channelID, err = c.resolveChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttribute(AttrChannelID, channelID)
+	if n <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	if cached, ok := c.GetMessagesLimit(channelID, n); ok && len(cached) == n {
+		return cached, nil
+	}
+
+	c.rlockGlobal()
+	loader := c.loader
+	c.RUnlock()
+	if loader != nil {
+		c.profileOp(ctx, "fetch", channelID, func(ctx context.Context) {
+			var fetched []*discordgo.Message
+			if panicErr := c.guard("Loader", func() { fetched, err = loader(ctx, channelID, n) }); panicErr != nil {
+				err = panicErr
+			}
+			if err == nil {
+				err = c.mergeMessages(channelID, fetched, "LatestOrFetch")
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	msgs, _ = c.GetMessagesLimit(channelID, n)
+	if msgs == nil {
+		msgs = []*discordgo.Message{}
+	}
+	return msgs, nil
+}
+
+// mergeMessages stores fetched messages through prependBatch, in ID order: those older than the cached
+// messages fill the free room at the old end of the channel and the others are added as AddMessages would, so
+// the usual admission checks, eviction callbacks and spilling apply. source names the public method fetching
+// them. Near-duplicates suppressed by SetSimilarityDedup are not reported as a failure.
+func (c *MessageCache) mergeMessages(channelID string, fetched []*discordgo.Message, source string) error {
+	fetched = slices.DeleteFunc(slices.Clone(fetched), func(msg *discordgo.Message) bool { return msg == nil })
+	if err := c.sortMessagesByID(fetched); err != nil {
+		return err
+	}
+	if _, err := c.prependBatch(channelID, fetched, source); err != nil && !errors.Is(err, ErrSuppressedDuplicate) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeLoader serves the newest messages of a fixed channel history and counts its calls.
+type fakeLoader struct {
+	history []*discordgo.Message
+	calls   int
+	err     error
+}
+
+func (f *fakeLoader) load(ctx context.Context, channelID string, limit int) ([]*discordgo.Message, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	start := max(0, len(f.history)-limit)
+	return f.history[start:], nil
+}
+
+func testHistory(n int) []*discordgo.Message {
+	msgs := make([]*discordgo.Message, n)
+	for i := range msgs {
+		msgs[i] = &discordgo.Message{ID: fmt.Sprint(100 + i)}
+	}
+	return msgs
+}
+
+func messageIDs(msgs []*discordgo.Message) []string {
+	ids := make([]string, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID
+	}
+	return ids
+}
+
+func TestLatestOrFetchCacheHit(t *testing.T) {
+	history := testHistory(10)
+	loader := &fakeLoader{history: history}
+	cache := NewMessageCache(10, WithLoader(loader.load))
+	cache.AddMessages("channel1", history)
+
+	msgs, err := cache.LatestOrFetch("channel1", 3)
+	if err != nil {
+		t.Fatalf("LatestOrFetch returned error: %v", err)
+	}
+	if fmt.Sprint(messageIDs(msgs)) != "[107 108 109]" {
+		t.Errorf("Unexpected messages: %v", messageIDs(msgs))
+	}
+	if loader.calls != 0 {
+		t.Errorf("Loader should not be called on a cache hit, got %d calls", loader.calls)
+	}
+}
+
+func TestLatestOrFetchPartialCache(t *testing.T) {
+	history := testHistory(10)
+	loader := &fakeLoader{history: history}
+	cache := NewMessageCache(10, WithLoader(loader.load))
+	cache.AddMessages("channel1", history[8:])
+
+	msgs, err := cache.LatestOrFetch("channel1", 5)
+	if err != nil {
+		t.Fatalf("LatestOrFetch returned error: %v", err)
+	}
+	if fmt.Sprint(messageIDs(msgs)) != "[105 106 107 108 109]" {
+		t.Errorf("Unexpected messages: %v", messageIDs(msgs))
+	}
+	if loader.calls != 1 {
+		t.Errorf("Expected one loader call, got %d", loader.calls)
+	}
+
+	// The fetched messages are now cached without duplicating the ones already present.
+	if cached, _ := cache.GetMessages("channel1"); len(cached) != 5 {
+		t.Errorf("Expected 5 cached messages, got %v", messageIDs(cached))
+	}
+}
+
+func TestLatestOrFetchWithoutLoader(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(2))
+
+	msgs, err := cache.LatestOrFetch("channel1", 5)
+	if err != nil || len(msgs) != 2 {
+		t.Errorf("Expected the 2 cached messages, got %d (err %v)", len(msgs), err)
+	}
+
+	msgs, err = cache.LatestOrFetch("missing", 5)
+	if err != nil || msgs == nil || len(msgs) != 0 {
+		t.Errorf("Expected an empty slice for an unknown channel, got %v (err %v)", msgs, err)
+	}
+
+	if _, err := cache.LatestOrFetch("channel1", 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+}
+
+func TestLatestOrFetchLoaderError(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	loader := &fakeLoader{err: errFetch}
+	cache := NewMessageCache(10)
+	cache.SetLoader(loader.load)
+
+	if _, err := cache.LatestOrFetch("channel1", 5); !errors.Is(err, errFetch) {
+		t.Errorf("Expected the loader error, got %v", err)
+	}
+}
+
+func TestLatestOrFetchEmptyChannel(t *testing.T) {
+	loader := &fakeLoader{history: testHistory(3)}
+	cache := NewMessageCache(10)
+	cache.SetLoader(loader.load)
+
+	if _, err := cache.LatestOrFetch("", 2); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel, got %v", err)
+	}
+	if n, _ := cache.MessageCount(""); loader.calls != 0 || n != 0 {
+		t.Errorf("Expected no load for an empty channel ID, got %d calls and %d messages", loader.calls, n)
+	}
+
+	cache.SetDefaultChannel("orphans")
+	if msgs, err := cache.LatestOrFetch("", 2); err != nil || len(msgs) != 2 {
+		t.Errorf("Expected the default channel loaded, got %d messages (err %v)", len(msgs), err)
+	}
+	if n, _ := cache.MessageCount("orphans"); n != 2 {
+		t.Errorf("Expected the loaded messages in the default channel, got %d", n)
+	}
+}
+
+func TestLatestOrFetchAdmission(t *testing.T) {
+	history := testHistory(6) // 100..105
+	loader := &fakeLoader{history: history}
+	var evicted []string
+	cache := NewMessageCache(4, WithLoader(loader.load), WithGlobalDedup(), OnEvict(func(channelID string, msg *discordgo.Message, reason EvictReason) {
+		evicted = append(evicted, msg.ID)
+	}))
+	cache.AddMessage("other", history[4])
+	cache.AddMessages("channel1", history[1:3])
+
+	msgs, err := cache.LatestOrFetch("channel1", 6)
+	if got := fmt.Sprint(messageIDs(msgs)); err != nil || got != "[101 102 103 105]" {
+		t.Errorf("Expected the fetched messages admitted like adds, got %v (err %v)", got, err)
+	}
+	if fmt.Sprint(evicted) != "[100]" {
+		t.Errorf("Expected the displaced message reported to OnEvict, got %v", evicted)
+	}
+
+	cache.SealChannel("channel1")
+	if _, err := cache.LatestOrFetch("channel1", 6); !errors.Is(err, ErrChannelSealed) {
+		t.Errorf("Expected ErrChannelSealed, got %v", err)
+	}
+}
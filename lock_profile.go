@@ -0,0 +1,154 @@
+package dgocacheler
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// lockWaitBuckets is the number of power-of-two histogram buckets; the last bucket collects waits of ~1s and above.
+const lockWaitBuckets = 31
+
+// lockProfile holds the wait-time histograms for the global and per-channel locks.
+type lockProfile struct {
+	global  waitHistogram // global records waits for the MessageCache lock
+	channel waitHistogram // channel records waits for ChannelCache locks
+}
+
+// waitHistogram is a lock-free histogram of wait durations. Bucket i counts waits in [2^(i-1), 2^i) nanoseconds.
+type waitHistogram struct {
+	buckets     [lockWaitBuckets]atomic.Uint64
+	count       atomic.Uint64
+	over1ms     atomic.Uint64
+	over10ms    atomic.Uint64
+	over100ms   atomic.Uint64
+	totalWaitNs atomic.Uint64
+}
+
+// LockWaitStats summarizes the time spent waiting to acquire a lock. Percentiles are bucket upper bounds,
+// so they are accurate to within a factor of two.
+type LockWaitStats struct {
+	Acquisitions uint64 // Acquisitions is the number of lock acquisitions observed
+	TotalWaitNs  uint64 // TotalWaitNs is the total time spent waiting, in nanoseconds
+	P50WaitNs    uint64 // P50WaitNs is the median wait, in nanoseconds
+	P99WaitNs    uint64 // P99WaitNs is the 99th percentile wait, in nanoseconds
+	Over1ms      uint64 // Over1ms counts acquisitions that waited at least 1ms
+	Over10ms     uint64 // Over10ms counts acquisitions that waited at least 10ms
+	Over100ms    uint64 // Over100ms counts acquisitions that waited at least 100ms
+}
+
+// observe records a single wait.
+func (h *waitHistogram) observe(wait time.Duration) {
+	ns := uint64(max(wait, 0))
+	h.buckets[min(bits.Len64(ns), lockWaitBuckets-1)].Add(1)
+	h.count.Add(1)
+	h.totalWaitNs.Add(ns)
+	if wait >= time.Millisecond {
+		h.over1ms.Add(1)
+		if wait >= 10*time.Millisecond {
+			h.over10ms.Add(1)
+			if wait >= 100*time.Millisecond {
+				h.over100ms.Add(1)
+			}
+		}
+	}
+}
+
+// snapshot summarizes the histogram. Concurrent observations may be partially included.
+func (h *waitHistogram) snapshot() LockWaitStats {
+	var counts [lockWaitBuckets]uint64
+	var total uint64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+		total += counts[i]
+	}
+	return LockWaitStats{
+		Acquisitions: h.count.Load(),
+		TotalWaitNs:  h.totalWaitNs.Load(),
+		P50WaitNs:    percentile(counts[:], total, 50),
+		P99WaitNs:    percentile(counts[:], total, 99),
+		Over1ms:      h.over1ms.Load(),
+		Over10ms:     h.over10ms.Load(),
+		Over100ms:    h.over100ms.Load(),
+	}
+}
+
+// percentile returns the upper bound of the bucket containing the p-th percentile observation.
+func percentile(counts []uint64, total uint64, p uint64) uint64 {
+	if total == 0 {
+		return 0
+	}
+	rank := (total*p + 99) / 100
+	var seen uint64
+	for i, n := range counts {
+		seen += n
+		if seen >= rank {
+			return 1<<i - 1
+		}
+	}
+	return 1<<(len(counts)-1) - 1
+}
+
+// SetContentionTracking turns counting of the add lock paths on or off. While on, every add that found its
+// channel with a lookup (the fast path) or had to take the global write lock to create it (the slow path) is
+// counted, as Stats reports in AddFastPath and AddSlowPath; a high share of slow paths under load suggests
+// sharding channels. Counts survive turning tracking off. Tracking is off by default and costs nothing then.
+func (c *MessageCache) SetContentionTracking(enabled bool) {
+	c.contentionTracking.Store(enabled)
+}
+
+// countAddPath records whether an add took the slow path when contention tracking is enabled.
+func (c *MessageCache) countAddPath(slow bool) {
+	if !c.contentionTracking.Load() {
+		return
+	}
+	if slow {
+		c.addSlowPath.Add(1)
+	} else {
+		c.addFastPath.Add(1)
+	}
+}
+
+// lockGlobal acquires the global write lock, recording the wait when lock profiling is enabled.
+func (c *MessageCache) lockGlobal() {
+	if c.lockProfile == nil {
+		c.Lock()
+		return
+	}
+	start := time.Now()
+	c.Lock()
+	c.lockProfile.global.observe(time.Since(start))
+}
+
+// rlockGlobal acquires the global read lock, recording the wait when lock profiling is enabled.
+func (c *MessageCache) rlockGlobal() {
+	if c.lockProfile == nil {
+		c.RLock()
+		return
+	}
+	start := time.Now()
+	c.RLock()
+	c.lockProfile.global.observe(time.Since(start))
+}
+
+// lockChannel acquires a channel's write lock, recording the wait when lock profiling is enabled.
+func (c *MessageCache) lockChannel(cc *ChannelCache) {
+	if c.lockProfile == nil {
+		cc.Lock()
+		return
+	}
+	start := time.Now()
+	cc.Lock()
+	c.lockProfile.channel.observe(time.Since(start))
+}
+
+// rlockChannel acquires a channel's read lock, recording the wait when lock profiling is enabled.
+func (c *MessageCache) rlockChannel(cc *ChannelCache) {
+	if c.lockProfile == nil {
+		cc.RLock()
+		return
+	}
+	start := time.Now()
+	cc.RLock()
+	c.lockProfile.channel.observe(time.Since(start))
+}
@@ -0,0 +1,117 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestWaitHistogramSnapshot(t *testing.T) {
+	var h waitHistogram
+	for i := 0; i < 98; i++ {
+		h.observe(100 * time.Nanosecond)
+	}
+	h.observe(2 * time.Millisecond)
+	h.observe(200 * time.Millisecond)
+
+	stats := h.snapshot()
+	if stats.Acquisitions != 100 {
+		t.Errorf("Expected 100 acquisitions, got %d", stats.Acquisitions)
+	}
+	if stats.P50WaitNs < 100 || stats.P50WaitNs >= 200 {
+		t.Errorf("Expected p50 in [100, 200), got %d", stats.P50WaitNs)
+	}
+	if stats.P99WaitNs < uint64(2*time.Millisecond) || stats.P99WaitNs >= uint64(4*time.Millisecond) {
+		t.Errorf("Expected p99 in [2ms, 4ms), got %d", stats.P99WaitNs)
+	}
+	if stats.Over1ms != 2 || stats.Over10ms != 1 || stats.Over100ms != 1 {
+		t.Errorf("Unexpected threshold counts: %+v", stats)
+	}
+}
+
+func TestStatsLockProfiling(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	if stats := cache.Stats(); stats.LockProfiling || stats.GlobalLockWait.Acquisitions != 0 {
+		t.Error("Lock profiling should be disabled by default.")
+	}
+
+	cache = NewMessageCache(10, WithLockProfiling())
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache.AddMessage(fmt.Sprintf("channel%d", i%5), &discordgo.Message{ID: fmt.Sprint(i)})
+			cache.GetMessages("channel0")
+		}(i)
+	}
+	wg.Wait()
+
+	stats := cache.Stats()
+	if !stats.LockProfiling {
+		t.Error("Stats should report lock profiling as enabled.")
+	}
+	if stats.GlobalLockWait.Acquisitions == 0 || stats.ChannelLockWait.Acquisitions == 0 {
+		t.Errorf("Expected lock acquisitions to be recorded, got %+v", stats)
+	}
+	if stats.Channels != 5 || stats.Messages != 50 {
+		t.Errorf("Expected 5 channels and 50 messages, got %d and %d", stats.Channels, stats.Messages)
+	}
+}
+
+func BenchmarkAddMessageLockProfiling(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"disabled", nil},
+		{"enabled", []Option{WithLockProfiling()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cache := NewMessageCache(1000, bc.opts...)
+			msg := &discordgo.Message{ID: "1"}
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					cache.AddMessage("channel1", msg)
+				}
+			})
+		})
+	}
+}
+
+func TestContentionTracking(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("channel0", &discordgo.Message{ID: "1"})
+	if stats := cache.Stats(); stats.AddFastPath != 0 || stats.AddSlowPath != 0 {
+		t.Errorf("Expected no counts while tracking is off, got %+v", stats)
+	}
+
+	cache.SetContentionTracking(true)
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.AddMessage(fmt.Sprintf("channel%d", i+1), &discordgo.Message{ID: "1"})
+		}()
+	}
+	wg.Wait()
+	cache.AddMessage("channel0", &discordgo.Message{ID: "2"})
+	stats := cache.Stats()
+	if stats.AddSlowPath != 100 {
+		t.Errorf("Expected 100 slow paths for new channels, got %d", stats.AddSlowPath)
+	}
+	if stats.AddFastPath != 1 {
+		t.Errorf("Expected 1 fast path for an existing channel, got %d", stats.AddFastPath)
+	}
+
+	cache.SetContentionTracking(false)
+	cache.AddMessage("channel200", &discordgo.Message{ID: "1"})
+	if got := cache.Stats().AddSlowPath; got != 100 {
+		t.Errorf("Expected counts to stop while tracking is off, got %d", got)
+	}
+}
@@ -0,0 +1,75 @@
+package dgocacheler
+
+import (
+	"container/heap"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// LongestMessages returns up to n messages of a channel with the longest content in bytes, longest first. Ties
+// are broken newest first. It selects the messages with a bounded heap instead of sorting the channel. It
+// returns ErrCacheMiss for unknown channels and ErrInvalidLimit if n is not positive.
+func (c *MessageCache) LongestMessages(channelID string, n int) ([]*discordgo.Message, error) {
+	if n <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	top := make(lengthHeap, 0, min(n, cc.size))
+	for i := 0; i < cc.size; i++ {
+		candidate := rankedMessage{message: cc.at(i).message, pos: i}
+		switch {
+		case len(top) < n:
+			heap.Push(&top, candidate)
+		case top.ranksBelow(top[0], candidate):
+			top[0] = candidate
+			heap.Fix(&top, 0)
+		}
+	}
+	cc.RUnlock()
+
+	slices.SortFunc(top, func(a, b rankedMessage) int {
+		if top.ranksBelow(a, b) {
+			return 1
+		}
+		return -1
+	})
+	msgs := make([]*discordgo.Message, len(top))
+	for i, ranked := range top {
+		msgs[i] = ranked.message
+	}
+	return msgs, nil
+}
+
+// rankedMessage is a message with its logical position, for breaking ties by age.
+type rankedMessage struct {
+	message *discordgo.Message
+	pos     int
+}
+
+// lengthHeap is a min-heap of messages by content length, with older messages ranking lower on ties.
+type lengthHeap []rankedMessage
+
+// ranksBelow reports whether a ranks below b: shorter content, or equal content length and older.
+func (h lengthHeap) ranksBelow(a, b rankedMessage) bool {
+	if la, lb := len(a.message.Content), len(b.message.Content); la != lb {
+		return la < lb
+	}
+	return a.pos < b.pos
+}
+
+func (h lengthHeap) Len() int           { return len(h) }
+func (h lengthHeap) Less(i, j int) bool { return h.ranksBelow(h[i], h[j]) }
+func (h lengthHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *lengthHeap) Push(x any)        { *h = append(*h, x.(rankedMessage)) }
+
+func (h *lengthHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,42 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestLongestMessages(t *testing.T) {
+	cache := NewMessageCache(10)
+	for i, content := range []string{"a", "ccc", "bb", "dddd", "eee", "", "fff"} {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(100 + i), Content: content})
+	}
+
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "[103]"},
+		{3, "[103 106 104]"}, // the three-byte messages tie, newest first
+		{4, "[103 106 104 101]"},
+		{20, "[103 106 104 101 102 100 105]"},
+	}
+	for _, tt := range tests {
+		msgs, err := cache.LongestMessages("channel1", tt.n)
+		if err != nil {
+			t.Fatalf("LongestMessages(%d) returned error: %v", tt.n, err)
+		}
+		if got := fmt.Sprint(messageIDs(msgs)); got != tt.want {
+			t.Errorf("LongestMessages(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+
+	if _, err := cache.LongestMessages("channel1", 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+	if _, err := cache.LongestMessages("unknown", 1); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package dgocacheler
+
+// WithMaxChannels caps the number of cached channels. Creating a channel beyond the cap evicts the least
+// recently used one, counting every lookup or add as a use. Frozen channels are never evicted, so the cap can
+// be exceeded when they fill it. n <= 0 means no cap.
+func WithMaxChannels(n int) Option {
+	return func(c *MessageCache) {
+		c.maxChannels = n
+	}
+}
+
+// OnChannelEvicted registers fn to be called with the ID of each channel evicted by the WithMaxChannels cap.
+// It runs after the cache locks are released, so it may call back into the cache.
+func OnChannelEvicted(fn func(channelID string)) Option {
+	return func(c *MessageCache) {
+		c.onChannelEvicted = fn
+	}
+}
+
+// ChannelEvictionCount returns how many channels have been evicted by the WithMaxChannels cap.
+func (c *MessageCache) ChannelEvictionCount() int64 {
+	return c.channelEvictions.Load()
+}
+
+// touchChannel records a use of a channel for least recently used eviction.
+func (c *MessageCache) touchChannel(cc *ChannelCache) {
+	if c.maxChannels > 0 {
+		cc.lastUsed.Store(c.useClock.Add(1))
+	}
+}
+
+// evictChannelsLocked evicts least recently used channels until one more fits under the cap, returning their
+// IDs. The caller must hold the global write lock and pass the IDs to channelsEvicted after releasing it.
+func (c *MessageCache) evictChannelsLocked() []string {
+	if c.maxChannels <= 0 {
+		return nil
+	}
+	var evicted []string
+	for len(c.messages) >= c.maxChannels {
+		var victim *ChannelCache
+		for _, cc := range c.messages {
+			if !cc.frozen.Load() && (victim == nil || cc.lastUsed.Load() < victim.lastUsed.Load()) {
+				victim = cc
+			}
+		}
+		if victim == nil {
+			break // only frozen channels are left
+		}
+		c.deleteChannelLocked(victim.id)
+		evicted = append(evicted, victim.id)
+	}
+	c.channelEvictions.Add(int64(len(evicted)))
+	return evicted
+}
+
+// channelsEvicted reports evicted channels to the OnChannelEvicted callback. It must be called without holding
+// any cache lock.
+func (c *MessageCache) channelsEvicted(channelIDs []string) {
+	if c.onChannelEvicted == nil {
+		return
+	}
+	for _, channelID := range channelIDs {
+		c.guard("OnChannelEvicted", func() { c.onChannelEvicted(channelID) })
+	}
+}
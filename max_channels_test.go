@@ -0,0 +1,44 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMaxChannelsEviction(t *testing.T) {
+	var evicted []string
+	cache := NewMessageCache(10, WithMaxChannels(3), OnChannelEvicted(func(channelID string) {
+		evicted = append(evicted, channelID)
+	}))
+	for _, channelID := range []string{"a", "b", "c"} {
+		cache.AddMessages(channelID, testHistory(2))
+	}
+	cache.GetMessages("a") // b is now the least recently used
+
+	cache.AddMessages("d", testHistory(1))
+	cache.AddMessages("e", testHistory(1))
+
+	if got := fmt.Sprint(evicted); got != "[b c]" {
+		t.Errorf("Expected LRU victims [b c], got %v", got)
+	}
+	if n := cache.ChannelEvictionCount(); n != 2 {
+		t.Errorf("Expected 2 channel evictions, got %d", n)
+	}
+	if _, ok := cache.GetMessages("b"); ok {
+		t.Error("Evicted channel b should no longer be cached.")
+	}
+	if stats := cache.Stats(); stats.Channels != 3 {
+		t.Errorf("Expected 3 channels under the cap, got %d", stats.Channels)
+	}
+}
+
+func TestMaxChannelsSyncMap(t *testing.T) {
+	cache := NewMessageCache(10, WithMaxChannels(2), WithSyncMapChannels())
+	cache.PrewarmChannels([]string{"a", "b", "c"})
+	if _, ok := cache.channelCache("a"); ok {
+		t.Error("Expected a to be evicted from the sync.Map mirror as well.")
+	}
+	if n := cache.ChannelEvictionCount(); n != 1 {
+		t.Errorf("Expected 1 channel eviction, got %d", n)
+	}
+}
@@ -3,86 +3,1059 @@
 package dgocacheler
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 // MessageCache holds Discord messages organized by channel ID. It supports concurrent access.
+//
+// The embedded lock guards the channel map only; each ChannelCache carries its own lock for its messages.
+// Locks are always acquired global-first, and the global lock is never taken while holding a channel lock.
 type MessageCache struct {
-	sync.RWMutex                                 // Embedding RWMutex to provide locking
-	messages     map[string][]*discordgo.Message // messages maps channel IDs to slice of messages
-	maxMessages  int                             // maxMessages defines the max number of messages per channel
+	sync.RWMutex                                    // Embedding RWMutex to provide locking
+	messages             map[string]*ChannelCache   // messages maps channel IDs to their channel caches
+	maxMessages          int                        // maxMessages defines the max number of messages per channel
+	insertSeq            atomic.Uint64              // insertSeq is the last insertion sequence handed out by the cache
+	lockProfile          *lockProfile               // lockProfile records lock wait times, nil unless WithLockProfiling is set
+	loader               Loader                     // loader fetches messages missing from the cache, nil when not configured
+	pprofLabels          bool                       // pprofLabels runs heavier operations under pprof labels when set
+	tracer               Tracer                     // tracer starts spans around slower operations, nil when tracing is disabled
+	similarityThreshold  atomic.Uint64              // similarityThreshold holds the float64 bits of the near-duplicate threshold, 0 disables it
+	syncChannels         *sync.Map                  // syncChannels mirrors messages for lock-free lookups, nil unless WithSyncMapChannels is set
+	webhookPolicy        WebhookPolicy              // webhookPolicy controls how webhook messages are cached
+	tagInteractions      bool                       // tagInteractions flags interaction responses on ingestion
+	ignoreInteractions   bool                       // ignoreInteractions drops interaction responses on ingestion
+	interactionBotID     string                     // interactionBotID is the bot user whose messages count as interaction responses, set by WithInteractionBotID
+	redactor             Redactor                   // redactor rewrites message text before storage, nil when not configured
+	dedupDisabled        bool                       // dedupDisabled skips tracking message IDs, set by WithoutDedup
+	noDedupChannels      map[string]struct{}        // noDedupChannels lists the channels that skip tracking message IDs, set by WithoutChannelDedup
+	bloomRate            float64                    // bloomRate is the false-positive rate of the Bloom dedup filters, set by WithBloomDedup
+	dedupHorizon         int                        // dedupHorizon is the number of recent IDs each channel rejects, set by WithDedupHorizon
+	duplicateTracking    int                        // duplicateTracking is the number of duplicate drops recorded per channel
+	duplicatesDropped    atomic.Uint64              // duplicatesDropped counts the messages dropped as duplicates
+	horizonSuppressed    atomic.Uint64              // horizonSuppressed counts re-adds rejected by the dedup horizon only
+	snapshotKey          []byte                     // snapshotKey encrypts persisted snapshots, nil for plaintext
+	debugContent         bool                       // debugContent exposes message content through DebugHandler
+	batchDuplicatePolicy atomic.Int32               // batchDuplicatePolicy holds the BatchDuplicatePolicy used by AddMessages
+	fetches              fetchGroup                 // fetches deduplicates concurrent GetOrFetch calls per channel
+	paused               atomic.Bool                // paused is set while ingestion is paused
+	pause                pauseState                 // pause holds the messages buffered while paused
+	maxChannels          int                        // maxChannels caps the number of cached channels, 0 for no cap
+	useClock             atomic.Int64               // useClock orders channel uses for least recently used eviction
+	channelEvictions     atomic.Int64               // channelEvictions counts channels evicted by the maxChannels cap
+	onChannelEvicted     func(channelID string)     // onChannelEvicted is called for each evicted channel, nil when not set
+	frozenSkips          atomic.Uint64              // frozenSkips counts the mutations ignored because their channel was frozen
+	perAuthorCap         int                        // perAuthorCap bounds the messages per author in a channel, 0 for no cap
+	onEvict              EvictFunc                  // onEvict is called for each evicted message, nil when not set
+	defaultChannel       atomic.Pointer[string]     // defaultChannel receives adds with an empty channel ID, nil to reject them
+	strictCapacity       bool                       // strictCapacity rejects adds to full channels instead of evicting
+	spill                *spiller                   // spill delivers evicted messages to the spill handler, nil when not set
+	errorHandler         func(error)                // errorHandler receives background errors, nil when not set
+	optionErr            error                      // optionErr joins the errors of invalid options, returned by NewMessageCacheChecked
+	globalIDs            *globalIDSet               // globalIDs counts message IDs across channels, nil unless WithGlobalDedup is set
+	crossposts           *crosspostIndex            // crossposts tracks crosspost copies and aliases, nil unless WithCrosspostDedup is set
+	tiers                tierPolicy                 // tiers sizes the hot and cold tiers of WithTieredRetention, zero when disabled
+	optimisticReads      bool                       // optimisticReads lets GetMessagesLimit read without locking, set by WithOptimisticReads
+	idComparator         func(a, b string) int      // idComparator orders message IDs, nil for CompareSnowflakes
+	mirror               *stateMirror               // mirror feeds a discordgo.State, nil unless WithStateMirror is set
+	logger               *slog.Logger               // logger receives problem reports, nil when not set
+	recoverCallbacks     atomic.Bool                // recoverCallbacks recovers panics in user callbacks when set
+	callbackPanics       atomic.Uint64              // callbackPanics counts the recovered callback panics
+	callbackErrors       chan error                 // callbackErrors carries recovered callback panics
+	contentionTracking   atomic.Bool                // contentionTracking counts the add lock paths when set
+	addFastPath          atomic.Uint64              // addFastPath counts adds that found their channel with a lookup
+	addSlowPath          atomic.Uint64              // addSlowPath counts adds that took the global write lock to create their channel
+	clock                func() time.Time           // clock returns the current time, nil for time.Now
+	edits                editCoalescer              // edits holds the state of edit coalescing, see SetEditCoalesceWindow
+	initialChannels      map[string]int             // initialChannels holds the channels registered by WithChannels until construction
+	guilds               guildPolicies              // guilds holds the retention policies set by SetGuildPolicy
+	janitor              *janitor                   // janitor applies guild policies periodically, nil unless WithJanitor is set
+	audit                *auditLog                  // audit records removals, nil unless WithAuditLog is set
+	hotWindow            atomic.Int64               // hotWindow is the number of newest messages per channel never reclaimed, 0 when reclamation is off
+	softLimit            atomic.Int64               // softLimit is the estimated size in bytes above which cold messages are reclaimed, 0 for none
+	reclaimer            atomic.Pointer[reclaimer]  // reclaimer runs Reclaim after garbage collections, nil when reclamation is off
+	anonymizeSalt        []byte                     // anonymizeSalt keys the hashes replacing user IDs, nil unless WithAnonymizedAuthors is set
+	contentHashing       bool                       // contentHashing stores content digests instead of text, set by WithContentHashing
+	sanitizeContent      bool                       // sanitizeContent repairs invalid UTF-8 and strips control characters, set by WithSanitizeContent
+	users                atomic.Pointer[userIndex]  // users indexes messages per author, nil unless SetPerUserRetention is set, written under the global lock
+	lru                  atomic.Pointer[messageLRU] // lru orders messages by recency of use, nil unless SetGlobalMaxMessages is set, written under the global lock
+	codec                atomic.Pointer[codecFuncs] // codec serializes messages for snapshots and exports, nil for JSON
+	spans                atomic.Pointer[spanHook]   // spans starts spans for SetSpanStarter, nil when not set
 }
 
 // NewMessageCache creates a new MessageCache with a specified maximum number of messages per channel.
-func NewMessageCache(maxMessages int) *MessageCache {
-	return &MessageCache{
-		messages:    make(map[string][]*discordgo.Message),
-		maxMessages: maxMessages,
+func NewMessageCache(maxMessages int, opts ...Option) *MessageCache {
+	c := &MessageCache{
+		messages:       make(map[string]*ChannelCache),
+		maxMessages:    maxMessages,
+		callbackErrors: make(chan error, callbackErrorBuffer),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.mirror != nil {
+		c.mirror.logger = c.logger
+	}
+	if c.errorHandler != nil {
+		c.recoverCallbacks.Store(true)
+	}
+	if c.optionErr != nil {
+		c.reportError(c.optionErr)
+	}
+	if c.spill != nil {
+		c.spill.start(c.reportError, c.guard)
+	}
+	if c.initialChannels != nil {
+		c.registerChannels()
+	}
+	if c.janitor != nil {
+		c.janitor.start(c)
+	}
+	if c.audit != nil {
+		c.audit.start(c.reportError)
+	}
+	return c
 }
 
-// AddMessage adds a single message to the cache for a specific channel.
-func (c *MessageCache) AddMessage(channelID string, message *discordgo.Message) {
-	c.Lock()
-	defer c.Unlock()
-	c.addMessageInternal(channelID, message)
+// NewMessageCacheChecked is NewMessageCache but also returns the errors of invalid options, such as a
+// WithSnapshotEncryption key of the wrong length, instead of leaving them for the first operation they affect.
+// The cache is returned either way.
+func NewMessageCacheChecked(maxMessages int, opts ...Option) (*MessageCache, error) {
+	c := NewMessageCache(maxMessages, opts...)
+	return c, c.optionErr
 }
 
-// AddMessages adds multiple messages to the cache for a specific channel.
-func (c *MessageCache) AddMessages(channelID string, messages []*discordgo.Message) {
-	c.Lock()
-	defer c.Unlock()
-	for _, message := range messages {
-		c.addMessageInternal(channelID, message)
+// AddMessage adds a single message to the cache for a specific channel. Nil messages are ignored. An empty
+// channel ID is rejected with ErrInvalidChannel unless SetDefaultChannel is configured.
+func (c *MessageCache) AddMessage(channelID string, message *discordgo.Message) (err error) {
+	if end := c.traceAdd(context.Background(), "AddMessage"); end != nil {
+		defer func() { end(err) }()
+	}
+	channelID, err = c.resolveChannel(channelID)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.addMessage(channelID, message, 0, addCall{source: "AddMessage", logger: c.logger})
+	return err
+}
+
+// addMessage adds a message with an eviction priority to a resolved channel, or holds it while ingestion is
+// paused, and reports what happened to it. It implements the single-message adds.
+func (c *MessageCache) addMessage(channelID string, message *discordgo.Message, priority int, call addCall) (outcome addOutcome, held bool, err error) {
+	if held, err := c.holdIfPaused(channelID, priority, call.source, message); held {
+		return addFiltered, true, err
+	}
+	defer c.enforceGlobalCaps()
+	cc := c.lockChannelForAdd(channelID)
+	defer cc.Unlock()
+	outcome, err = c.addEntry(cc, message, priority, call)
+	return outcome, false, err
+}
+
+// TryAddMessage is like AddMessage but does not wait for a busy channel lock: if another goroutine holds it, the
+// message is not added and false is returned, so the caller can drop or queue it. It may still wait for the
+// global lock, to create the channel or enforce cache-wide caps. A true result means the message was handled as
+// AddMessage would handle it, which includes holding it while ingestion is paused and dropping it as nil, a
+// duplicate or filtered without an error, so it does not mean the message was stored.
+func (c *MessageCache) TryAddMessage(channelID string, message *discordgo.Message) (added bool, err error) {
+	if end := c.traceAdd(context.Background(), "TryAddMessage"); end != nil {
+		defer func() { end(err) }()
+	}
+	channelID, err = c.resolveChannel(channelID)
+	if err != nil {
+		return false, err
+	}
+	if held, err := c.holdIfPaused(channelID, 0, "TryAddMessage", message); held {
+		return true, err
+	}
+	defer c.enforceGlobalCaps()
+	for {
+		cc := c.getOrCreateChannelCache(channelID)
+		if !cc.TryLock() {
+			return false, nil
+		}
+		if cc.retired {
+			cc.Unlock()
+			continue
+		}
+		defer cc.Unlock()
+		return true, c.addMessageInternal(cc, message, "TryAddMessage")
+	}
+}
+
+// AddMessages adds multiple messages to the cache for a specific channel. Messages that are rejected do not stop
+// the rest of the batch from being added; if any were rejected, ErrSuppressedDuplicate is returned. In strict
+// capacity mode the batch stops at the first message that does not fit, with a *ChannelFullError.
+func (c *MessageCache) AddMessages(channelID string, messages []*discordgo.Message) (err error) {
+	if end := c.traceAdd(context.Background(), "AddMessages"); end != nil {
+		defer func() { end(err) }()
+	}
+	_, err = c.addBatch(channelID, messages, "AddMessages")
+	return err
+}
+
+// AddMessagesMulti adds batches of messages to several channels, as AddMessages does for one. A failing channel
+// does not stop the others; failures are returned as *ChannelError values joined with errors.Join.
+func (c *MessageCache) AddMessagesMulti(batches map[string][]*discordgo.Message) error {
+	failed := make(map[string]error)
+	for channelID, messages := range batches {
+		if err := c.AddMessages(channelID, messages); err != nil {
+			failed[channelID] = err
+		}
 	}
+	return joinChannelErrors(failed)
+}
+
+// AddMessagesReport is like AddMessages but also reports what happened to the messages of the batch.
+func (c *MessageCache) AddMessagesReport(channelID string, messages []*discordgo.Message) (report BatchReport, err error) {
+	if end := c.traceAdd(context.Background(), "AddMessagesReport"); end != nil {
+		defer func() { end(err) }()
+	}
+	return c.addBatch(channelID, messages, "AddMessagesReport")
+}
+
+// addBatch implements AddMessages and AddMessagesReport.
+func (c *MessageCache) addBatch(channelID string, messages []*discordgo.Message, source string) (report BatchReport, err error) {
+	channelID, err = c.resolveChannel(channelID)
+	if err != nil {
+		return report, err
+	}
+	if held, err := c.holdIfPaused(channelID, 0, source, messages...); held {
+		for _, message := range messages {
+			if message == nil {
+				report.NilSkipped++
+			} else {
+				report.Held++
+			}
+		}
+		return report, err
+	}
+	defer c.enforceGlobalCaps()
+	cc := c.lockChannelForAdd(channelID)
+	defer cc.Unlock()
+	return c.addBatchLocked(cc, messages, source)
+}
+
+// addBatchLocked adds a batch of messages to a channel whose write lock the caller holds.
+func (c *MessageCache) addBatchLocked(cc *ChannelCache, messages []*discordgo.Message, source string) (report BatchReport, err error) {
+	evictions := cc.evictions
+	defer func() {
+		report.Evicted = int(cc.evictions - evictions)
+	}()
+	call := addCall{source: source, logger: c.logger}
+	if report, ok := c.addBatchBulk(cc, messages, call); ok {
+		return report, nil
+	}
+	return c.addBatchEach(cc, messages, call)
+}
+
+// addBatchEach is addBatchLocked adding the messages one by one. The caller must hold the channel's write lock.
+func (c *MessageCache) addBatchEach(cc *ChannelCache, messages []*discordgo.Message, call addCall) (report BatchReport, err error) {
+	var seen map[string]struct{}
+	if c.batchDuplicatePolicy.Load() == int32(LastWins) {
+		seen = make(map[string]struct{}, len(messages))
+	}
+	for i, message := range messages {
+		if seen != nil && message != nil {
+			if _, repeated := seen[message.ID]; repeated {
+				if i := cc.find(message.ID); i >= 0 {
+					if !MessagesEqual(cc.at(i).message, message) {
+						c.updateAt(cc, i, message)
+					}
+					report.Updated++
+					continue
+				}
+			}
+			seen[message.ID] = struct{}{}
+		}
+		outcome, addErr := c.addEntry(cc, message, 0, call)
+		report.count(outcome)
+		if addErr != nil {
+			if errors.Is(addErr, ErrChannelFull) {
+				return report, &ChannelFullError{ChannelID: cc.id, Stored: i}
+			}
+			err = addErr
+		}
+	}
+	return report, err
+}
+
+// AddMessageWithPriority is like AddMessage but stores the message with a priority, for messages such as pins
+// or staff posts that should outlive ordinary ones. When the channel is full, the oldest of the messages with
+// the lowest priority is evicted instead of strictly the oldest. Ordinary messages have priority 0.
+func (c *MessageCache) AddMessageWithPriority(channelID string, message *discordgo.Message, priority int) (err error) {
+	if end := c.traceAdd(context.Background(), "AddMessageWithPriority"); end != nil {
+		defer func() { end(err) }()
+	}
+	channelID, err = c.resolveChannel(channelID)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.addMessage(channelID, message, priority, addCall{source: "AddMessageWithPriority", logger: c.logger})
+	return err
 }
 
 // addMessageInternal is an unexported helper function that handles the actual addition of messages to the cache.
-func (c *MessageCache) addMessageInternal(channelID string, message *discordgo.Message) {
-	if _, ok := c.messages[channelID]; !ok {
-		c.messages[channelID] = []*discordgo.Message{}
+// Messages whose ID is already cached in the channel are skipped. source names the public method adding the
+// message, for diagnostics. The caller must hold the channel's write lock.
+func (c *MessageCache) addMessageInternal(cc *ChannelCache, message *discordgo.Message, source string) error {
+	return c.addPrioritized(cc, message, 0, source)
+}
+
+// addPrioritized is addMessageInternal with an eviction priority. The caller must hold the channel's write lock.
+func (c *MessageCache) addPrioritized(cc *ChannelCache, message *discordgo.Message, priority int, source string) error {
+	_, err := c.addEntry(cc, message, priority, addCall{source: source, logger: c.logger})
+	return err
+}
+
+// addCall describes the public method call an add belongs to.
+type addCall struct {
+	source string       // source names the method, for diagnostics
+	logger *slog.Logger // logger receives the logs of the call, nil when logging is off
+}
+
+// addEntry is addPrioritized, also reporting what happened to the message. The caller must hold the channel's
+// write lock.
+func (c *MessageCache) addEntry(cc *ChannelCache, message *discordgo.Message, priority int, call addCall) (addOutcome, error) {
+	if message == nil {
+		return addNil, nil
+	}
+	if cc.sealed {
+		return addFiltered, ErrChannelSealed
+	}
+	if c.skipFrozen(cc) || c.skipWebhook(message) || c.skipInteraction(message) {
+		return addFiltered, nil
 	}
-	c.messages[channelID] = append(c.messages[channelID], message)
-	if len(c.messages[channelID]) > c.maxMessages {
-		c.messages[channelID] = c.messages[channelID][1:]
+	if p, ok := c.attributeGuild(cc, message); ok && !p.admits(message, c.now()) {
+		return addFiltered, nil
 	}
+	if c.isDuplicate(cc, message.ID, call) || c.ContainsGlobal(message.ID) {
+		return addDuplicate, nil
+	}
+	if c.crossposts != nil && c.crossposts.alias(cc, cc.id, message) {
+		return addDuplicate, nil
+	}
+	if c.channelFull(cc) {
+		return addFiltered, ErrChannelFull
+	}
+	if cc.maxMessages <= 0 {
+		return addFiltered, nil
+	}
+	message, sanitized := c.ingestLogged(message, call.logger)
+	if message == nil {
+		return addFiltered, nil
+	}
+	if c.isSimilarDuplicate(cc, message) {
+		return addFiltered, ErrSuppressedDuplicate
+	}
+	entry := c.newEntry(message)
+	entry.size += sanitized
+	entry.priority = priority
+	c.enforceAuthorCap(cc, entry.author)
+	cc.add(entry)
+	cc.horizon.push(message.ID)
+	cc.notifyTails(message)
+	return addStored, nil
+}
+
+// newEntry wraps a message for storage, assigning it the next insertion sequence.
+func (c *MessageCache) newEntry(message *discordgo.Message) cachedMessage {
+	return c.entryFor(message, c.insertSeq.Add(1))
 }
 
-// GetMessages retrieves all messages for a given channel from the cache
+// entryFor wraps a message for storage with the given insertion sequence, computing its estimated size
+// and ingestion tags.
+func (c *MessageCache) entryFor(message *discordgo.Message, insertSeq uint64) cachedMessage {
+	return cachedMessage{
+		message:     message,
+		insertSeq:   insertSeq,
+		size:        estimateMessageSize(message),
+		interaction: c.tagInteractions && c.isInteractionResponse(message),
+		author:      c.entryAuthor(message),
+	}
+}
+
+// UpdateMessage replaces the cached message with the same ID as message, for example after an edit.
+// The message keeps its position in the channel. Rapid edits may be held back; see SetEditCoalesceWindow.
+// It returns ErrCacheMiss for unknown channels, ErrChannelSealed for sealed channels and ErrMessageNotFound if
+// the message is not cached.
+func (c *MessageCache) UpdateMessage(channelID string, message *discordgo.Message) error {
+	if message == nil {
+		return ErrMessageNotFound
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return ErrCacheMiss
+	}
+	defer c.enforceGlobalCaps()
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if cc.sealed {
+		return ErrChannelSealed
+	}
+	i := cc.find(message.ID)
+	if i < 0 {
+		return ErrMessageNotFound
+	}
+	if !c.edits.coalesceEdit(channelID, message, c.now(), c.settleEdits) {
+		c.updateAt(cc, i, message)
+	}
+	return nil
+}
+
+// updateAt replaces the entry at a logical position with message, keeping its insertion sequence and priority.
+// The caller must hold the channel's write lock.
+func (c *MessageCache) updateAt(cc *ChannelCache, i int, message *discordgo.Message) {
+	if c.skipFrozen(cc) {
+		return
+	}
+	message, sanitized := c.ingest(message)
+	if message == nil {
+		return
+	}
+	entry := c.entryFor(message, cc.at(i).insertSeq)
+	entry.size += sanitized
+	entry.priority = cc.at(i).priority
+	cc.replace(i, entry)
+}
+
+// GetMessages retrieves all messages for a given channel from the cache. Until the channel changes, repeated
+// calls return the same slice without copying or locking, so callers must not modify it; its capacity is
+// capped so that appending copies. With SetGlobalMaxMessages, every call locks and copies so that reads bump
+// recency.
 func (c *MessageCache) GetMessages(channelID string) ([]*discordgo.Message, bool) {
-	c.RLock()
-	defer c.RUnlock()
-	msgs, ok := c.messages[channelID]
-	return msgs, ok
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, false
+	}
+	if c.lru.Load() != nil {
+		c.rlockChannel(cc)
+		defer cc.RUnlock()
+		cc.touch(0, cc.size)
+		return cc.messages(), true
+	}
+	return c.messagesView(cc), true
+}
+
+// GetMessagesWithWrapInfo retrieves all messages for a given channel in chronological order, in a freshly
+// allocated slice, together with whether the channel ever dropped messages because it was full, meaning older
+// history is missing. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) GetMessagesWithWrapInfo(channelID string) (msgs []*discordgo.Message, wrapped bool, err error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, false, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	return cc.messages(), cc.wrapped, nil
 }
 
-// GetMessagesLimit retrieves up to a specified number of recent messages for a given channel.
+// GetMessagesBySeq retrieves all messages for a given channel ordered by the sequence in which they were inserted.
+// The insertion sequence reflects the order of the add calls, which can differ from snowflake (timestamp) order.
+func (c *MessageCache) GetMessagesBySeq(channelID string) ([]*discordgo.Message, bool) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, false
+	}
+	c.rlockChannel(cc)
+	sorted := cc.entries()
+	cc.RUnlock()
+	slices.SortStableFunc(sorted, func(a, b cachedMessage) int {
+		return cmp.Compare(a.insertSeq, b.insertSeq)
+	})
+	return messagesOf(sorted), true
+}
+
+// GetMessagesLimit retrieves up to a specified number of recent messages for a given channel. With
+// WithOptimisticReads it takes no channel lock unless writers keep interfering.
 func (c *MessageCache) GetMessagesLimit(channelID string, limit int) ([]*discordgo.Message, bool) {
-	c.RLock()
-	defer c.RUnlock()
-	msgs, ok := c.messages[channelID]
-	if !ok || len(msgs) == 0 {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
 		return nil, false
 	}
-	start := len(msgs) - limit
-	if start < 0 {
-		start = 0
+	if cc.optimistic != nil && limit > 0 && c.lru.Load() == nil {
+		if msgs, ok := cc.optimistic.newest(limit); ok && len(msgs) > 0 {
+			return msgs, true
+		} else if ok {
+			return nil, false
+		}
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	if cc.size == 0 {
+		return nil, false
+	}
+	n := min(max(limit, 0), cc.size)
+	cc.touch(cc.size-n, n)
+	return cc.newestMessages(limit), true
+}
+
+// GetMessage retrieves a single cached message by ID. It returns ErrDedupDisabled when the channel does not
+// track message IDs, ErrCacheMiss for unknown channels and ErrMessageNotFound if the message is not cached.
+// With WithCrosspostDedup, crossposts aliased to the channel resolve to their stored copy.
+func (c *MessageCache) GetMessage(channelID, messageID string) (*discordgo.Message, error) {
+	msg, err := c.getMessage(channelID, messageID)
+	if errors.Is(err, ErrCacheMiss) || errors.Is(err, ErrMessageNotFound) {
+		if copied, ok := c.resolveCrosspost(channelID, messageID); ok {
+			return copied, nil
+		}
+	}
+	return msg, err
+}
+
+// getMessage is GetMessage without resolving crosspost aliases.
+func (c *MessageCache) getMessage(channelID, messageID string) (*discordgo.Message, error) {
+	cc, err := c.idTrackingChannel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	i := cc.find(messageID)
+	if i < 0 {
+		return nil, ErrMessageNotFound
+	}
+	cc.touch(i, 1)
+	return cc.at(i).message, nil
+}
+
+// RemoveMessage deletes a single cached message by ID, for example after a message delete event. It returns
+// ErrDedupDisabled when the channel does not track message IDs, ErrCacheMiss for unknown channels,
+// ErrChannelSealed for sealed channels and ErrMessageNotFound if the message is not cached.
+func (c *MessageCache) RemoveMessage(channelID, messageID string) error {
+	cc, err := c.idTrackingChannel(channelID)
+	if err != nil {
+		return err
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if cc.sealed {
+		return ErrChannelSealed
+	}
+	i := cc.find(messageID)
+	if i < 0 {
+		return ErrMessageNotFound
+	}
+	if !c.skipFrozen(cc) {
+		c.auditMessage("RemoveMessage", cc, cc.removeAt(i))
+	}
+	return nil
+}
+
+// Newest returns the most recent message of a channel without allocating. It returns ErrCacheMiss for unknown
+// channels and ErrEmptyChannel if the channel holds no messages.
+func (c *MessageCache) Newest(channelID string) (*discordgo.Message, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	if cc.size == 0 {
+		return nil, ErrEmptyChannel
+	}
+	return cc.at(cc.size - 1).message, nil
+}
+
+// LastMessageID returns the ID of the most recent message of a channel, which is handy for last_message_id
+// tracking and gap detection. It returns ErrCacheMiss for unknown channels and ErrEmptyChannel if the channel
+// holds no messages.
+func (c *MessageCache) LastMessageID(channelID string) (string, error) {
+	msg, err := c.Newest(channelID)
+	if err != nil {
+		return "", err
+	}
+	return msg.ID, nil
+}
+
+// MessageIDs returns the IDs of the cached messages of a channel in chronological order. It only allocates the
+// returned slice, which makes it cheaper than GetMessages when comparing IDs. It returns ErrCacheMiss for
+// unknown channels.
+func (c *MessageCache) MessageIDs(channelID string) ([]string, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	ids := make([]string, cc.size)
+	for i := range ids {
+		ids[i] = cc.at(i).message.ID
+	}
+	return ids, nil
+}
+
+// GetOldestMessagesLimit retrieves up to limit of the oldest messages for a given channel, in chronological order,
+// in a freshly allocated slice. An empty channel yields an empty slice. It returns ErrCacheMiss for unknown
+// channels and ErrInvalidLimit if limit is not positive.
+func (c *MessageCache) GetOldestMessagesLimit(channelID string, limit int) ([]*discordgo.Message, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	return cc.oldestMessages(limit), nil
+}
+
+// GetRecentSince retrieves up to limit of the newest messages for a given channel that were sent after since,
+// going by the timestamps encoded in their snowflake IDs, in chronological order. It returns ErrCacheMiss for
+// unknown channels and ErrInvalidLimit if limit is not positive.
+func (c *MessageCache) GetRecentSince(channelID string, limit int, since time.Time) ([]*discordgo.Message, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	msgs := make([]*discordgo.Message, 0, min(limit, cc.size))
+	for i := cc.size - 1; i >= 0 && len(msgs) < limit; i-- {
+		msg := cc.at(i).message
+		if t, err := discordgo.SnowflakeTimestamp(msg.ID); err == nil && t.After(since) {
+			msgs = append(msgs, msg)
+		}
+	}
+	slices.Reverse(msgs)
+	return msgs, nil
+}
+
+// GetMessagesRange retrieves up to count messages for a given channel, skipping the offset newest ones, in
+// chronological order. An offset past the cached messages yields an empty slice. It returns ErrCacheMiss for
+// unknown channels and ErrInvalidLimit if offset or count is negative.
+func (c *MessageCache) GetMessagesRange(channelID string, offset, count int) ([]*discordgo.Message, error) {
+	if offset < 0 || count < 0 {
+		return nil, ErrInvalidLimit
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	end := max(cc.size-offset, 0)
+	start := max(end-count, 0)
+	return cc.window(start, end-start), nil
+}
+
+// GetRecentWindow retrieves up to limit messages for a given channel, newest first, starting offset messages
+// back from the newest, for paging backwards through history. An offset past the cached messages yields an empty
+// slice. It returns ErrCacheMiss for unknown channels and ErrInvalidLimit if limit is not positive or offset is
+// negative.
+func (c *MessageCache) GetRecentWindow(channelID string, offset, limit int) ([]*discordgo.Message, error) {
+	if limit <= 0 || offset < 0 {
+		return nil, ErrInvalidLimit
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	end := max(cc.size-offset, 0)
+	msgs := make([]*discordgo.Message, 0, min(limit, end))
+	for i := end - 1; i >= 0 && len(msgs) < limit; i-- {
+		msgs = append(msgs, cc.at(i).message)
+	}
+	cc.touch(end-len(msgs), len(msgs))
+	return msgs, nil
+}
+
+// GetContext retrieves a message with up to before older and up to after newer messages around it, in
+// chronological order, for example to jump to a message. Fewer are returned near either end of the cached
+// messages. It returns ErrCacheMiss for unknown channels, ErrMessageNotFound if the message is not cached and
+// ErrInvalidLimit if before or after is negative.
+func (c *MessageCache) GetContext(channelID, messageID string, before, after int) ([]*discordgo.Message, error) {
+	if before < 0 || after < 0 {
+		return nil, ErrInvalidLimit
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	i := cc.find(messageID)
+	if i < 0 {
+		return nil, ErrMessageNotFound
+	}
+	start := max(i-before, 0)
+	n := min(i+after+1, cc.size) - start
+	cc.touch(start, n)
+	return cc.window(start, n), nil
+}
+
+// MessageCount returns the number of messages cached for a given channel, or ErrCacheMiss for unknown channels.
+func (c *MessageCache) MessageCount(channelID string) (int, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return 0, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	return cc.size, nil
+}
+
+// TotalCount returns the number of messages cached across all channels.
+func (c *MessageCache) TotalCount() int {
+	total := 0
+	for _, cc := range c.channelCaches() {
+		c.rlockChannel(cc)
+		total += cc.size
+		cc.RUnlock()
 	}
-	return msgs[start:], true
+	return total
+}
+
+// IsEmpty reports whether no channel holds any messages, stopping at the first channel that does.
+func (c *MessageCache) IsEmpty() bool {
+	c.rlockGlobal()
+	defer c.RUnlock()
+	for _, cc := range c.messages {
+		c.rlockChannel(cc)
+		size := cc.size
+		cc.RUnlock()
+		if size > 0 {
+			return false
+		}
+	}
+	return true
 }
 
 // SetMaxMessages sets the maximum number of messages to store per channel in the cache.
+// Channels given their own capacity through SetChannelMaxMessages, WithChannels or a guild policy keep it; see
+// ResetMaxMessages.
 func (c *MessageCache) SetMaxMessages(maxMessages int) {
-	c.Lock()
+	c.SetMaxMessagesContext(context.Background(), maxMessages)
+}
+
+// SetMaxMessagesContext is like SetMaxMessages but runs the resize under the given context,
+// which carries the pprof labels applied by WithPprofLabels.
+func (c *MessageCache) SetMaxMessagesContext(ctx context.Context, maxMessages int) {
+	ctx, span := c.startSpan(ctx, "SetMaxMessages")
+	defer span.End()
+	c.profileOp(ctx, "resize", "", func(context.Context) {
+		c.lockGlobal()
+		defer c.Unlock()
+		c.maxMessages = maxMessages
+		for _, cc := range c.messages {
+			c.lockChannel(cc)
+			if !cc.customMax && c.guildCapacity(cc.guildID) == 0 && !c.skipFrozen(cc) {
+				before := cc.size
+				cc.setMaxMessages(maxMessages)
+				c.auditRemoval("SetMaxMessages", cc, before-cc.size)
+			}
+			cc.Unlock()
+		}
+	})
+}
+
+// ResetMaxMessages is like SetMaxMessages but also applies maxMessages to channels with their own capacity, from
+// SetChannelMaxMessages or WithChannels, which then follow the cache-wide limit again. Frozen channels are
+// skipped.
+func (c *MessageCache) ResetMaxMessages(maxMessages int) {
+	c.lockGlobal()
 	defer c.Unlock()
 	c.maxMessages = maxMessages
-	for channelID, messages := range c.messages {
-		if len(messages) > maxMessages {
-			c.messages[channelID] = messages[len(messages)-maxMessages:]
+	for _, cc := range c.messages {
+		c.lockChannel(cc)
+		if !c.skipFrozen(cc) {
+			before := cc.size
+			cc.customMax = false
+			cc.setMaxMessages(maxMessages)
+			c.auditRemoval("ResetMaxMessages", cc, before-cc.size)
+		}
+		cc.Unlock()
+	}
+}
+
+// SetChannelMaxMessages sets the maximum number of messages stored for one channel, creating the channel if needed.
+// The channel keeps this capacity when the cache-wide limit changes. It returns ErrInvalidMaxMessages if
+// maxMessages is not positive.
+func (c *MessageCache) SetChannelMaxMessages(channelID string, maxMessages int) error {
+	return c.SetChannelMaxMessagesBatch(map[string]int{channelID: maxMessages})
+}
+
+// SetChannelMaxMessagesBatch applies per-channel capacities in one step, as SetChannelMaxMessages does for a single
+// channel. All sizes are validated before anything changes: if any is invalid, ErrInvalidMaxMessages is returned
+// for each invalid channel and no channel is modified; the same goes for ErrChannelFrozen if any channel is
+// frozen. Failures are reported per channel as *ChannelError values joined with errors.Join. Channels not in
+// sizes are left unchanged.
+func (c *MessageCache) SetChannelMaxMessagesBatch(sizes map[string]int) error {
+	failed := make(map[string]error)
+	for channelID, maxMessages := range sizes {
+		if maxMessages <= 0 {
+			failed[channelID] = fmt.Errorf("%w: %d", ErrInvalidMaxMessages, maxMessages)
+		}
+	}
+	if len(failed) > 0 {
+		return joinChannelErrors(failed)
+	}
+
+	var evicted []string
+	c.lockGlobal()
+	for channelID := range sizes {
+		if cc, ok := c.messages[channelID]; ok && cc.frozen.Load() {
+			failed[channelID] = ErrChannelFrozen
+		}
+	}
+	if len(failed) > 0 {
+		c.Unlock()
+		return joinChannelErrors(failed)
+	}
+	for channelID, maxMessages := range sizes {
+		cc, ok := c.messages[channelID]
+		if !ok {
+			var victims []string
+			cc, victims = c.createChannelLocked(channelID, maxMessages, 0)
+			evicted = append(evicted, victims...)
+		}
+		c.lockChannel(cc)
+		before := cc.size
+		cc.customMax = true
+		cc.setMaxMessages(maxMessages)
+		c.auditRemoval("SetChannelMaxMessages", cc, before-cc.size)
+		cc.Unlock()
+	}
+	c.Unlock()
+	c.channelsEvicted(evicted)
+	return nil
+}
+
+// ClearChannel removes all cached messages and counters of a channel, keeping the channel and its capacity. It returns
+// ErrCacheMiss for unknown channels and ErrChannelFrozen for frozen ones; see ForceClearChannel.
+func (c *MessageCache) ClearChannel(channelID string) error {
+	return c.clearChannel(channelID, false)
+}
+
+// ClearChannels clears several channels as ClearChannel does. A failing channel does not stop the others;
+// failures, such as ErrCacheMiss or ErrChannelFrozen, are returned as *ChannelError values joined with
+// errors.Join.
+func (c *MessageCache) ClearChannels(channelIDs ...string) error {
+	failed := make(map[string]error)
+	for _, channelID := range channelIDs {
+		if err := c.ClearChannel(channelID); err != nil {
+			failed[channelID] = err
+		}
+	}
+	return joinChannelErrors(failed)
+}
+
+// ForceClearChannel is like ClearChannel but also clears frozen channels, which stay frozen.
+func (c *MessageCache) ForceClearChannel(channelID string) error {
+	return c.clearChannel(channelID, true)
+}
+
+// clearChannel implements ClearChannel and ForceClearChannel.
+func (c *MessageCache) clearChannel(channelID string, force bool) error {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return ErrCacheMiss
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if cc.frozen.Load() && !force {
+		return ErrChannelFrozen
+	}
+	c.clearLocked("ClearChannel", cc)
+	return nil
+}
+
+// clearLocked removes all messages and counters of a channel for op. The caller must hold the channel's write
+// lock.
+func (c *MessageCache) clearLocked(op string, cc *ChannelCache) {
+	c.auditRemoval(op, cc, cc.size)
+	cc.reset(nil)
+	cc.counters = nil
+}
+
+// DrainChannel returns all cached messages of a channel in chronological order and removes them in the same
+// locked operation, so every message is either returned by exactly one drain or left in the cache. Counters and
+// the channel itself are kept. It returns ErrCacheMiss for unknown channels and ErrChannelFrozen for frozen ones.
+func (c *MessageCache) DrainChannel(channelID string) ([]*discordgo.Message, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if cc.frozen.Load() {
+		return nil, ErrChannelFrozen
+	}
+	msgs := cc.messages()
+	c.auditRemoval("DrainChannel", cc, len(msgs))
+	cc.reset(nil)
+	return msgs, nil
+}
+
+// DeleteChannel removes a channel and its messages from the cache. It returns ErrCacheMiss for unknown channels
+// and ErrChannelFrozen for frozen ones; see ForceDeleteChannel.
+func (c *MessageCache) DeleteChannel(channelID string) error {
+	return c.deleteChannel(channelID, false)
+}
+
+// ForceDeleteChannel is like DeleteChannel but also deletes frozen channels.
+func (c *MessageCache) ForceDeleteChannel(channelID string) error {
+	return c.deleteChannel(channelID, true)
+}
+
+// deleteChannel implements DeleteChannel and ForceDeleteChannel.
+func (c *MessageCache) deleteChannel(channelID string, force bool) error {
+	c.lockGlobal()
+	defer c.Unlock()
+	cc, ok := c.messages[channelID]
+	if !ok {
+		return ErrCacheMiss
+	}
+	if cc.frozen.Load() && !force {
+		return ErrChannelFrozen
+	}
+	c.rlockChannel(cc)
+	c.auditRemoval("DeleteChannel", cc, cc.size)
+	cc.RUnlock()
+	c.deleteChannelLocked(channelID)
+	return nil
+}
+
+// RenameChannel moves the cached data of oldID to newID without copying it. It returns ErrCacheMiss if oldID is
+// not cached and ErrChannelExists if newID already is.
+func (c *MessageCache) RenameChannel(oldID, newID string) error {
+	c.lockGlobal()
+	defer c.Unlock()
+	cc, ok := c.messages[oldID]
+	if !ok {
+		return ErrCacheMiss
+	}
+	if _, exists := c.messages[newID]; exists {
+		return ErrChannelExists
+	}
+	c.unlinkChannelLocked(oldID)
+	c.storeChannelLocked(newID, cc)
+	c.lockChannel(cc)
+	cc.id = newID
+	cc.Unlock()
+	return nil
+}
+
+// channelCache looks up the ChannelCache for a channel without creating it.
+func (c *MessageCache) channelCache(channelID string) (*ChannelCache, bool) {
+	var cc *ChannelCache
+	var ok bool
+	if c.syncChannels != nil {
+		cc, ok = c.loadChannel(channelID)
+	} else {
+		c.rlockGlobal()
+		cc, ok = c.messages[channelID]
+		c.RUnlock()
+	}
+	if ok {
+		c.touchChannel(cc)
+	}
+	return cc, ok
+}
+
+// channelCaches returns a snapshot of all channel caches so they can be visited without holding the global lock.
+func (c *MessageCache) channelCaches() []*ChannelCache {
+	c.rlockGlobal()
+	defer c.RUnlock()
+	caches := make([]*ChannelCache, 0, len(c.messages))
+	for _, cc := range c.messages {
+		caches = append(caches, cc)
+	}
+	return caches
+}
+
+// getOrCreateChannelCache returns the ChannelCache for a channel, creating it under the global write lock if needed.
+func (c *MessageCache) getOrCreateChannelCache(channelID string) *ChannelCache {
+	if cc, ok := c.channelCache(channelID); ok {
+		c.countAddPath(false)
+		return cc
+	}
+	c.countAddPath(true)
+	c.lockGlobal()
+	if cc, ok := c.messages[channelID]; ok {
+		c.Unlock()
+		return cc
+	}
+	cc, evicted := c.createChannelLocked(channelID, c.maxMessages, 0)
+	c.Unlock()
+	c.channelsEvicted(evicted)
+	return cc
+}
+
+// lockChannelForAdd returns the ChannelCache for a channel with its write lock held, creating it if needed.
+// A channel cache retired by a concurrent replace or delete is skipped in favor of its successor, so adds never
+// land in a cache that is no longer reachable.
+func (c *MessageCache) lockChannelForAdd(channelID string) *ChannelCache {
+	for {
+		cc := c.getOrCreateChannelCache(channelID)
+		c.lockChannel(cc)
+		if !cc.retired {
+			return cc
 		}
+		cc.Unlock()
+	}
+}
+
+// createChannelLocked creates and stores a new channel cache with room for capacity messages allocated up front,
+// returning it with the IDs of the channels evicted to make room. The caller must hold the global write lock
+// and pass the evicted IDs to channelsEvicted after releasing it.
+func (c *MessageCache) createChannelLocked(channelID string, maxMessages, capacity int) (*ChannelCache, []string) {
+	evicted := c.evictChannelsLocked()
+	cc := c.newChannel(channelID, maxMessages, capacity)
+	c.touchChannel(cc)
+	c.storeChannelLocked(channelID, cc)
+	return cc, evicted
+}
+
+// newChannel creates an empty channel cache configured with the cache's options.
+func (c *MessageCache) newChannel(channelID string, maxMessages, capacity int) *ChannelCache {
+	trackIDs := !c.dedupDisabled && !containsKey(c.noDedupChannels, channelID)
+	cc := newChannelCache(channelID, maxMessages, capacity, trackIDs)
+	cc.onEvict = c.evictHook()
+	cc.globalIDs = c.globalIDs
+	if c.bloomRate > 0 && cc.messageIDs != nil {
+		cc.messageIDs = nil
+		cc.bloomRate = c.bloomRate
+		cc.bloom = newCountingBloom(maxMessages, c.bloomRate)
+	}
+	if c.dedupHorizon > maxMessages && trackIDs {
+		cc.horizon = newIDRing(c.dedupHorizon)
+	}
+	if c.perAuthorCap > 0 {
+		cc.authorCounts = make(map[string]int)
+	}
+	if c.tiers.hot > 0 {
+		cc.tiers = &tierState{tierPolicy: c.tiers}
 	}
+	if c.optimisticReads {
+		cc.optimistic = newOptimisticRing()
+	}
+	return cc
+}
+
+// newDetachedChannel is newChannel for a channel built off to the side and swapped in later, such as by
+// ReplaceChannel or LoadFromFile: it reports no evictions and stays out of the global ID set until
+// storeChannelLocked attaches it, so building it has no effect if it is thrown away.
+func (c *MessageCache) newDetachedChannel(channelID string, maxMessages, capacity int) *ChannelCache {
+	cc := c.newChannel(channelID, maxMessages, capacity)
+	cc.onEvict = nil
+	cc.globalIDs = nil
+	return cc
 }
 
 // Global cache
@@ -1,8 +1,12 @@
 package dgocacheler
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 )
@@ -74,3 +78,667 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected 100 messages, got %d", len(msgs))
 	}
 }
+
+func TestGetMessagesBySeqConcurrent(t *testing.T) {
+	const writers, perWriter = 8, 50
+	cache := NewMessageCache(writers * perWriter)
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprintf("%d-%d", w, i)})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	msgs, ok := cache.GetMessagesBySeq("channel1")
+	if !ok || len(msgs) != writers*perWriter {
+		t.Fatalf("Expected %d messages, got %d", writers*perWriter, len(msgs))
+	}
+
+	// Every writer's messages must appear in the order that writer added them.
+	next := make(map[int]int)
+	for _, msg := range msgs {
+		var w, i int
+		if _, err := fmt.Sscanf(msg.ID, "%d-%d", &w, &i); err != nil {
+			t.Fatalf("Unexpected message ID %q", msg.ID)
+		}
+		if i != next[w] {
+			t.Fatalf("Writer %d: expected message %d, got %d", w, next[w], i)
+		}
+		next[w]++
+	}
+
+	// Sequence numbers must be unique and strictly increasing in the returned order.
+	cc := cache.messages["channel1"]
+	cc.RLock()
+	defer cc.RUnlock()
+	seqs := make(map[string]uint64)
+	for _, entry := range cc.entries() {
+		seqs[entry.message.ID] = entry.insertSeq
+	}
+	for i := 1; i < len(msgs); i++ {
+		if seqs[msgs[i-1].ID] >= seqs[msgs[i].ID] {
+			t.Fatalf("Sequence not increasing at index %d", i)
+		}
+	}
+}
+
+func TestGetMessagesBySeqUnknownChannel(t *testing.T) {
+	cache := NewMessageCache(5)
+	if _, ok := cache.GetMessagesBySeq("missing"); ok {
+		t.Error("GetMessagesBySeq should report a miss for an unknown channel.")
+	}
+}
+
+func TestSetChannelMaxMessagesBatch(t *testing.T) {
+	cache := NewMessageCache(5)
+	for _, channelID := range []string{"channel1", "channel2", "channel3"} {
+		cache.AddMessages(channelID, testHistory(5))
+	}
+
+	err := cache.SetChannelMaxMessagesBatch(map[string]int{"channel1": 2, "channel2": 10, "channel4": 3})
+	if err != nil {
+		t.Fatalf("SetChannelMaxMessagesBatch returned error: %v", err)
+	}
+
+	// Resized down: only the newest messages remain.
+	if msgs, _ := cache.GetMessages("channel1"); fmt.Sprint(messageIDs(msgs)) != "[103 104]" {
+		t.Errorf("Expected channel1 to keep its 2 newest messages, got %v", messageIDs(msgs))
+	}
+	// Resized up: the channel can now hold more messages.
+	cache.AddMessages("channel2", testHistory(10)[5:])
+	if msgs, _ := cache.GetMessages("channel2"); len(msgs) != 10 {
+		t.Errorf("Expected channel2 to hold 10 messages, got %d", len(msgs))
+	}
+	// Channels not in the map are unchanged; channels in the map are created.
+	if cache.messages["channel3"].maxMessages != 5 || cache.messages["channel4"].maxMessages != 3 {
+		t.Error("Unexpected capacities for channel3 or channel4.")
+	}
+
+	// The global setter does not override per-channel capacities.
+	cache.SetMaxMessages(7)
+	if cache.messages["channel1"].maxMessages != 2 || cache.messages["channel3"].maxMessages != 7 {
+		t.Error("SetMaxMessages should only resize channels without their own capacity.")
+	}
+}
+
+func TestSetChannelMaxMessagesBatchInvalid(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(5))
+
+	err := cache.SetChannelMaxMessagesBatch(map[string]int{"channel1": 2, "channel2": 0})
+	if !errors.Is(err, ErrInvalidMaxMessages) {
+		t.Fatalf("Expected ErrInvalidMaxMessages, got %v", err)
+	}
+	if msgs, _ := cache.GetMessages("channel1"); len(msgs) != 5 {
+		t.Errorf("No change should be applied on an invalid batch, got %d messages", len(msgs))
+	}
+	if _, ok := cache.messages["channel2"]; ok {
+		t.Error("No channel should be created on an invalid batch.")
+	}
+	if err := cache.SetChannelMaxMessages("channel1", -1); !errors.Is(err, ErrInvalidMaxMessages) {
+		t.Errorf("Expected ErrInvalidMaxMessages, got %v", err)
+	}
+}
+
+func TestBatchErrorsAttributeChannels(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(2))
+	cache.FreezeChannel("channel1")
+
+	err := cache.SetChannelMaxMessagesBatch(map[string]int{"channel1": 2, "channel2": 0, "channel3": -1})
+	var channelErr *ChannelError
+	if !errors.As(err, &channelErr) || channelErr.ChannelID != "channel2" || !errors.Is(err, ErrInvalidMaxMessages) {
+		t.Errorf("Expected a ChannelError for channel2 wrapping ErrInvalidMaxMessages, got %v", err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("Expected both invalid channels to be reported, got %d errors", n)
+	}
+	err = cache.SetChannelMaxMessagesBatch(map[string]int{"channel1": 2, "channel2": 3})
+	if !errors.As(err, &channelErr) || channelErr.ChannelID != "channel1" || !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected a ChannelError for channel1 wrapping ErrChannelFrozen, got %v", err)
+	}
+}
+
+func TestAddMessagesMulti(t *testing.T) {
+	cache := NewMessageCache(5, WithStrictCapacity())
+	cache.AddMessages("full", testHistory(5))
+
+	err := cache.AddMessagesMulti(map[string][]*discordgo.Message{
+		"channel1": testHistory(2),
+		"full":     testHistory(6)[5:],
+		"":         testHistory(1),
+		"channel2": testHistory(3),
+	})
+	if !errors.Is(err, ErrChannelFull) || !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected the aggregate to hold ErrChannelFull and ErrInvalidChannel, got %v", err)
+	}
+	var fullErr *ChannelFullError
+	if !errors.As(err, &fullErr) || fullErr.ChannelID != "full" {
+		t.Errorf("Expected the *ChannelFullError to be reachable, got %v", err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected sibling channels to be added despite failures, got %d messages", n)
+	}
+	if n, _ := cache.MessageCount("channel2"); n != 3 {
+		t.Errorf("Expected sibling channels to be added despite failures, got %d messages", n)
+	}
+	if err := cache.AddMessagesMulti(map[string][]*discordgo.Message{"channel3": testHistory(1)}); err != nil {
+		t.Errorf("Expected nil without failures, got %v", err)
+	}
+}
+
+func TestClearChannels(t *testing.T) {
+	cache := NewMessageCache(5)
+	for _, channelID := range []string{"a", "b", "d"} {
+		cache.AddMessages(channelID, testHistory(2))
+	}
+	cache.FreezeChannel("b")
+
+	err := cache.ClearChannels("a", "b", "c", "d")
+	if !errors.Is(err, ErrChannelFrozen) || !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrChannelFrozen and ErrCacheMiss in the aggregate, got %v", err)
+	}
+	if want := "channel b: " + ErrChannelFrozen.Error() + "\nchannel c: " + ErrCacheMiss.Error(); err.Error() != want {
+		t.Errorf("Got %q, want %q", err.Error(), want)
+	}
+	for channelID, want := range map[string]int{"a": 0, "b": 2, "d": 0} {
+		if n, _ := cache.MessageCount(channelID); n != want {
+			t.Errorf("Expected %d messages in %s, got %d", want, channelID, n)
+		}
+	}
+}
+
+func TestGetOldestMessagesLimit(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(8)) // wraps: 103..107 remain
+
+	msgs, err := cache.GetOldestMessagesLimit("channel1", 2)
+	if err != nil || fmt.Sprint(messageIDs(msgs)) != "[103 104]" {
+		t.Errorf("Expected the 2 oldest messages, got %v (err %v)", messageIDs(msgs), err)
+	}
+	msgs, err = cache.GetOldestMessagesLimit("channel1", 50)
+	if err != nil || fmt.Sprint(messageIDs(msgs)) != "[103 104 105 106 107]" {
+		t.Errorf("Expected all messages when limit exceeds size, got %v (err %v)", messageIDs(msgs), err)
+	}
+
+	cache.SetChannelMaxMessages("empty", 5)
+	msgs, err = cache.GetOldestMessagesLimit("empty", 5)
+	if err != nil || msgs == nil || len(msgs) != 0 {
+		t.Errorf("Expected an empty slice for an empty channel, got %v (err %v)", msgs, err)
+	}
+
+	if _, err := cache.GetOldestMessagesLimit("missing", 5); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+	if _, err := cache.GetOldestMessagesLimit("channel1", 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+}
+
+// snowflakeAt returns a snowflake ID encoding t, with seq as the increment.
+func snowflakeAt(t time.Time, seq int) string {
+	return fmt.Sprint((t.UnixMilli()-1420070400000)<<22 | int64(seq))
+}
+
+func TestGetRecentSince(t *testing.T) {
+	cache := NewMessageCache(10)
+	now := time.Now()
+	for i := range 6 {
+		sent := now.Add(time.Duration(i-6) * time.Minute) // sent 6..1 minutes ago
+		cache.AddMessage("channel1", &discordgo.Message{ID: snowflakeAt(sent, i)})
+	}
+	ids, _ := cache.MessageIDs("channel1")
+
+	tests := []struct {
+		name  string
+		limit int
+		since time.Time
+		want  []string
+	}{
+		{"some excluded", 20, now.Add(-3*time.Minute - 30*time.Second), ids[3:]},
+		{"limited", 2, now.Add(-3*time.Minute - 30*time.Second), ids[4:]},
+		{"all excluded", 20, now, []string{}},
+		{"none excluded", 20, now.Add(-time.Hour), ids},
+	}
+	for _, tt := range tests {
+		msgs, err := cache.GetRecentSince("channel1", tt.limit, tt.since)
+		if err != nil {
+			t.Fatalf("%s: GetRecentSince returned error: %v", tt.name, err)
+		}
+		if got := fmt.Sprint(messageIDs(msgs)); got != fmt.Sprint(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := cache.GetRecentSince("channel1", 0, now); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+	if _, err := cache.GetRecentSince("unknown", 5, now); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestGetMessagesRange(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(15)) // wraps: 105..114 remain
+
+	for _, tc := range []struct {
+		offset, count int
+		want          string
+	}{
+		{0, 3, "[112 113 114]"},
+		{2, 3, "[110 111 112]"},
+		{8, 5, "[105 106]"},
+		{10, 5, "[]"},
+		{50, 5, "[]"},
+		{0, 0, "[]"},
+		{0, 100, "[105 106 107 108 109 110 111 112 113 114]"},
+	} {
+		msgs, err := cache.GetMessagesRange("channel1", tc.offset, tc.count)
+		if err != nil || fmt.Sprint(messageIDs(msgs)) != tc.want {
+			t.Errorf("GetMessagesRange(%d, %d) = %v (err %v), want %s", tc.offset, tc.count, messageIDs(msgs), err, tc.want)
+		}
+	}
+
+	if _, err := cache.GetMessagesRange("channel1", -1, 5); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit for a negative offset, got %v", err)
+	}
+	if _, err := cache.GetMessagesRange("channel1", 0, -5); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit for a negative count, got %v", err)
+	}
+	if _, err := cache.GetMessagesRange("missing", 0, 5); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestGetRecentWindow(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(15)) // wraps: 105..114 remain
+
+	for _, tc := range []struct {
+		offset, limit int
+		want          string
+	}{
+		{0, 3, "[114 113 112]"},
+		{3, 3, "[111 110 109]"},
+		{8, 5, "[106 105]"},
+		{10, 5, "[]"},
+		{50, 5, "[]"},
+	} {
+		msgs, err := cache.GetRecentWindow("channel1", tc.offset, tc.limit)
+		if err != nil || fmt.Sprint(messageIDs(msgs)) != tc.want {
+			t.Errorf("GetRecentWindow(%d, %d) = %v (err %v), want %s", tc.offset, tc.limit, messageIDs(msgs), err, tc.want)
+		}
+	}
+
+	if _, err := cache.GetRecentWindow("channel1", 0, 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit for a zero limit, got %v", err)
+	}
+	if _, err := cache.GetRecentWindow("channel1", -1, 5); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit for a negative offset, got %v", err)
+	}
+	if _, err := cache.GetRecentWindow("missing", 0, 5); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestGetContext(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(15)) // wraps: 105..114 remain
+
+	for _, tc := range []struct {
+		anchor        string
+		before, after int
+		want          string
+	}{
+		{"109", 2, 2, "[107 108 109 110 111]"},
+		{"109", 0, 0, "[109]"},
+		{"106", 3, 1, "[105 106 107]"},
+		{"105", 5, 2, "[105 106 107]"},
+		{"113", 1, 5, "[112 113 114]"},
+		{"114", 2, 3, "[112 113 114]"},
+		{"110", 20, 20, "[105 106 107 108 109 110 111 112 113 114]"},
+	} {
+		msgs, err := cache.GetContext("channel1", tc.anchor, tc.before, tc.after)
+		if err != nil || fmt.Sprint(messageIDs(msgs)) != tc.want {
+			t.Errorf("GetContext(%s, %d, %d) = %v (err %v), want %s", tc.anchor, tc.before, tc.after, messageIDs(msgs), err, tc.want)
+		}
+	}
+
+	if _, err := cache.GetContext("channel1", "104", 1, 1); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound for an evicted anchor, got %v", err)
+	}
+	if _, err := cache.GetContext("channel1", "110", -1, 1); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit for a negative count, got %v", err)
+	}
+	if _, err := cache.GetContext("missing", "110", 1, 1); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestGetMessagesWithWrapInfo(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(5))
+	msgs, wrapped, err := cache.GetMessagesWithWrapInfo("channel1")
+	if err != nil || len(msgs) != 5 || wrapped {
+		t.Errorf("Expected a full but never overflowed channel, got %d messages, wrapped %v (err %v)", len(msgs), wrapped, err)
+	}
+
+	cache.AddMessages("channel1", testHistory(7)[5:])
+	msgs, wrapped, _ = cache.GetMessagesWithWrapInfo("channel1")
+	if fmt.Sprint(messageIDs(msgs)) != "[102 103 104 105 106]" || !wrapped {
+		t.Errorf("Expected an overflowed channel, got %v, wrapped %v", messageIDs(msgs), wrapped)
+	}
+	cache.RemoveMessage("channel1", "106")
+	if _, wrapped, _ := cache.GetMessagesWithWrapInfo("channel1"); !wrapped {
+		t.Error("Expected the overflow flag to stick once set.")
+	}
+
+	cache.ReplaceChannel("channel2", testHistory(8))
+	if msgs, wrapped, _ := cache.GetMessagesWithWrapInfo("channel2"); len(msgs) != 5 || !wrapped {
+		t.Errorf("Expected replacing with more messages than fit to count as overflow, got %d messages, wrapped %v", len(msgs), wrapped)
+	}
+	if _, _, err := cache.GetMessagesWithWrapInfo("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestMessageCount(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(15))
+	if n, err := cache.MessageCount("channel1"); err != nil || n != 10 {
+		t.Errorf("Expected 10 messages, got %d (err %v)", n, err)
+	}
+	if _, err := cache.MessageCount("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	cache := NewMessageCache(10)
+	if !cache.IsEmpty() {
+		t.Error("Expected a new cache to be empty")
+	}
+
+	cache.Channel("channel1")
+	cache.AddMessage("channel2", &discordgo.Message{ID: "1"})
+	cache.RemoveMessage("channel2", "1")
+	if !cache.IsEmpty() {
+		t.Error("Expected a cache with only empty channels to be empty")
+	}
+
+	cache.AddMessage("channel3", &discordgo.Message{ID: "2"})
+	if cache.IsEmpty() {
+		t.Error("Expected a populated cache not to be empty")
+	}
+}
+
+func TestUpdateMessage(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", []*discordgo.Message{{ID: "1", Content: "one"}, {ID: "2", Content: "two"}})
+
+	if err := cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "edited"}); err != nil {
+		t.Fatalf("UpdateMessage returned error: %v", err)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if len(msgs) != 2 || msgs[0].Content != "edited" || msgs[1].Content != "two" {
+		t.Errorf("Expected the message to be updated in place, got %v", msgs)
+	}
+
+	if err := cache.UpdateMessage("channel1", &discordgo.Message{ID: "9"}); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected ErrMessageNotFound, got %v", err)
+	}
+	if err := cache.UpdateMessage("missing", &discordgo.Message{ID: "1"}); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestTryAddMessage(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+
+	locked, release := make(chan struct{}), make(chan struct{})
+	go func() {
+		cc := cache.messages["channel1"]
+		cc.Lock()
+		close(locked)
+		<-release
+		cc.Unlock()
+	}()
+	<-locked
+
+	if ok, err := cache.TryAddMessage("channel1", &discordgo.Message{ID: "2"}); ok || err != nil {
+		t.Errorf("TryAddMessage should fail while the channel is locked, got %v (err %v)", ok, err)
+	}
+	close(release)
+
+	// Once released, the try succeeds.
+	for {
+		ok, err := cache.TryAddMessage("channel1", &discordgo.Message{ID: "2"})
+		if err != nil {
+			t.Fatalf("TryAddMessage returned error: %v", err)
+		}
+		if ok {
+			break
+		}
+		runtime.Gosched()
+	}
+	if msgs, _ := cache.GetMessages("channel1"); len(msgs) != 2 {
+		t.Errorf("Expected 2 messages, got %d", len(msgs))
+	}
+
+	// A true result means the message was handled, not necessarily stored.
+	if ok, err := cache.TryAddMessage("channel1", &discordgo.Message{ID: "2"}); !ok || err != nil {
+		t.Errorf("Expected a duplicate to be handled, got %v (err %v)", ok, err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected the duplicate not stored, got %d messages", n)
+	}
+}
+
+func TestNewestAndLastMessageID(t *testing.T) {
+	cache := NewMessageCache(3)
+	cache.AddMessages("channel1", testHistory(5))
+
+	if msg, err := cache.Newest("channel1"); err != nil || msg.ID != "104" {
+		t.Errorf("Expected the newest message, got %v (err %v)", msg, err)
+	}
+	if id, err := cache.LastMessageID("channel1"); err != nil || id != "104" {
+		t.Errorf("Expected last message ID 104, got %q (err %v)", id, err)
+	}
+
+	cache.SetChannelMaxMessages("empty", 3)
+	if _, err := cache.LastMessageID("empty"); !errors.Is(err, ErrEmptyChannel) {
+		t.Errorf("Expected ErrEmptyChannel, got %v", err)
+	}
+	if _, err := cache.LastMessageID("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { cache.LastMessageID("channel1") }); allocs != 0 {
+		t.Errorf("LastMessageID should not allocate, got %v allocations", allocs)
+	}
+}
+
+func BenchmarkLastMessageID(b *testing.B) {
+	cache := NewMessageCache(100)
+	cache.AddMessages("channel1", testHistory(100))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cache.LastMessageID("channel1")
+	}
+}
+
+func TestRenameChannel(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithSyncMapChannels()}} {
+		cache := NewMessageCache(10, opts...)
+		cache.AddMessages("old", testHistory(3))
+		cache.AddMessages("taken", testHistory(1))
+
+		if err := cache.RenameChannel("old", "new"); err != nil {
+			t.Fatalf("RenameChannel returned error: %v", err)
+		}
+		if _, ok := cache.GetMessages("old"); ok {
+			t.Error("The old channel ID should no longer be cached.")
+		}
+		if msgs, ok := cache.GetMessages("new"); !ok || len(msgs) != 3 {
+			t.Errorf("Expected the messages under the new ID, got %d", len(msgs))
+		}
+		if sizes := cache.LargestChannels(1); sizes[0].ChannelID != "new" {
+			t.Errorf("Expected the channel to report its new ID, got %q", sizes[0].ChannelID)
+		}
+
+		if err := cache.RenameChannel("missing", "other"); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Expected ErrCacheMiss, got %v", err)
+		}
+		if err := cache.RenameChannel("new", "taken"); !errors.Is(err, ErrChannelExists) {
+			t.Errorf("Expected ErrChannelExists, got %v", err)
+		}
+		if msgs, _ := cache.GetMessages("taken"); len(msgs) != 1 {
+			t.Error("A failed rename must not touch the target channel.")
+		}
+	}
+}
+
+func TestAddMessageWithPriority(t *testing.T) {
+	cache := NewMessageCache(4)
+	history := testHistory(8)
+	cache.AddMessageWithPriority("channel1", history[0], 10) // pinned
+	cache.AddMessages("channel1", history[1:3])
+	cache.AddMessageWithPriority("channel1", history[3], 5)
+	cache.AddMessages("channel1", history[4:7])
+
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[100 103 105 106]" {
+		t.Errorf("Expected high-priority messages to survive overflow, got %v", got)
+	}
+
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "100", Content: "edited"})
+	cache.SetMaxMessages(2)
+	msgs, _ = cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[100 103]" {
+		t.Errorf("Expected shrinking to evict by priority, got %v", got)
+	}
+	if stats, _ := cache.ChannelStats("channel1"); stats.EstimatedBytes != recomputeBytes(cache.messages["channel1"]) {
+		t.Error("Byte count drifted after priority eviction.")
+	}
+}
+
+func TestClearAndDeleteChannel(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(3))
+	cache.SetChannelMaxMessages("channel1", 2)
+
+	if err := cache.ClearChannel("channel1"); err != nil {
+		t.Fatalf("ClearChannel returned error: %v", err)
+	}
+	cache.AddMessages("channel1", testHistory(3))
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected the cleared channel to keep its capacity, got %d messages", n)
+	}
+	if err := cache.DeleteChannel("channel1"); err != nil {
+		t.Fatalf("DeleteChannel returned error: %v", err)
+	}
+	if _, ok := cache.GetMessages("channel1"); ok {
+		t.Error("Deleted channel should no longer be cached.")
+	}
+	if err := cache.ClearChannel("channel1"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss from ClearChannel, got %v", err)
+	}
+	if err := cache.DeleteChannel("channel1"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss from DeleteChannel, got %v", err)
+	}
+}
+
+func TestDrainChannel(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(3))
+	msgs, err := cache.DrainChannel("channel1")
+	if err != nil || fmt.Sprint(messageIDs(msgs)) != "[100 101 102]" {
+		t.Errorf("Unexpected drain result: %v (err %v)", messageIDs(msgs), err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 0 {
+		t.Errorf("Expected the channel to be empty after draining, got %d messages", n)
+	}
+	if _, err := cache.DrainChannel("unknown"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestDrainChannelConcurrent(t *testing.T) {
+	const total = 2000
+	cache := NewMessageCache(total)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "seed"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := range total {
+			cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+		}
+	}()
+	seen := make(map[string]int)
+	drain := func() {
+		msgs, _ := cache.DrainChannel("channel1")
+		for _, msg := range msgs {
+			seen[msg.ID]++
+		}
+	}
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			drain()
+			runtime.Gosched()
+		}
+	}
+	drain()
+
+	if len(seen) != total+1 {
+		t.Errorf("Expected all %d messages to be drained, got %d", total+1, len(seen))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Errorf("Message %s drained %d times", id, n)
+		}
+	}
+}
+
+func TestMessageIDs(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(8))
+	ids, err := cache.MessageIDs("channel1")
+	msgs, _ := cache.GetMessages("channel1")
+	if err != nil || fmt.Sprint(ids) != fmt.Sprint(messageIDs(msgs)) {
+		t.Errorf("Expected IDs %v, got %v (err %v)", messageIDs(msgs), ids, err)
+	}
+	if _, err := cache.MessageIDs("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func BenchmarkMessageIDs(b *testing.B) {
+	cache := NewMessageCache(100)
+	cache.AddMessages("channel1", testHistory(100))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cache.MessageIDs("channel1")
+	}
+}
+
+func BenchmarkMessageIDsViaGetMessages(b *testing.B) {
+	cache := NewMessageCache(100)
+	cache.AddMessages("channel1", testHistory(100))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		msgs, _ := cache.GetMessages("channel1")
+		messageIDs(msgs)
+	}
+}
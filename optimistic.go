@@ -0,0 +1,154 @@
+package dgocacheler
+
+import (
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// optimisticRetries is how many times an optimistic read is attempted before falling back to the read lock.
+const optimisticRetries = 4
+
+// WithOptimisticReads lets GetMessagesLimit read without taking the channel lock. Every channel keeps a copy of
+// its message pointers in atomic slots and a sequence counter that is odd while a writer holds the channel
+// lock. A reader copies the newest pointers and keeps them only if the counter was even and unchanged around
+// the copy, retrying a few times before falling back to the read lock. This trades a second slot per cached
+// message and slightly slower writes for uncontended reads that touch no lock. Reads go through the lock while
+// SetGlobalMaxMessages is set, since they bump recency.
+func WithOptimisticReads() Option {
+	return func(c *MessageCache) {
+		c.optimisticReads = true
+	}
+}
+
+// optimisticRing mirrors a channel's message pointers for lock-free readers. Writers update it under the
+// channel's write lock; every field a reader touches is atomic.
+type optimisticRing struct {
+	seq   atomic.Uint64                                       // seq is odd while a writer holds the channel lock
+	slots atomic.Pointer[[]atomic.Pointer[discordgo.Message]] // slots is the ring of message pointers
+	head  atomic.Int64                                        // head is the slot of the oldest message
+	size  atomic.Int64                                        // size is the number of messages
+}
+
+// newOptimisticRing returns an empty ring.
+func newOptimisticRing() *optimisticRing {
+	r := &optimisticRing{}
+	r.slots.Store(&[]atomic.Pointer[discordgo.Message]{})
+	return r
+}
+
+// Lock acquires the channel's write lock.
+func (cc *ChannelCache) Lock() {
+	cc.RWMutex.Lock()
+	if cc.optimistic != nil {
+		cc.optimistic.seq.Add(1)
+	}
+}
+
+// TryLock tries to acquire the channel's write lock and reports whether it succeeded.
+func (cc *ChannelCache) TryLock() bool {
+	if !cc.RWMutex.TryLock() {
+		return false
+	}
+	if cc.optimistic != nil {
+		cc.optimistic.seq.Add(1)
+	}
+	return true
+}
+
+// Unlock releases the channel's write lock.
+func (cc *ChannelCache) Unlock() {
+	if cc.optimistic != nil {
+		cc.optimistic.seq.Add(1)
+	}
+	cc.RWMutex.Unlock()
+}
+
+// newest copies up to limit of the newest message pointers, reporting false if no attempt saw a stable ring.
+func (r *optimisticRing) newest(limit int) ([]*discordgo.Message, bool) {
+	for attempt := 0; attempt < optimisticRetries; attempt++ {
+		seq := r.seq.Load()
+		if seq%2 == 1 {
+			continue
+		}
+		slots := *r.slots.Load()
+		head, size := r.head.Load(), r.size.Load()
+		n := min(int64(max(limit, 0)), size)
+		msgs := make([]*discordgo.Message, n)
+		for i := range msgs {
+			if len(slots) > 0 {
+				msgs[i] = slots[(head+size-n+int64(i))%int64(len(slots))].Load()
+			}
+		}
+		if r.seq.Load() == seq {
+			return msgs, true
+		}
+	}
+	return nil, false
+}
+
+// slot returns the slot of logical position i. The caller must hold the channel's write lock.
+func (r *optimisticRing) slot(i int) *atomic.Pointer[discordgo.Message] {
+	slots := *r.slots.Load()
+	return &slots[(int(r.head.Load())+i)%len(slots)]
+}
+
+// push appends the newest message, growing the ring when full. The caller must hold the channel's write lock.
+func (r *optimisticRing) push(message *discordgo.Message) {
+	size := int(r.size.Load())
+	if size == len(*r.slots.Load()) {
+		r.load(r.messages(), max(2*size, channelInitialCapacity))
+	}
+	r.slot(size).Store(message)
+	r.size.Add(1)
+}
+
+// dropOldest removes the oldest message. The caller must hold the channel's write lock.
+func (r *optimisticRing) dropOldest() {
+	r.slot(0).Store(nil)
+	r.head.Store((r.head.Load() + 1) % int64(len(*r.slots.Load())))
+	r.size.Add(-1)
+}
+
+// removeAt removes the message at logical position i. The caller must hold the channel's write lock.
+func (r *optimisticRing) removeAt(i int) {
+	size := int(r.size.Load())
+	if i < size/2 {
+		for j := i; j > 0; j-- {
+			r.slot(j).Store(r.slot(j - 1).Load())
+		}
+		r.dropOldest()
+		return
+	}
+	for j := i; j < size-1; j++ {
+		r.slot(j).Store(r.slot(j + 1).Load())
+	}
+	r.slot(size - 1).Store(nil)
+	r.size.Add(-1)
+}
+
+// set replaces the message at logical position i. The caller must hold the channel's write lock.
+func (r *optimisticRing) set(i int, message *discordgo.Message) {
+	r.slot(i).Store(message)
+}
+
+// load replaces the contents with msgs in a ring of at least the given capacity. The caller must hold the
+// channel's write lock.
+func (r *optimisticRing) load(msgs []*discordgo.Message, capacity int) {
+	slots := make([]atomic.Pointer[discordgo.Message], max(capacity, len(msgs)))
+	for i, msg := range msgs {
+		slots[i].Store(msg)
+	}
+	r.slots.Store(&slots)
+	r.head.Store(0)
+	r.size.Store(int64(len(msgs)))
+}
+
+// messages copies the message pointers in order. The caller must hold the channel's write lock.
+func (r *optimisticRing) messages() []*discordgo.Message {
+	msgs := make([]*discordgo.Message, r.size.Load())
+	for i := range msgs {
+		msgs[i] = r.slot(i).Load()
+	}
+	return msgs
+}
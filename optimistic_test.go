@@ -0,0 +1,138 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestOptimisticReadsMatchLockedReads(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cache := NewMessageCache(8, WithOptimisticReads(), WithTieredRetention(4, 3, 2))
+	nextID := 0
+	message := func() *discordgo.Message {
+		nextID++
+		return &discordgo.Message{ID: fmt.Sprint(nextID)}
+	}
+
+	for op := 0; op < 3000; op++ {
+		switch rng.Intn(10) {
+		case 0, 1, 2, 3:
+			cache.AddMessage("channel1", message())
+		case 4:
+			cache.AddMessages("channel1", []*discordgo.Message{message(), message()})
+		case 5:
+			if msgs, _ := cache.GetMessages("channel1"); len(msgs) > 0 {
+				cache.RemoveMessage("channel1", msgs[rng.Intn(len(msgs))].ID)
+			}
+		case 6:
+			if msgs, _ := cache.GetMessages("channel1"); len(msgs) > 0 {
+				edited := *msgs[rng.Intn(len(msgs))]
+				edited.Content = "edited"
+				cache.UpdateMessage("channel1", &edited)
+			}
+		case 7:
+			cache.EvictOldest("channel1", 1+rng.Intn(2))
+		case 8:
+			cache.ReplaceChannel("channel1", []*discordgo.Message{message(), message(), message()})
+		case 9:
+			cache.SetChannelMaxMessages("channel1", 2+rng.Intn(12))
+		}
+
+		want, _ := cache.GetMessages("channel1")
+		limit := 1 + rng.Intn(10)
+		got, ok := cache.GetMessagesLimit("channel1", limit)
+		want = want[max(len(want)-limit, 0):]
+		if ok != (len(want) > 0) || fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("After operation %d: GetMessagesLimit(%d) = %v, %v, want %v", op, limit, messageIDs(got), ok, messageIDs(want))
+		}
+	}
+}
+
+func TestOptimisticReadsUnderConcurrentWrites(t *testing.T) {
+	cache := NewMessageCache(50, WithOptimisticReads())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 2000; i++ {
+			cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+			if i%7 == 0 {
+				cache.RemoveMessage("channel1", fmt.Sprint(i-3))
+			}
+			if i%500 == 0 {
+				cache.ReplaceChannel("channel1", testHistory(10))
+			}
+		}
+	}()
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 2000 {
+				msgs, _ := cache.GetMessagesLimit("channel1", 20)
+				if len(msgs) > 20 {
+					t.Errorf("Expected at most 20 messages, got %d", len(msgs))
+					return
+				}
+				for i, msg := range msgs {
+					if msg == nil {
+						t.Error("Expected no nil messages")
+						return
+					}
+					if i > 0 && CompareSnowflakes(msgs[i-1].ID, msg.ID) >= 0 {
+						t.Errorf("Expected a consistent window, got %v", messageIDs(msgs))
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// benchmarkGetMessagesLimit reads one channel from parallel goroutines, adding a message instead of every
+// writeEvery-th read. Channel lookups use the sync.Map mirror so that the channel lock dominates.
+func benchmarkGetMessagesLimit(b *testing.B, writeEvery int, opts ...Option) {
+	cache := NewMessageCache(100, append(opts, WithSyncMapChannels())...)
+	for i := range 1000 {
+		cache.AddMessage("channel"+strconv.Itoa(i%10), &discordgo.Message{ID: strconv.Itoa(i)})
+	}
+	var next sync.Mutex
+	id := 1000
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for n := 0; pb.Next(); n++ {
+			if writeEvery > 0 && n%writeEvery == 0 {
+				next.Lock()
+				id++
+				message := &discordgo.Message{ID: strconv.Itoa(id)}
+				next.Unlock()
+				cache.AddMessage("channel5", message)
+				continue
+			}
+			cache.GetMessagesLimit("channel5", 10)
+		}
+	})
+}
+
+func BenchmarkGetMessagesLimitReadHeavyLocked(b *testing.B) {
+	benchmarkGetMessagesLimit(b, 0)
+}
+
+func BenchmarkGetMessagesLimitReadHeavyOptimistic(b *testing.B) {
+	benchmarkGetMessagesLimit(b, 0, WithOptimisticReads())
+}
+
+func BenchmarkGetMessagesLimitMixedLocked(b *testing.B) {
+	benchmarkGetMessagesLimit(b, 10)
+}
+
+func BenchmarkGetMessagesLimitMixedOptimistic(b *testing.B) {
+	benchmarkGetMessagesLimit(b, 10, WithOptimisticReads())
+}
@@ -0,0 +1,20 @@
+package dgocacheler
+
+import "errors"
+
+// Option configures optional behavior of a MessageCache when passed to NewMessageCache.
+type Option func(*MessageCache)
+
+// invalidOption records the error of an option given invalid arguments. NewMessageCache passes it to the
+// WithErrorHandler handler and NewMessageCacheChecked returns it.
+func (c *MessageCache) invalidOption(err error) {
+	c.optionErr = errors.Join(c.optionErr, err)
+}
+
+// WithLockProfiling enables tracking of the time spent waiting for the global and per-channel locks.
+// The collected wait-time histograms are reported through Stats.
+func WithLockProfiling() Option {
+	return func(c *MessageCache) {
+		c.lockProfile = &lockProfile{}
+	}
+}
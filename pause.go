@@ -0,0 +1,106 @@
+package dgocacheler
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// pauseState tracks whether ingestion is paused and holds the messages buffered meanwhile.
+type pauseState struct {
+	mu       sync.Mutex
+	held     []heldMessage // held are the buffered messages in arrival order
+	buffer   int           // buffer is the maximum number of held messages, set by PauseBuffering
+	errPause bool          // errPause makes adds return ErrPaused, set by WithPauseErrors
+	skipped  uint64        // skipped counts the messages dropped while paused
+}
+
+// heldMessage is a message buffered while ingestion is paused.
+type heldMessage struct {
+	channelID string
+	message   *discordgo.Message
+	priority  int
+	source    string // source names the method that added the message
+}
+
+// PauseBuffering keeps up to max messages that arrive while ingestion is paused and adds them, in order, on
+// Resume. Messages beyond max are dropped and counted like unbuffered ones.
+func PauseBuffering(max int) Option {
+	return func(c *MessageCache) {
+		c.pause.buffer = max
+	}
+}
+
+// WithPauseErrors makes the add methods return ErrPaused for messages that are dropped while ingestion is
+// paused, instead of nil.
+func WithPauseErrors() Option {
+	return func(c *MessageCache) {
+		c.pause.errPause = true
+	}
+}
+
+// Pause stops the cache from accepting new messages, for example during maintenance. Adds return immediately
+// and their messages are dropped, or held with PauseBuffering; TryAddMessage reports them as taken. Reads keep
+// working.
+func (c *MessageCache) Pause() {
+	c.pause.mu.Lock()
+	defer c.pause.mu.Unlock()
+	c.paused.Store(true)
+}
+
+// Resume accepts new messages again after Pause, first adding any held messages in the order they arrived.
+// Adds that arrive while the held messages are replayed wait for the replay so ordering is preserved.
+func (c *MessageCache) Resume() {
+	c.pause.mu.Lock()
+	defer c.pause.mu.Unlock()
+	for _, held := range c.pause.held {
+		cc := c.lockChannelForAdd(held.channelID)
+		c.addPrioritized(cc, held.message, held.priority, held.source)
+		cc.Unlock()
+	}
+	c.pause.held = nil
+	c.paused.Store(false)
+	c.enforceGlobalCaps()
+}
+
+// Paused reports whether ingestion is paused.
+func (c *MessageCache) Paused() bool {
+	return c.paused.Load()
+}
+
+// PausedSkipped returns the number of messages dropped because ingestion was paused.
+func (c *MessageCache) PausedSkipped() uint64 {
+	c.pause.mu.Lock()
+	defer c.pause.mu.Unlock()
+	return c.pause.skipped
+}
+
+// holdIfPaused takes messages arriving while ingestion is paused, buffering or dropping them. It reports
+// whether the messages were taken, along with the error the add method should return. source names the add
+// method.
+func (c *MessageCache) holdIfPaused(channelID string, priority int, source string, messages ...*discordgo.Message) (bool, error) {
+	if !c.paused.Load() {
+		return false, nil
+	}
+	c.pause.mu.Lock()
+	defer c.pause.mu.Unlock()
+	if !c.paused.Load() {
+		return false, nil
+	}
+	dropped := false
+	for _, message := range messages {
+		if message == nil {
+			continue
+		}
+		if len(c.pause.held) >= c.pause.buffer {
+			c.pause.skipped++
+			dropped = true
+			continue
+		}
+		c.pause.held = append(c.pause.held, heldMessage{channelID: channelID, message: message, priority: priority, source: source})
+	}
+	if dropped && c.pause.errPause {
+		return true, ErrPaused
+	}
+	return true, nil
+}
@@ -0,0 +1,93 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPauseDropsMessages(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(2))
+	cache.Pause()
+	if err := cache.AddMessages("channel1", testHistory(5)[2:]); err != nil {
+		t.Errorf("Expected nil while paused, got %v", err)
+	}
+	if msgs, _ := cache.GetMessages("channel1"); len(msgs) != 2 {
+		t.Errorf("Expected reads to work and no new messages while paused, got %d", len(msgs))
+	}
+	if n := cache.PausedSkipped(); n != 3 {
+		t.Errorf("Expected 3 skipped messages, got %d", n)
+	}
+	cache.Resume()
+	cache.AddMessage("channel1", testHistory(6)[5])
+	if n, _ := cache.MessageCount("channel1"); n != 3 || cache.Paused() {
+		t.Errorf("Expected adds to resume, got %d messages (paused %v)", n, cache.Paused())
+	}
+
+	strict := NewMessageCache(10, WithPauseErrors())
+	strict.Pause()
+	if err := strict.AddMessage("channel1", testHistory(1)[0]); !errors.Is(err, ErrPaused) {
+		t.Errorf("Expected ErrPaused, got %v", err)
+	}
+}
+
+func TestPauseBuffering(t *testing.T) {
+	cache := NewMessageCache(10, PauseBuffering(4))
+	cache.AddMessages("channel1", testHistory(1))
+	cache.Pause()
+	history := testHistory(6)
+	cache.AddMessages("channel1", history[1:3])
+	cache.AddMessage("channel1", history[0]) // duplicate of a cached message
+	cache.AddMessageWithPriority("channel1", history[3], 5)
+	cache.AddMessages("channel1", history[4:]) // 105 exceeds the buffer
+	cache.Resume()
+
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[100 101 102 103]" {
+		t.Errorf("Expected held messages replayed in order and deduped, got %v", got)
+	}
+	if n := cache.PausedSkipped(); n != 2 {
+		t.Errorf("Expected 2 messages over the buffer to be skipped, got %d", n)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestPauseConcurrent(t *testing.T) {
+	const writers, perWriter = 8, 200
+	cache := NewMessageCache(writers*perWriter, PauseBuffering(writers*perWriter))
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprintf("%d-%d", w, i)})
+			}
+		}(w)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			cache.Pause()
+			cache.GetMessages("channel1")
+			cache.Resume()
+		}
+	}()
+	wg.Wait()
+	<-done
+	cache.Resume()
+
+	if n, _ := cache.MessageCount("channel1"); n != writers*perWriter {
+		t.Errorf("Expected no messages lost across pauses, got %d of %d", n, writers*perWriter)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package dgocacheler
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PrependMessages stores history older than a channel's cached messages at the old end of the channel, for
+// example pages fetched by BackfillFrom, keeping the channel in chronological order. The messages may come in
+// any order and are sorted by ID. Being older than everything cached, they only fill free room and never evict
+// newer messages: when the channel is too full for all of them, the oldest are dropped and counted as Filtered,
+// and in strict capacity mode it also returns ErrChannelFull. Messages not older than the oldest cached message
+// are added as AddMessages would. Messages are filtered and deduplicated like adds but not held while ingestion
+// is paused. The per-author cap keeps an author's newest messages, so history over it is dropped and counted as
+// Filtered rather than evicting cached messages, and history similar to the author's recent cached messages is
+// counted as Filtered and reported with ErrSuppressedDuplicate. Tail consumers are not notified of prepended
+// history. It returns ErrInvalidChannel for an empty channel ID and ErrChannelSealed for sealed channels.
+func (c *MessageCache) PrependMessages(channelID string, messages []*discordgo.Message) (report BatchReport, err error) {
+	if end := c.traceAdd(context.Background(), "PrependMessages"); end != nil {
+		defer func() { end(err) }()
+	}
+	channelID, err = c.resolveChannel(channelID)
+	if err != nil {
+		return report, err
+	}
+	return c.prependBatch(channelID, messages, "PrependMessages")
+}
+
+// prependBatch implements PrependMessages without resolving the channel ID.
+func (c *MessageCache) prependBatch(channelID string, messages []*discordgo.Message, source string) (report BatchReport, err error) {
+	defer c.enforceGlobalCaps()
+	cc := c.lockChannelForAdd(channelID)
+	defer cc.Unlock()
+	if cc.sealed {
+		return report, ErrChannelSealed
+	}
+
+	call := addCall{source: source, logger: c.logger}
+	var older, newer []*discordgo.Message
+	for _, message := range messages {
+		if message != nil && cc.size > 0 && c.compareIDs(message.ID, cc.at(0).message.ID) >= 0 {
+			newer = append(newer, message)
+		} else {
+			older = append(older, message)
+		}
+	}
+	entries, err := c.prependEntries(cc, older, call, &report)
+	if room := max(cc.maxMessages-cc.size, 0); len(entries) > room {
+		report.Filtered += len(entries) - room
+		entries = entries[len(entries)-room:]
+		if c.strictCapacity {
+			err = ErrChannelFull
+		}
+	}
+	cc.prepend(entries)
+	for _, entry := range entries {
+		cc.horizon.push(entry.message.ID)
+	}
+	report.Added += len(entries)
+
+	if len(newer) > 0 {
+		newerReport, newerErr := c.addBatchLocked(cc, newer, source)
+		report.Added += newerReport.Added
+		report.Duplicates += newerReport.Duplicates
+		report.Filtered += newerReport.Filtered
+		report.Evicted += newerReport.Evicted
+		if newerErr != nil {
+			err = newerErr
+		}
+	}
+	return report, err
+}
+
+// prependEntries turns messages into entries for prepend, counting the messages it skips in report: filtered
+// like adds, deduplicated against the channel and each other, ingested, sorted by ID and capped per author. It
+// returns ErrSuppressedDuplicate when it skipped a message similar to a cached one. The caller must hold the
+// channel's write lock.
+func (c *MessageCache) prependEntries(cc *ChannelCache, messages []*discordgo.Message, call addCall, report *BatchReport) (_ []cachedMessage, err error) {
+	entries := make([]cachedMessage, 0, len(messages))
+	seen := make(map[string]struct{}, len(messages))
+	for _, message := range messages {
+		switch {
+		case message == nil:
+			report.NilSkipped++
+			continue
+		case c.skipFrozen(cc) || c.skipWebhook(message) || c.skipInteraction(message):
+			report.Filtered++
+			continue
+		}
+		if p, ok := c.attributeGuild(cc, message); ok && !p.admits(message, c.now()) {
+			report.Filtered++
+			continue
+		}
+		if containsKey(seen, message.ID) || c.isDuplicate(cc, message.ID, call) || c.ContainsGlobal(message.ID) {
+			report.Duplicates++
+			continue
+		}
+		seen[message.ID] = struct{}{}
+		message, sanitized := c.ingestLogged(message, call.logger)
+		if message == nil {
+			report.Filtered++
+			continue
+		}
+		if c.isSimilarDuplicate(cc, message) {
+			report.Filtered++
+			err = ErrSuppressedDuplicate
+			continue
+		}
+		entry := c.newEntry(message)
+		entry.size += sanitized
+		entries = append(entries, entry)
+	}
+	c.sortByID(entries)
+	kept := c.capAuthors(entries, cc.authorCounts)
+	report.Filtered += len(entries) - len(kept)
+	return kept, err
+}
+
+// prepend stores entries in chronological order before the oldest entry. The caller must make sure they fit
+// within maxMessages and hold the write lock.
+func (cc *ChannelCache) prepend(entries []cachedMessage) {
+	if len(entries) == 0 {
+		return
+	}
+	if need := cc.size + len(entries); need > len(cc.buffer) {
+		cc.resize(min(max(2*len(cc.buffer), channelInitialCapacity, need), cc.maxMessages))
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		cc.head = (cc.head - 1 + len(cc.buffer)) % len(cc.buffer)
+		cc.buffer[cc.head] = entries[i]
+		cc.size++
+		cc.account(entries[i], 1)
+		if cc.mirror != nil {
+			cc.mirror.add(cc.id, entries[i].message)
+		}
+		if cc.crossposts != nil {
+			cc.crossposts.add(cc, entries[i].message)
+		}
+	}
+	if cc.optimistic != nil {
+		cc.optimistic.load(cc.messages(), len(cc.buffer))
+	}
+	cc.ageTiers()
+}
@@ -0,0 +1,84 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPrependMessages(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithOptimisticReads()}} {
+		cache := NewMessageCache(8, opts...)
+		history := testHistory(10) // 100..109
+		cache.AddMessages("channel1", history[5:8])
+
+		batch := []*discordgo.Message{history[3], history[0], history[1], nil, history[2], history[4], history[6], history[3]}
+		report, err := cache.PrependMessages("channel1", batch)
+		if err != nil || report.Added != 5 || report.Duplicates != 2 || report.NilSkipped != 1 {
+			t.Errorf("Unexpected report %+v, %v", report, err)
+		}
+		msgs, _ := cache.GetMessages("channel1")
+		if got := fmt.Sprint(messageIDs(msgs)); got != "[100 101 102 103 104 105 106 107]" {
+			t.Errorf("Expected the history at the old end, got %v", got)
+		}
+
+		cache.RemoveMessage("channel1", "101")
+		report, _ = cache.PrependMessages("channel1", []*discordgo.Message{history[9], {ID: "98"}, {ID: "99"}})
+		if report.Added != 2 || report.Filtered != 1 {
+			t.Errorf("Expected the oldest message dropped and the newer one added, got %+v", report)
+		}
+		msgs, _ = cache.GetMessages("channel1")
+		if got := fmt.Sprint(messageIDs(msgs)); got != "[100 102 103 104 105 106 107 109]" {
+			t.Errorf("Unexpected messages %v", got)
+		}
+		if err := cache.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	cache := NewMessageCache(8)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.SealChannel("channel1")
+	if _, err := cache.PrependMessages("channel1", testHistory(1)); !errors.Is(err, ErrChannelSealed) {
+		t.Errorf("Expected ErrChannelSealed, got %v", err)
+	}
+}
+
+func TestPrependMessagesAdmission(t *testing.T) {
+	message := func(id, author, content string) *discordgo.Message {
+		return &discordgo.Message{ID: id, Author: &discordgo.User{ID: author}, Content: content}
+	}
+
+	cache := NewMessageCache(10, WithPerAuthorCap(2))
+	cache.AddMessage("channel1", message("110", "a", ""))
+	report, err := cache.PrependMessages("channel1", []*discordgo.Message{
+		message("101", "a", ""), message("102", "a", ""), message("103", "a", ""), message("104", "b", ""),
+	})
+	msgs, _ := cache.GetMessages("channel1")
+	if err != nil || report.Added != 2 || report.Filtered != 2 || fmt.Sprint(messageIDs(msgs)) != "[103 104 110]" {
+		t.Errorf("Expected the author's oldest history dropped, got %v, %+v, %v", messageIDs(msgs), report, err)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	cache = NewMessageCache(10)
+	cache.SetSimilarityDedup(0.8)
+	cache.AddMessage("channel1", message("110", "a", "hello world"))
+	report, err = cache.PrependMessages("channel1", []*discordgo.Message{
+		message("101", "a", "hello world!"), message("102", "a", "something else"),
+	})
+	if !errors.Is(err, ErrSuppressedDuplicate) || report.Added != 1 || report.Filtered != 1 {
+		t.Errorf("Expected similar history suppressed, got %+v, %v", report, err)
+	}
+
+	cache = NewMessageCache(3, WithStrictCapacity())
+	cache.AddMessages("channel1", []*discordgo.Message{{ID: "110"}, {ID: "111"}})
+	report, err = cache.PrependMessages("channel1", []*discordgo.Message{{ID: "101"}, {ID: "102"}})
+	msgs, _ = cache.GetMessages("channel1")
+	if !errors.Is(err, ErrChannelFull) || report.Added != 1 || report.Filtered != 1 || fmt.Sprint(messageIDs(msgs)) != "[102 110 111]" {
+		t.Errorf("Expected ErrChannelFull once the room is filled, got %v, %+v, %v", messageIDs(msgs), report, err)
+	}
+}
@@ -0,0 +1,57 @@
+package dgocacheler
+
+// prewarmCapacity bounds how many messages PrewarmChannels sizes each channel for, so that a very large
+// maxMessages does not allocate huge buffers up front.
+const prewarmCapacity = 1024
+
+// PrewarmChannels creates empty channel caches for the listed channels with their buffers and ID maps sized for
+// up to maxMessages (bounded by 1024) messages, moving that allocation out of the hot event path at startup.
+// Channels that already exist are left untouched.
+func (c *MessageCache) PrewarmChannels(channelIDs []string) {
+	var evicted []string
+	c.lockGlobal()
+	for _, channelID := range channelIDs {
+		if _, ok := c.messages[channelID]; ok {
+			continue
+		}
+		_, victims := c.createChannelLocked(channelID, c.maxMessages, prewarmCapacity)
+		evicted = append(evicted, victims...)
+	}
+	c.Unlock()
+	c.channelsEvicted(evicted)
+}
+
+// WithChannels registers channels up front with their own capacities, mapping channel IDs to the maximum number
+// of messages kept, as SetChannelMaxMessages would. The channels are created eagerly when the cache is
+// constructed, pre-sized like PrewarmChannels, so they never run with the cache-wide default. Channels given a
+// capacity that is not positive get the cache-wide default instead. Other channels are created on first use as
+// usual.
+func WithChannels(channels map[string]int) Option {
+	return func(c *MessageCache) {
+		if c.initialChannels == nil {
+			c.initialChannels = make(map[string]int, len(channels))
+		}
+		for channelID, maxMessages := range channels {
+			c.initialChannels[channelID] = maxMessages
+		}
+	}
+}
+
+// registerChannels creates the channels set by WithChannels. It runs once all options are applied, so that the
+// channels are configured like any other.
+func (c *MessageCache) registerChannels() {
+	var evicted []string
+	c.lockGlobal()
+	for channelID, maxMessages := range c.initialChannels {
+		custom := maxMessages > 0
+		if !custom {
+			maxMessages = c.maxMessages
+		}
+		cc, victims := c.createChannelLocked(channelID, maxMessages, prewarmCapacity)
+		cc.customMax = custom
+		evicted = append(evicted, victims...)
+	}
+	c.initialChannels = nil
+	c.Unlock()
+	c.channelsEvicted(evicted)
+}
@@ -0,0 +1,138 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPrewarmChannels(t *testing.T) {
+	cache := NewMessageCache(50)
+	cache.AddMessages("existing", testHistory(3))
+	existing := cache.messages["existing"]
+
+	cache.PrewarmChannels([]string{"existing", "channel1", "channel2"})
+
+	if cache.messages["existing"] != existing {
+		t.Error("Existing channels must be left untouched.")
+	}
+	if msgs, _ := cache.GetMessages("existing"); len(msgs) != 3 {
+		t.Errorf("Existing channel lost messages, got %d", len(msgs))
+	}
+	for _, channelID := range []string{"channel1", "channel2"} {
+		cc, ok := cache.messages[channelID]
+		if !ok {
+			t.Fatalf("Expected %s to be created", channelID)
+		}
+		if len(cc.buffer) != 50 || cc.size != 0 {
+			t.Errorf("Expected an empty, pre-sized buffer for %s, got capacity %d and size %d", channelID, len(cc.buffer), cc.size)
+		}
+	}
+
+	// Prewarmed channels behave like any other, including wrap-around.
+	cache.AddMessages("channel1", testHistory(60))
+	if msgs, _ := cache.GetMessages("channel1"); len(msgs) != 50 || msgs[0].ID != "110" {
+		t.Errorf("Unexpected messages in a prewarmed channel: %d starting at %s", len(msgs), msgs[0].ID)
+	}
+}
+
+func TestPrewarmChannelsBoundsCapacity(t *testing.T) {
+	cache := NewMessageCache(10_000_000)
+	cache.PrewarmChannels([]string{"channel1"})
+	if n := len(cache.messages["channel1"].buffer); n != prewarmCapacity {
+		t.Errorf("Expected the prewarmed buffer to be capped at %d, got %d", prewarmCapacity, n)
+	}
+}
+
+func TestAddMessageSkipsDuplicateIDs(t *testing.T) {
+	cache := NewMessageCache(3)
+	cache.AddMessages("channel1", []*discordgo.Message{{ID: "1", Content: "first"}, {ID: "2"}, {ID: "1", Content: "again"}})
+	msgs, _ := cache.GetMessages("channel1")
+	if fmt.Sprint(messageIDs(msgs)) != "[1 2]" || msgs[0].Content != "first" {
+		t.Errorf("Expected the duplicate to be skipped, got %v", messageIDs(msgs))
+	}
+
+	// Once evicted, an ID can be cached again.
+	cache.AddMessages("channel1", []*discordgo.Message{{ID: "3"}, {ID: "4"}, {ID: "1"}})
+	if msgs, _ := cache.GetMessages("channel1"); fmt.Sprint(messageIDs(msgs)) != "[3 4 1]" {
+		t.Errorf("Expected an evicted ID to be accepted again, got %v", messageIDs(msgs))
+	}
+}
+
+func BenchmarkFirstMessage(b *testing.B) {
+	const channels = 1000
+	ids := make([]string, channels)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("channel%d", i)
+	}
+	msg := &discordgo.Message{ID: "1"}
+	for _, prewarm := range []bool{false, true} {
+		b.Run(fmt.Sprintf("prewarm=%v", prewarm), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				cache := NewMessageCache(100)
+				if prewarm {
+					cache.PrewarmChannels(ids)
+				}
+				b.StartTimer()
+				for _, channelID := range ids {
+					cache.AddMessage(channelID, msg)
+				}
+			}
+		})
+	}
+}
+
+func TestWithChannels(t *testing.T) {
+	cache := NewMessageCache(10, WithChannels(map[string]int{"small": 3, "large": 50, "default": 0}))
+	for channelID, want := range map[string]int{"small": 3, "large": 50, "default": 10} {
+		stats, err := cache.ChannelStats(channelID)
+		if err != nil {
+			t.Fatalf("Expected %s to be created eagerly, got %v", channelID, err)
+		}
+		if stats.MaxMessages != want {
+			t.Errorf("Expected %s to hold %d messages, got %d", channelID, want, stats.MaxMessages)
+		}
+	}
+	if len(cache.messages["large"].buffer) != 50 {
+		t.Errorf("Expected a pre-sized buffer, got capacity %d", len(cache.messages["large"].buffer))
+	}
+
+	cache.AddMessages("small", testHistory(5))
+	cache.AddMessages("other", testHistory(15))
+	if n, _ := cache.MessageCount("small"); n != 3 {
+		t.Errorf("Expected the registered capacity to apply, got %d messages", n)
+	}
+	if n, _ := cache.MessageCount("other"); n != 10 {
+		t.Errorf("Expected unregistered channels to use the default, got %d messages", n)
+	}
+
+	cache.SetMaxMessages(20)
+	for channelID, want := range map[string]int{"small": 3, "large": 50, "default": 20, "other": 20} {
+		if stats, _ := cache.ChannelStats(channelID); stats.MaxMessages != want {
+			t.Errorf("SetMaxMessages: expected %s to hold %d messages, got %d", channelID, want, stats.MaxMessages)
+		}
+	}
+	cache.ResetMaxMessages(5)
+	for _, channelID := range []string{"small", "large", "default", "other"} {
+		if stats, _ := cache.ChannelStats(channelID); stats.MaxMessages != 5 {
+			t.Errorf("ResetMaxMessages: expected %s to hold 5 messages, got %d", channelID, stats.MaxMessages)
+		}
+	}
+	cache.SetMaxMessages(8)
+	if stats, _ := cache.ChannelStats("small"); stats.MaxMessages != 8 {
+		t.Errorf("Expected a reset channel to follow the cache-wide limit, got %d", stats.MaxMessages)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestWithChannelsUsesOtherOptions(t *testing.T) {
+	cache := NewMessageCache(10, WithChannels(map[string]int{"channel1": 5}), WithoutDedup())
+	if cache.messages["channel1"].messageIDs != nil {
+		t.Error("Expected registered channels to be configured by options given after WithChannels.")
+	}
+}
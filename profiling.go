@@ -0,0 +1,34 @@
+package dgocacheler
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// pprofOpLabel is the pprof label key naming the cache operation.
+const pprofOpLabel = "dgocacheler_op"
+
+// pprofChannelLabel is the pprof label key naming the channel an operation works on.
+const pprofChannelLabel = "channel"
+
+// WithPprofLabels makes the heavier cache operations run under pprof labels, so their CPU time is attributed
+// to the operation (and channel) in profiles rather than smeared across the calling goroutines.
+func WithPprofLabels() Option {
+	return func(c *MessageCache) {
+		c.pprofLabels = true
+	}
+}
+
+// profileOp runs fn with pprof labels identifying the operation and channel when WithPprofLabels is set,
+// and calls it directly otherwise. An empty channelID omits the channel label.
+func (c *MessageCache) profileOp(ctx context.Context, op, channelID string, fn func(context.Context)) {
+	if !c.pprofLabels {
+		fn(ctx)
+		return
+	}
+	labels := []string{pprofOpLabel, op}
+	if channelID != "" {
+		labels = append(labels, pprofChannelLabel, channelID)
+	}
+	pprof.Do(ctx, pprof.Labels(labels...), fn)
+}
@@ -0,0 +1,129 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// goroutineProfile captures the goroutine profile in its labelled text form.
+func goroutineProfile(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		t.Fatalf("Failed to write goroutine profile: %v", err)
+	}
+	return buf.String()
+}
+
+func TestPprofLabelsAppearInProfile(t *testing.T) {
+	var profile string
+	loader := func(ctx context.Context, channelID string, limit int) ([]*discordgo.Message, error) {
+		profile = goroutineProfile(t)
+		return nil, nil
+	}
+	cache := NewMessageCache(10, WithLoader(loader), WithPprofLabels())
+	if _, err := cache.LatestOrFetch("channel1", 5); err != nil {
+		t.Fatalf("LatestOrFetch returned error: %v", err)
+	}
+	if !strings.Contains(profile, `"dgocacheler_op":"fetch"`) || !strings.Contains(profile, `"channel":"channel1"`) {
+		t.Errorf("Expected fetch labels in the goroutine profile, got:\n%s", profile)
+	}
+}
+
+func TestPprofLabelsDisabled(t *testing.T) {
+	var labelled bool
+	loader := func(ctx context.Context, channelID string, limit int) ([]*discordgo.Message, error) {
+		_, labelled = pprof.Label(ctx, pprofOpLabel)
+		return nil, nil
+	}
+	cache := NewMessageCache(10, WithLoader(loader))
+	if _, err := cache.LatestOrFetch("channel1", 5); err != nil {
+		t.Fatalf("LatestOrFetch returned error: %v", err)
+	}
+	if labelled {
+		t.Error("Operations should not be labelled unless WithPprofLabels is set.")
+	}
+}
+
+func TestPprofLabelsResize(t *testing.T) {
+	var labels map[string]string
+	cache := NewMessageCache(10, WithPprofLabels())
+	cache.profileOp(context.Background(), "resize", "", func(ctx context.Context) {
+		labels = map[string]string{}
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+	})
+	if labels[pprofOpLabel] != "resize" {
+		t.Errorf("Expected resize label, got %v", labels)
+	}
+	if _, ok := labels[pprofChannelLabel]; ok {
+		t.Error("Channel label should be omitted for cache-wide operations.")
+	}
+
+	cache.SetMaxMessagesContext(context.Background(), 5)
+	if cache.maxMessages != 5 {
+		t.Errorf("SetMaxMessagesContext did not apply, got %d", cache.maxMessages)
+	}
+}
+
+// profilingWriter captures the goroutine profile on its first write.
+type profilingWriter struct {
+	t       *testing.T
+	profile string
+}
+
+func (w *profilingWriter) Write(p []byte) (int, error) {
+	if w.profile == "" {
+		w.profile = goroutineProfile(w.t)
+	}
+	return len(p), nil
+}
+
+func TestPprofLabelsExport(t *testing.T) {
+	cache := NewMessageCache(10, WithPprofLabels())
+	cache.AddMessages("channel1", testHistory(2))
+	w := &profilingWriter{t: t}
+	if _, err := cache.ExportAll(context.Background(), w, ExportOptions{}); err != nil {
+		t.Fatalf("ExportAll returned error: %v", err)
+	}
+	if !strings.Contains(w.profile, `"dgocacheler_op":"export"`) {
+		t.Errorf("Expected export labels in the goroutine profile, got:\n%s", w.profile)
+	}
+}
+
+func TestPprofLabelsBackfillAndSearch(t *testing.T) {
+	var profile string
+	f := &fakeFetcher{history: map[string]int{"channel1": 5}, fetch: func(string) {
+		if profile == "" {
+			profile = goroutineProfile(t)
+		}
+	}}
+	cache := NewMessageCache(10, WithPprofLabels())
+	if result := cache.backfill(context.Background(), f, "channel1", 5, BackfillOptions{}); result.Err != nil {
+		t.Fatalf("Backfill returned error: %v", result.Err)
+	}
+	if !strings.Contains(profile, `"dgocacheler_op":"backfill"`) || !strings.Contains(profile, `"channel":"channel1"`) {
+		t.Errorf("Expected backfill labels in the goroutine profile, got:\n%s", profile)
+	}
+
+	profile = ""
+	q := cache.Query("channel1").where(func(*cachedMessage) bool {
+		if profile == "" {
+			profile = goroutineProfile(t)
+		}
+		return true
+	})
+	if msgs, err := q.RunContext(context.Background()); err != nil || len(msgs) != 5 {
+		t.Fatalf("Expected 5 messages, got %d (err %v)", len(msgs), err)
+	}
+	if !strings.Contains(profile, `"dgocacheler_op":"search"`) || !strings.Contains(profile, `"channel":"channel1"`) {
+		t.Errorf("Expected search labels in the goroutine profile, got:\n%s", profile)
+	}
+}
@@ -0,0 +1,140 @@
+package dgocacheler
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Query builds a filtered read of one channel. Create one with MessageCache.Query, chain filters, then call Run.
+type Query struct {
+	c         *MessageCache
+	channelID string
+	filters   []func(*cachedMessage) bool
+	limit     int
+	err       error
+}
+
+// Query starts a filtered read of a channel.
+func (c *MessageCache) Query(channelID string) *Query {
+	return &Query{c: c, channelID: channelID}
+}
+
+// Author keeps messages by the given author key, as reported by AuthorCounts.
+func (q *Query) Author(authorID string) *Query {
+	return q.where(func(entry *cachedMessage) bool {
+		return q.c.authorKey(entry.message) == authorID
+	})
+}
+
+// Types keeps messages whose Type is one of types.
+func (q *Query) Types(types ...discordgo.MessageType) *Query {
+	return q.where(func(entry *cachedMessage) bool {
+		return slices.Contains(types, entry.message.Type)
+	})
+}
+
+// Content keeps messages whose content is exactly text. With WithContentHashing, text is hashed and compared
+// with the stored digests.
+func (q *Query) Content(text string) *Query {
+	stored := q.c.storedContent(text)
+	return q.where(func(entry *cachedMessage) bool {
+		return entry.message.Content == stored
+	})
+}
+
+// ContentContains keeps messages whose content contains substr. It is not supported with WithContentHashing.
+func (q *Query) ContentContains(substr string) *Query {
+	if q.c.contentHashing {
+		q.err = ErrUnsupported
+	}
+	return q.where(func(entry *cachedMessage) bool {
+		return strings.Contains(entry.message.Content, substr)
+	})
+}
+
+// InteractionsOnly keeps interaction responses. It requires WithTagInteractions.
+func (q *Query) InteractionsOnly() *Query {
+	q.requireInteractionTags()
+	return q.where(func(entry *cachedMessage) bool {
+		return entry.interaction
+	})
+}
+
+// ExcludeInteractions drops interaction responses. It requires WithTagInteractions.
+func (q *Query) ExcludeInteractions() *Query {
+	q.requireInteractionTags()
+	return q.where(func(entry *cachedMessage) bool {
+		return !entry.interaction
+	})
+}
+
+// Limit keeps only the newest n matching messages. It must be positive.
+func (q *Query) Limit(n int) *Query {
+	if n <= 0 {
+		q.err = ErrInvalidLimit
+	}
+	q.limit = n
+	return q
+}
+
+// Run executes the query and returns the matching messages oldest first. It returns ErrCacheMiss for unknown
+// channels, or the first error recorded while building the query.
+func (q *Query) Run() ([]*discordgo.Message, error) {
+	return q.RunContext(context.Background())
+}
+
+// RunContext is like Run but runs under the pprof labels of ctx, extended with the search labels when
+// WithPprofLabels is set.
+func (q *Query) RunContext(ctx context.Context) (msgs []*discordgo.Message, err error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	q.c.profileOp(ctx, "search", q.channelID, func(context.Context) {
+		msgs, err = q.run()
+	})
+	return msgs, err
+}
+
+// run implements RunContext.
+func (q *Query) run() ([]*discordgo.Message, error) {
+	cc, ok := q.c.channelCache(q.channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	q.c.rlockChannel(cc)
+	defer cc.RUnlock()
+	msgs := []*discordgo.Message{}
+	for i := cc.size - 1; i >= 0 && (q.limit == 0 || len(msgs) < q.limit); i-- {
+		if entry := cc.at(i); q.matches(entry) {
+			msgs = append(msgs, entry.message)
+		}
+	}
+	slices.Reverse(msgs)
+	return msgs, nil
+}
+
+// where adds a filter to the query.
+func (q *Query) where(filter func(*cachedMessage) bool) *Query {
+	q.filters = append(q.filters, filter)
+	return q
+}
+
+// matches reports whether an entry passes every filter.
+func (q *Query) matches(entry *cachedMessage) bool {
+	for _, filter := range q.filters {
+		if !filter(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// requireInteractionTags records ErrUnsupported when interaction tagging is disabled.
+func (q *Query) requireInteractionTags() {
+	if !q.c.tagInteractions {
+		q.err = ErrUnsupported
+	}
+}
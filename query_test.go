@@ -0,0 +1,44 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestQuery(t *testing.T) {
+	cache := NewMessageCache(10)
+	reply := authoredMessage("3", "alice", "reply")
+	reply.Type = discordgo.MessageTypeReply
+	cache.AddMessages("channel1", []*discordgo.Message{
+		authoredMessage("1", "alice", "a"),
+		authoredMessage("2", "bob", "b"),
+		reply,
+		authoredMessage("4", "alice", "c"),
+	})
+
+	for _, tc := range []struct {
+		query *Query
+		want  string
+	}{
+		{cache.Query("channel1"), "[1 2 3 4]"},
+		{cache.Query("channel1").Author("alice"), "[1 3 4]"},
+		{cache.Query("channel1").Author("alice").Limit(2), "[3 4]"},
+		{cache.Query("channel1").Author("alice").Types(discordgo.MessageTypeReply), "[3]"},
+		{cache.Query("channel1").Types(discordgo.MessageTypeDefault).Limit(1), "[4]"},
+	} {
+		msgs, err := tc.query.Run()
+		if err != nil || fmt.Sprint(messageIDs(msgs)) != tc.want {
+			t.Errorf("Got %v (err %v), want %s", messageIDs(msgs), err, tc.want)
+		}
+	}
+
+	if _, err := cache.Query("channel1").Limit(0).Run(); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+	if _, err := cache.Query("missing").Run(); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package dgocacheler
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateBudget is a token bucket shared by concurrent callers to cap their combined request rate, for example
+// every backfill of a warm-up. Tokens refill at a fixed rate up to a burst size, and each Wait takes one.
+// A nil *RateBudget imposes no limit.
+type RateBudget struct {
+	mu     sync.Mutex
+	rate   float64                                          // rate is the number of tokens added per second
+	burst  float64                                          // burst is the most tokens the bucket holds
+	tokens float64                                          // tokens is the balance as of last, negative while callers wait
+	last   time.Time                                        // last is when tokens was computed
+	clock  func() time.Time                                 // clock returns the current time
+	sleep  func(ctx context.Context, d time.Duration) error // sleep waits for d or until ctx is done
+}
+
+// NewRateBudget returns a budget allowing perSecond requests per second on average and up to burst at once.
+// The bucket starts full. A burst below 1 is treated as 1. It returns nil, imposing no limit, if perSecond is
+// not positive.
+func NewRateBudget(perSecond float64, burst int) *RateBudget {
+	if perSecond <= 0 || math.IsNaN(perSecond) {
+		return nil
+	}
+	b := &RateBudget{rate: perSecond, burst: float64(max(burst, 1)), clock: time.Now, sleep: sleepContext}
+	b.tokens = b.burst
+	return b
+}
+
+// Wait takes a token, blocking until one is available. It returns ctx.Err() without taking a token if ctx is
+// done first.
+func (b *RateBudget) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil || b == nil {
+		return err
+	}
+	if err := b.sleep(ctx, b.reserve()); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
+
+// reserve takes a token and returns how long to wait until it is covered.
+func (b *RateBudget) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a token taken by an abandoned Wait.
+func (b *RateBudget) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens = min(b.tokens+1, b.burst)
+}
+
+// refill adds the tokens accrued since the last update. The caller must hold b.mu.
+func (b *RateBudget) refill() {
+	now := b.clock()
+	if elapsed := now.Sub(b.last); !b.last.IsZero() && elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*b.rate, b.burst)
+	}
+	b.last = now
+}
+
+// sleepContext waits for d, returning ctx.Err() if ctx is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
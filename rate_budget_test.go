@@ -0,0 +1,89 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeBudget returns a budget on a fake clock whose waits advance the clock and are recorded in slept.
+func fakeBudget(perSecond float64, burst int, slept *[]time.Duration) (*RateBudget, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	b := NewRateBudget(perSecond, burst)
+	b.clock = clock.Now
+	b.sleep = func(ctx context.Context, d time.Duration) error {
+		if d > 0 {
+			*slept = append(*slept, d)
+			clock.Advance(d)
+		}
+		return ctx.Err()
+	}
+	return b, clock
+}
+
+func TestRateBudgetPacing(t *testing.T) {
+	var slept []time.Duration
+	b, clock := fakeBudget(10, 3, &slept)
+	for range 5 {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait returned error: %v", err)
+		}
+	}
+	if fmt.Sprint(slept) != "[100ms 100ms]" {
+		t.Errorf("Expected the burst to pass and then 100ms per token, got %v", slept)
+	}
+
+	slept = nil
+	clock.Advance(time.Hour)
+	for range 4 {
+		b.Wait(context.Background())
+	}
+	if fmt.Sprint(slept) != "[100ms]" {
+		t.Errorf("Expected idle time to refill only up to the burst, got %v", slept)
+	}
+
+	slept = nil
+	clock.Advance(50 * time.Millisecond)
+	b.Wait(context.Background())
+	if fmt.Sprint(slept) != "[50ms]" {
+		t.Errorf("Expected partial refills to shorten the wait, got %v", slept)
+	}
+}
+
+func TestRateBudgetCancel(t *testing.T) {
+	var slept []time.Duration
+	b, _ := fakeBudget(1, 1, &slept)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if err := b.Wait(context.Background()); err != nil || len(slept) != 0 {
+		t.Errorf("Expected a cancelled Wait to take no token, got %v after waiting %v", err, slept)
+	}
+
+	b = NewRateBudget(1, 1)
+	b.Wait(context.Background())
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Wait to return when ctx is done, took %v", elapsed)
+	}
+	if b.tokens < -0.01 {
+		t.Errorf("Expected the abandoned token to be returned, got a balance of %v", b.tokens)
+	}
+
+	if NewRateBudget(0, 1) != nil {
+		t.Error("Expected no budget for a non-positive rate.")
+	}
+	var unlimited *RateBudget
+	if err := unlimited.Wait(context.Background()); err != nil {
+		t.Errorf("Expected a nil budget not to limit, got %v", err)
+	}
+}
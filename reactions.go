@@ -0,0 +1,67 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TopReactedMessages returns up to n messages of a channel with the most reactions in total, most reacted first.
+// Ties are broken newest first; messages without reactions count as zero. It returns ErrCacheMiss for unknown
+// channels and ErrInvalidLimit if n is not positive.
+func (c *MessageCache) TopReactedMessages(channelID string, n int) ([]*discordgo.Message, error) {
+	if n <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	msgs, err := c.filterMessages(channelID, func(*discordgo.Message) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	// Newest first, then a stable sort by reaction count keeps newer messages ahead on ties.
+	slices.Reverse(msgs)
+	slices.SortStableFunc(msgs, func(a, b *discordgo.Message) int {
+		return cmp.Compare(reactionCount(b), reactionCount(a))
+	})
+	return msgs[:min(n, len(msgs))], nil
+}
+
+// ReactionTotals sums reaction counts per emoji across a channel. Unicode emoji are keyed by name and custom
+// (guild) emoji by ID, since a custom emoji's name is neither unique nor stable. It returns ErrCacheMiss for
+// unknown channels.
+func (c *MessageCache) ReactionTotals(channelID string) (map[string]int, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	totals := make(map[string]int)
+	for i := 0; i < cc.size; i++ {
+		for _, reaction := range cc.at(i).message.Reactions {
+			if reaction != nil && reaction.Emoji != nil {
+				totals[emojiKey(reaction.Emoji)] += reaction.Count
+			}
+		}
+	}
+	return totals, nil
+}
+
+// reactionCount returns the total number of reactions on a message.
+func reactionCount(msg *discordgo.Message) int {
+	total := 0
+	for _, reaction := range msg.Reactions {
+		if reaction != nil {
+			total += reaction.Count
+		}
+	}
+	return total
+}
+
+// emojiKey identifies an emoji: its ID for custom emoji, its name for unicode emoji.
+func emojiKey(emoji *discordgo.Emoji) string {
+	if emoji.ID != "" {
+		return emoji.ID
+	}
+	return emoji.Name
+}
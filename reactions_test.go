@@ -0,0 +1,72 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func reactedMessage(id string, reactions ...*discordgo.MessageReactions) *discordgo.Message {
+	return &discordgo.Message{ID: id, Reactions: reactions}
+}
+
+func reaction(emoji *discordgo.Emoji, count int) *discordgo.MessageReactions {
+	return &discordgo.MessageReactions{Emoji: emoji, Count: count}
+}
+
+var (
+	thumbsUp  = &discordgo.Emoji{Name: "👍"}
+	partyBlob = &discordgo.Emoji{ID: "111", Name: "party"}
+	// renamedBlob is a different custom emoji that happens to share the name of partyBlob.
+	renamedBlob = &discordgo.Emoji{ID: "222", Name: "party"}
+)
+
+func TestTopReactedMessages(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", []*discordgo.Message{
+		reactedMessage("1", reaction(thumbsUp, 3)),
+		reactedMessage("2"),
+		reactedMessage("3", reaction(thumbsUp, 1), reaction(partyBlob, 4)),
+		reactedMessage("4", reaction(partyBlob, 3)),
+		reactedMessage("5", reaction(renamedBlob, 1)),
+	})
+
+	msgs, err := cache.TopReactedMessages("channel1", 3)
+	if err != nil || fmt.Sprint(messageIDs(msgs)) != "[3 4 1]" {
+		t.Errorf("Unexpected top messages %v (err %v)", messageIDs(msgs), err)
+	}
+	msgs, _ = cache.TopReactedMessages("channel1", 10)
+	if fmt.Sprint(messageIDs(msgs)) != "[3 4 1 5 2]" {
+		t.Errorf("Expected all messages with ties broken newest first, got %v", messageIDs(msgs))
+	}
+
+	if _, err := cache.TopReactedMessages("channel1", 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+	if _, err := cache.TopReactedMessages("missing", 1); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestReactionTotals(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", []*discordgo.Message{
+		reactedMessage("1", reaction(thumbsUp, 3)),
+		reactedMessage("2"),
+		reactedMessage("3", reaction(thumbsUp, 1), reaction(partyBlob, 4)),
+		reactedMessage("4", reaction(renamedBlob, 2)),
+	})
+
+	totals, err := cache.ReactionTotals("channel1")
+	if err != nil {
+		t.Fatalf("ReactionTotals returned error: %v", err)
+	}
+	if len(totals) != 3 || totals["👍"] != 4 || totals["111"] != 4 || totals["222"] != 2 {
+		t.Errorf("Unexpected totals: %v", totals)
+	}
+	if _, err := cache.ReactionTotals("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
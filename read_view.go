@@ -0,0 +1,30 @@
+package dgocacheler
+
+import "github.com/bwmarrin/discordgo"
+
+// channelView is an immutable copy of a channel's messages, valid while the channel generation is unchanged.
+type channelView struct {
+	gen  uint64
+	msgs []*discordgo.Message
+}
+
+// bumpGeneration marks the channel contents as changed, invalidating its cached view. The caller must hold the
+// write lock.
+func (cc *ChannelCache) bumpGeneration() {
+	cc.generation.Add(1)
+}
+
+// messagesView returns the channel's messages in chronological order. Repeated reads of an unchanged channel
+// share one immutable slice and take no lock: the view is used when it was built for the current generation,
+// and rebuilt under the read lock otherwise.
+func (c *MessageCache) messagesView(cc *ChannelCache) []*discordgo.Message {
+	if view := cc.view.Load(); view != nil && view.gen == cc.generation.Load() {
+		return view.msgs
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	msgs := cc.messages()
+	msgs = msgs[:len(msgs):len(msgs)]
+	cc.view.Store(&channelView{gen: cc.generation.Load(), msgs: msgs})
+	return msgs
+}
@@ -0,0 +1,92 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGetMessagesView(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(3))
+
+	first, _ := cache.GetMessages("channel1")
+	second, _ := cache.GetMessages("channel1")
+	if &first[0] != &second[0] {
+		t.Error("Expected reads of an unchanged channel to share the view.")
+	}
+	if cap(first) != len(first) {
+		t.Errorf("Expected the view capacity to be capped, got cap %d for len %d", cap(first), len(first))
+	}
+
+	cache.AddMessage("channel1", &discordgo.Message{ID: "103"})
+	third, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(third)); got != "[100 101 102 103]" {
+		t.Errorf("Expected a write to invalidate the view, got %v", got)
+	}
+	if got := fmt.Sprint(messageIDs(first)); got != "[100 101 102]" {
+		t.Errorf("Expected earlier views to stay unchanged, got %v", got)
+	}
+}
+
+func TestGetMessagesViewConcurrent(t *testing.T) {
+	const writes = 2000
+	cache := NewMessageCache(50)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "0"})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= writes; i++ {
+			cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+			if i%10 == 0 {
+				cache.RemoveMessage("channel1", fmt.Sprint(i-5))
+			}
+		}
+	}()
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range writes {
+				msgs, _ := cache.GetMessages("channel1")
+				for i := 1; i < len(msgs); i++ {
+					if CompareSnowflakes(msgs[i-1].ID, msgs[i].ID) >= 0 {
+						t.Errorf("Inconsistent view: %v", messageIDs(msgs))
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	msgs, _ := cache.GetMessages("channel1")
+	if msgs[len(msgs)-1].ID != fmt.Sprint(writes) {
+		t.Errorf("Expected the final view to include the last write, got %v", msgs[len(msgs)-1].ID)
+	}
+}
+
+func BenchmarkGetMessagesView(b *testing.B) {
+	cache := NewMessageCache(100)
+	cache.AddMessages("channel1", testHistory(100))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cache.GetMessages("channel1")
+	}
+}
+
+func BenchmarkGetMessagesCopy(b *testing.B) {
+	cache := NewMessageCache(100)
+	cache.AddMessages("channel1", testHistory(100))
+	cc, _ := cache.channelCache("channel1")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cc.RLock()
+		cc.messages()
+		cc.RUnlock()
+	}
+}
@@ -0,0 +1,30 @@
+package dgocacheler
+
+// StaleIDs returns the IDs of the cached messages of a channel that are missing from currentIDs, oldest first.
+// Given the IDs Discord currently reports for a channel, these are the messages deleted remotely, which can
+// then be dropped with RemoveMessage. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) StaleIDs(channelID string, currentIDs []string) ([]string, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	current := make(map[string]struct{}, len(currentIDs))
+	for _, id := range currentIDs {
+		current[id] = struct{}{}
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	var stale []string
+	for i := 0; i < cc.size; i++ {
+		if id := cc.at(i).message.ID; !containsKey(current, id) {
+			stale = append(stale, id)
+		}
+	}
+	return stale, nil
+}
+
+// containsKey reports whether key is in set.
+func containsKey(set map[string]struct{}, key string) bool {
+	_, ok := set[key]
+	return ok
+}
@@ -0,0 +1,26 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStaleIDs(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(5))
+
+	stale, err := cache.StaleIDs("channel1", []string{"100", "102", "104", "999"})
+	if err != nil || fmt.Sprint(stale) != "[101 103]" {
+		t.Errorf("Expected [101 103], got %v (err %v)", stale, err)
+	}
+	if stale, _ := cache.StaleIDs("channel1", messageIDs(testHistory(5))); len(stale) != 0 {
+		t.Errorf("Expected no stale IDs, got %v", stale)
+	}
+	if stale, _ := cache.StaleIDs("channel1", nil); fmt.Sprint(stale) != "[100 101 102 103 104]" {
+		t.Errorf("Expected every ID to be stale, got %v", stale)
+	}
+	if _, err := cache.StaleIDs("missing", nil); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
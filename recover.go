@@ -0,0 +1,83 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// callbackErrorBuffer is the number of recovered panics CallbackErrors holds before dropping new ones.
+const callbackErrorBuffer = 16
+
+// CallbackPanicError describes a panic recovered from a user-supplied callback.
+type CallbackPanicError struct {
+	Callback string // Callback names the callback that panicked, such as "OnEvict" or "Loader"
+	Value    any    // Value is the value passed to panic
+	Stack    []byte // Stack is the goroutine stack at the time of the panic
+}
+
+// Error implements error.
+func (e *CallbackPanicError) Error() string {
+	return fmt.Sprintf("dgocacheler: %s callback panicked: %v", e.Callback, e.Value)
+}
+
+// WithLogger sets the logger the cache reports problems to, such as recovered callback panics.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *MessageCache) {
+		c.logger = logger
+	}
+}
+
+// SetRecoverCallbacks controls whether panics in user-supplied callbacks (OnEvict, OnChannelEvicted, the
+// Redactor, loaders and fetch functions, the spill handler, the ID comparator and the Tracer) are recovered.
+// A recovered panic is logged, passed to the WithErrorHandler handler, sent to CallbackErrors and counted in
+// Stats.CallbackPanics, and the cache operation carries on with consistent state: the eviction stays applied,
+// a message whose redaction panicked is not stored, a panicking loader makes the fetch fail with a
+// *CallbackPanicError and a panicking comparator leaves messages partially sorted. By default panics propagate
+// to the caller, unless WithErrorHandler is set.
+func (c *MessageCache) SetRecoverCallbacks(enabled bool) {
+	c.recoverCallbacks.Store(enabled)
+}
+
+// CallbackErrors returns a channel receiving a *CallbackPanicError for every recovered callback panic. It is
+// buffered; panics recovered while the buffer is full are only logged.
+func (c *MessageCache) CallbackErrors() <-chan error {
+	return c.callbackErrors
+}
+
+// guard runs a user-supplied callback. When callback recovery is enabled, a panic is recovered, reported and
+// returned as a *CallbackPanicError; otherwise it propagates.
+func (c *MessageCache) guard(callback string, fn func()) error {
+	return c.guardLogged(c.logger, callback, fn)
+}
+
+// guardLogged is guard reporting recovered panics to logger, which may be nil.
+func (c *MessageCache) guardLogged(logger *slog.Logger, callback string, fn func()) (err error) {
+	if !c.recoverCallbacks.Load() {
+		fn()
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr := &CallbackPanicError{Callback: callback, Value: r, Stack: debug.Stack()}
+			c.reportPanic(logger, panicErr)
+			err = panicErr
+		}
+	}()
+	fn()
+	return nil
+}
+
+// reportPanic counts a recovered panic, logs it to logger, which may be nil, passes it to the error handler and
+// offers it to CallbackErrors.
+func (c *MessageCache) reportPanic(logger *slog.Logger, err *CallbackPanicError) {
+	c.callbackPanics.Add(1)
+	c.reportError(err)
+	if logger != nil {
+		logger.Error("dgocacheler: recovered callback panic", "callback", err.Callback, "panic", err.Value, "stack", string(err.Stack))
+	}
+	select {
+	case c.callbackErrors <- err:
+	default:
+	}
+}
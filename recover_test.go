@@ -0,0 +1,170 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestRecoverCallbacksOnEvict(t *testing.T) {
+	var logs bytes.Buffer
+	cache := NewMessageCache(2, WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		OnEvict(func(string, *discordgo.Message, EvictReason) { panic("boom") }))
+	cache.SetRecoverCallbacks(true)
+
+	cache.AddMessages("channel1", testHistory(3))
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected the add to complete despite the panic, got %d messages", n)
+	}
+	select {
+	case err := <-cache.CallbackErrors():
+		var panicErr *CallbackPanicError
+		if !errors.As(err, &panicErr) || panicErr.Callback != "OnEvict" || panicErr.Value != "boom" || len(panicErr.Stack) == 0 {
+			t.Errorf("Unexpected callback error: %v", err)
+		}
+	default:
+		t.Error("Expected the recovered panic on CallbackErrors.")
+	}
+	if !strings.Contains(logs.String(), "recovered callback panic") {
+		t.Errorf("Expected the panic to be logged, got %q", logs.String())
+	}
+}
+
+func TestRecoverCallbacksRedactor(t *testing.T) {
+	cache := NewMessageCache(5, WithRedactor(func(content string) string {
+		if content == "bad" {
+			panic("redactor failed")
+		}
+		return content
+	}))
+	cache.SetRecoverCallbacks(true)
+
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1", ChannelID: "channel1", Content: "bad"})
+	cache.AddMessage("channel1", &discordgo.Message{ID: "2", ChannelID: "channel1", Content: "good"})
+	msgs, _ := cache.GetMessages("channel1")
+	if len(msgs) != 1 || msgs[0].ID != "2" {
+		t.Errorf("Expected only the message that redacted cleanly to be stored, got %v", messageIDs(msgs))
+	}
+}
+
+func TestRecoverCallbacksLoader(t *testing.T) {
+	cache := NewMessageCache(5, WithLoader(func(context.Context, string, int) ([]*discordgo.Message, error) {
+		panic("loader failed")
+	}))
+	cache.SetRecoverCallbacks(true)
+
+	var panicErr *CallbackPanicError
+	if _, err := cache.LatestOrFetch("channel1", 3); !errors.As(err, &panicErr) || panicErr.Callback != "Loader" {
+		t.Errorf("Expected a *CallbackPanicError from the loader, got %v", err)
+	}
+}
+
+func TestRecoverCallbacksDisabledPropagates(t *testing.T) {
+	cache := NewMessageCache(1, OnEvict(func(string, *discordgo.Message, EvictReason) { panic("boom") }))
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected the panic to propagate by default.")
+		}
+	}()
+	cache.AddMessages("channel1", testHistory(2))
+}
+
+// panickingTracer panics when starting spans.
+type panickingTracer struct{}
+
+func (panickingTracer) Start(context.Context, string) (context.Context, Span) { panic("tracer failed") }
+
+func TestErrorHandlerRecoversEveryCallback(t *testing.T) {
+	var handled []string
+	boom := func() { panic("boom") }
+	cache := NewMessageCache(2,
+		WithErrorHandler(func(err error) {
+			var panicErr *CallbackPanicError
+			if errors.As(err, &panicErr) && len(panicErr.Stack) > 0 {
+				handled = append(handled, panicErr.Callback)
+			}
+		}),
+		OnEvict(func(string, *discordgo.Message, EvictReason) { boom() }),
+		WithMaxChannels(2),
+		OnChannelEvicted(func(string) { boom() }),
+		WithRedactor(func(content string) string {
+			if content == "bad" {
+				boom()
+			}
+			return content
+		}),
+		WithIDComparator(func(a, b string) int {
+			if a == "bad" || b == "bad" {
+				boom()
+			}
+			return CompareSnowflakes(a, b)
+		}),
+		WithLoader(func(context.Context, string, int) ([]*discordgo.Message, error) { boom(); return nil, nil }),
+		WithTracer(panickingTracer{}),
+	)
+
+	cache.AddMessages("channel1", testHistory(3)) // OnEvict
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected evictions to stay applied, got %d messages", n)
+	}
+	cache.AddMessage("channel2", &discordgo.Message{ID: "1"})                      // fills the channel slots
+	cache.AddMessage("channel3", &discordgo.Message{ID: "1"})                      // OnChannelEvicted
+	cache.AddMessage("channel3", &discordgo.Message{ID: "2", Content: "bad"})      // Redactor
+	cache.ReplaceChannel("channel2", []*discordgo.Message{{ID: "bad"}, {ID: "3"}}) // IDComparator
+	if _, err := cache.LatestOrFetch("channel9", 5); err == nil {                  // Tracer, Loader
+		t.Error("Expected the panicking loader to fail the fetch.")
+	}
+	if _, err := cache.GetOrFetch(context.Background(), "channel9", 5, func(context.Context) ([]*discordgo.Message, error) {
+		boom()
+		return nil, nil
+	}); err == nil {
+		t.Error("Expected the panicking fetch to fail.")
+	}
+
+	want := map[string]bool{"OnEvict": true, "OnChannelEvicted": true, "Redactor": true, "IDComparator": true, "Loader": true, "GetOrFetch": true, "Tracer": true}
+	for _, callback := range handled {
+		delete(want, callback)
+	}
+	if len(want) != 0 {
+		t.Errorf("Expected panics from every callback to reach the error handler, missing %v (got %v)", want, handled)
+	}
+	if got := cache.Stats().CallbackPanics; got != uint64(len(handled)) {
+		t.Errorf("Expected %d callback panics in Stats, got %d", len(handled), got)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Expected consistent state after recovered panics, got %v", err)
+	}
+	if n, _ := cache.MessageCount("channel2"); n != 2 {
+		t.Errorf("Expected the replace to complete, got %d messages", n)
+	}
+	if err := cache.AddMessage("channel3", &discordgo.Message{ID: "4"}); err != nil {
+		t.Errorf("Expected later operations to work, got %v", err)
+	}
+}
+
+func TestErrorHandlerRecoversSpillHandler(t *testing.T) {
+	var panics atomic.Int32
+	cache := NewMessageCache(1,
+		WithErrorHandler(func(err error) {
+			var panicErr *CallbackPanicError
+			if errors.As(err, &panicErr) && panicErr.Callback == "SpillHandler" {
+				panics.Add(1)
+			}
+		}),
+		WithSpillHandler(func(string, []*discordgo.Message) error { panic("spill failed") }),
+	)
+	cache.AddMessages("channel1", testHistory(2))
+	cache.Close()
+	if panics.Load() != 1 {
+		t.Errorf("Expected the spill handler panic to be reported once, got %d", panics.Load())
+	}
+	if stats := cache.Stats(); stats.SpillFailed != 1 || stats.CallbackPanics != 1 {
+		t.Errorf("Unexpected stats after a spill panic: %+v", stats)
+	}
+}
@@ -0,0 +1,131 @@
+package dgocacheler
+
+import (
+	"log/slog"
+	"regexp"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Redactor rewrites text before it is stored, for example to remove personal data.
+type Redactor func(content string) string
+
+// WithRedactor applies redactor to message content, embed titles, descriptions and fields, and attachment
+// filenames whenever a message is stored or updated. Messages are cloned first, so the caller's message is
+// never modified, and everything downstream (duplicate detection, queries, exports) only sees redacted text.
+func WithRedactor(redactor Redactor) Option {
+	return func(c *MessageCache) {
+		c.redactor = redactor
+	}
+}
+
+// emailPattern matches email addresses for RedactEmails.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// RedactEmails is a reference Redactor that replaces email addresses with "[redacted email]".
+func RedactEmails(content string) string {
+	return emailPattern.ReplaceAllString(content, "[redacted email]")
+}
+
+// ingest prepares a message for storage, cloning it and applying the ingestion transformations when any are
+// configured. Without transformations the message is stored as given. It returns nil if a transformation
+// panicked and the panic was recovered, in which case the message must not be stored, and how many bytes
+// WithSanitizeContent removed, which still count toward the size of the entry.
+func (c *MessageCache) ingest(msg *discordgo.Message) (*discordgo.Message, int) {
+	return c.ingestLogged(msg, c.logger)
+}
+
+// ingestLogged is ingest reporting recovered panics to logger, which may be nil.
+func (c *MessageCache) ingestLogged(msg *discordgo.Message, logger *slog.Logger) (*discordgo.Message, int) {
+	if c.redactor == nil && c.anonymizeSalt == nil && !c.contentHashing && !c.sanitizeContent {
+		return msg, 0
+	}
+	msg = cloneMessage(msg)
+	sanitized := 0
+	if c.sanitizeContent {
+		sanitized = sanitizeMessage(msg)
+	}
+	if c.redactor != nil {
+		if err := c.guardLogged(logger, "Redactor", func() { redactMessage(msg, c.redactor) }); err != nil {
+			return nil, 0
+		}
+	}
+	if c.anonymizeSalt != nil {
+		anonymizeMessage(msg, c.anonymizeSalt)
+	}
+	if c.contentHashing {
+		hashContent(msg)
+	}
+	return msg, sanitized
+}
+
+// redactMessage applies redactor to every text field of msg that may carry user content.
+func redactMessage(msg *discordgo.Message, redactor Redactor) {
+	msg.Content = redactor(msg.Content)
+	for _, embed := range msg.Embeds {
+		if embed == nil {
+			continue
+		}
+		embed.Title = redactor(embed.Title)
+		embed.Description = redactor(embed.Description)
+		for _, field := range embed.Fields {
+			if field != nil {
+				field.Name = redactor(field.Name)
+				field.Value = redactor(field.Value)
+			}
+		}
+	}
+	for _, attachment := range msg.Attachments {
+		if attachment != nil {
+			attachment.Filename = redactor(attachment.Filename)
+		}
+	}
+}
+
+// cloneMessage copies a message deeply enough that the fields the cache may rewrite (content, embeds,
+// attachments, author, mentions) can be changed without affecting the original.
+func cloneMessage(msg *discordgo.Message) *discordgo.Message {
+	clone := *msg
+	if msg.Author != nil {
+		author := *msg.Author
+		clone.Author = &author
+	}
+	if msg.Mentions != nil {
+		clone.Mentions = make([]*discordgo.User, len(msg.Mentions))
+		for i, user := range msg.Mentions {
+			if user != nil {
+				mention := *user
+				clone.Mentions[i] = &mention
+			}
+		}
+	}
+	if msg.Embeds != nil {
+		clone.Embeds = make([]*discordgo.MessageEmbed, len(msg.Embeds))
+		for i, embed := range msg.Embeds {
+			if embed == nil {
+				continue
+			}
+			e := *embed
+			if embed.Fields != nil {
+				e.Fields = make([]*discordgo.MessageEmbedField, len(embed.Fields))
+				for j, field := range embed.Fields {
+					if field != nil {
+						f := *field
+						e.Fields[j] = &f
+					}
+				}
+			}
+			clone.Embeds[i] = &e
+		}
+	}
+	if msg.Attachments != nil {
+		clone.Attachments = make([]*discordgo.MessageAttachment, len(msg.Attachments))
+		for i, attachment := range msg.Attachments {
+			if attachment != nil {
+				a := *attachment
+				clone.Attachments[i] = &a
+			}
+		}
+	}
+	return &clone
+}
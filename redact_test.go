@@ -0,0 +1,65 @@
+package dgocacheler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestRedactEmails(t *testing.T) {
+	got := RedactEmails("mail me at jane.doe+bot@example.co.uk or admin@test.io")
+	if got != "mail me at [redacted email] or [redacted email]" {
+		t.Errorf("Unexpected redaction: %q", got)
+	}
+	if got := RedactEmails("no address here @ all"); got != "no address here @ all" {
+		t.Errorf("Text without addresses should be unchanged, got %q", got)
+	}
+}
+
+func TestWithRedactor(t *testing.T) {
+	cache := NewMessageCache(10, WithRedactor(RedactEmails))
+	original := &discordgo.Message{
+		ID:          "1",
+		Content:     "contact jane@example.com",
+		Embeds:      []*discordgo.MessageEmbed{{Description: "ops@example.com", Fields: []*discordgo.MessageEmbedField{{Name: "to", Value: "bob@example.com"}}}},
+		Attachments: []*discordgo.MessageAttachment{{Filename: "jane@example.com.txt"}},
+	}
+	cache.AddMessage("channel1", original)
+
+	// The caller's message is untouched.
+	if original.Content != "contact jane@example.com" || original.Embeds[0].Description != "ops@example.com" ||
+		original.Embeds[0].Fields[0].Value != "bob@example.com" || original.Attachments[0].Filename != "jane@example.com.txt" {
+		t.Errorf("The original message was modified: %+v", original)
+	}
+
+	msgs, _ := cache.GetMessages("channel1")
+	stored := msgs[0]
+	for _, text := range []string{stored.Content, stored.Embeds[0].Description, stored.Embeds[0].Fields[0].Value, stored.Attachments[0].Filename} {
+		if strings.Contains(text, "@example.com") {
+			t.Errorf("Stored text was not redacted: %q", text)
+		}
+	}
+
+	// Updates are redacted too.
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "1", Content: "now x@example.org"})
+	if msgs, _ := cache.GetMessages("channel1"); msgs[0].Content != "now [redacted email]" {
+		t.Errorf("Updated content was not redacted: %q", msgs[0].Content)
+	}
+}
+
+func TestCloneMessage(t *testing.T) {
+	original := &discordgo.Message{
+		ID:       "1",
+		Author:   &discordgo.User{ID: "2"},
+		Mentions: []*discordgo.User{{ID: "3"}},
+		Embeds:   []*discordgo.MessageEmbed{{Title: "t"}},
+	}
+	clone := cloneMessage(original)
+	clone.Author.ID = "x"
+	clone.Mentions[0].ID = "x"
+	clone.Embeds[0].Title = "x"
+	if original.Author.ID != "2" || original.Mentions[0].ID != "3" || original.Embeds[0].Title != "t" {
+		t.Error("Changing the clone modified the original.")
+	}
+}
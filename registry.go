@@ -0,0 +1,56 @@
+package dgocacheler
+
+import (
+	"slices"
+	"sync"
+)
+
+// registry holds the named caches shared across packages, alongside the global Cache.
+var registry = struct {
+	sync.RWMutex
+	caches map[string]*MessageCache
+}{caches: make(map[string]*MessageCache)}
+
+// NamedCache returns the cache registered under name, creating and registering it with maxMessages and opts
+// if it does not exist yet. Like the global Cache, named caches let several packages share a cache without
+// importing each other.
+func NamedCache(name string, maxMessages int, opts ...Option) *MessageCache {
+	registry.Lock()
+	defer registry.Unlock()
+	if c, ok := registry.caches[name]; ok {
+		return c
+	}
+	c := NewMessageCache(maxMessages, opts...)
+	registry.caches[name] = c
+	return c
+}
+
+// UnregisterCache removes the cache registered under name. The cache itself keeps working for existing holders.
+func UnregisterCache(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.caches, name)
+}
+
+// AllCacheNames returns the names of all registered caches in sorted order.
+func AllCacheNames() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.caches))
+	for name := range registry.caches {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TotalCountAllCaches returns the number of messages held by all registered caches combined.
+func TotalCountAllCaches() int {
+	registry.RLock()
+	defer registry.RUnlock()
+	total := 0
+	for _, c := range registry.caches {
+		total += c.TotalCount()
+	}
+	return total
+}
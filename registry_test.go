@@ -0,0 +1,42 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNamedCacheAggregates(t *testing.T) {
+	names := []string{"test-registry-b", "test-registry-a", "test-registry-c"}
+	for _, name := range names {
+		t.Cleanup(func() { UnregisterCache(name) })
+	}
+	before := TotalCountAllCaches()
+
+	NamedCache("test-registry-a", 10).AddMessages("channel1", testHistory(3))
+	NamedCache("test-registry-b", 10).AddMessages("channel1", testHistory(4))
+	c := NamedCache("test-registry-c", 10)
+	c.AddMessages("channel1", testHistory(2))
+	c.AddMessages("channel2", testHistory(5))
+
+	if same := NamedCache("test-registry-c", 99); same != c {
+		t.Error("NamedCache should return the already registered cache.")
+	}
+	if got := TotalCountAllCaches() - before; got != 14 {
+		t.Errorf("Expected 14 messages across the named caches, got %d", got)
+	}
+
+	var registered []string
+	for _, name := range AllCacheNames() {
+		if len(name) > 14 && name[:14] == "test-registry-" {
+			registered = append(registered, name)
+		}
+	}
+	if fmt.Sprint(registered) != "[test-registry-a test-registry-b test-registry-c]" {
+		t.Errorf("Unexpected cache names: %v", registered)
+	}
+
+	UnregisterCache("test-registry-b")
+	if got := TotalCountAllCaches() - before; got != 10 {
+		t.Errorf("Expected 10 messages after unregistering a cache, got %d", got)
+	}
+}
@@ -0,0 +1,183 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ReplaceChannel atomically swaps the contents of a channel for msgs, for example to install history rebuilt
+// from the API. The new contents are ordered by ID, deduplicated, admitted like adds, including global dedup,
+// guild policies and the per-author cap, and capped to the channel capacity off to the side, then swapped in
+// one step: readers see either the old or the new messages. Adds racing the replace land in the new contents:
+// those that complete while it is being built are stored on top of it at the swap as if they came after it.
+// Counters, per-channel capacity, GetUnread positions and Tail consumers carry over. It returns
+// ErrInvalidChannel for an empty channel ID, ErrChannelFrozen for frozen channels and ErrChannelSealed for
+// sealed ones.
+func (c *MessageCache) ReplaceChannel(channelID string, msgs []*discordgo.Message) error {
+	return c.replaceChannels(map[string][]*discordgo.Message{channelID: msgs}, false)
+}
+
+// ReplaceAll atomically swaps the contents of the whole cache for channels, as ReplaceChannel does for one
+// channel. Channels missing from channels are dropped, along with adds racing the replace. Nothing changes if
+// any channel ID is empty (ErrInvalidChannel) or any cached channel is frozen or sealed, in which case a
+// *ChannelError wrapping ErrChannelFrozen or ErrChannelSealed is returned for each such channel, joined with
+// errors.Join.
+func (c *MessageCache) ReplaceAll(channels map[string][]*discordgo.Message) error {
+	return c.replaceChannels(channels, true)
+}
+
+// replaceChannels builds channel caches for contents and swaps them in under the global write lock. With all
+// set, every other channel is dropped.
+func (c *MessageCache) replaceChannels(contents map[string][]*discordgo.Message, all bool) error {
+	for channelID := range contents {
+		if channelID == "" {
+			return ErrInvalidChannel
+		}
+	}
+
+	since := c.insertSeq.Load() // since is the last insertion before the replace; later ones race it
+	c.rlockGlobal()
+	maxMessages := c.maxMessages
+	customMax := make(map[string]int)
+	guildIDs := make(map[string]string)
+	replacedIDs := make(map[string]struct{}) // replacedIDs holds the IDs the swap drops, which are not global duplicates
+	for channelID := range contents {
+		if cc, ok := c.messages[channelID]; ok {
+			c.rlockChannel(cc)
+			if cc.customMax {
+				customMax[channelID] = cc.maxMessages
+			}
+			guildIDs[channelID] = cc.guildID
+			for i := 0; i < cc.size && c.globalIDs != nil; i++ {
+				replacedIDs[cc.at(i).message.ID] = struct{}{}
+			}
+			cc.RUnlock()
+		}
+	}
+	c.RUnlock()
+
+	admitted := make(map[string]struct{}) // admitted holds the IDs stored so far across the new channels
+	globalDuplicate := func(messageID string) bool {
+		if c.globalIDs == nil {
+			return false
+		}
+		if containsKey(admitted, messageID) || !all && c.ContainsGlobal(messageID) && !containsKey(replacedIDs, messageID) {
+			return true
+		}
+		admitted[messageID] = struct{}{}
+		return false
+	}
+	built := make(map[string]*ChannelCache, len(contents))
+	for channelID, msgs := range contents {
+		capacity, custom := customMax[channelID]
+		if !custom {
+			capacity = cmp.Or(c.guildCapacity(guildIDs[channelID]), maxMessages)
+		}
+		cc := c.newDetachedChannel(channelID, capacity, 0)
+		cc.customMax = custom
+		cc.guildID = guildIDs[channelID]
+		cc.reset(c.prepareEntries(cc, msgs, globalDuplicate))
+		built[channelID] = cc
+	}
+
+	var evicted []string
+	c.lockGlobal()
+	blocked := make(map[string]error)
+	for channelID, cc := range c.messages {
+		if _, replaced := contents[channelID]; all || replaced {
+			c.rlockChannel(cc)
+			switch {
+			case cc.frozen.Load():
+				blocked[channelID] = ErrChannelFrozen
+			case cc.sealed:
+				blocked[channelID] = ErrChannelSealed
+			}
+			cc.RUnlock()
+		}
+	}
+	if len(blocked) > 0 {
+		c.Unlock()
+		return joinChannelErrors(blocked)
+	}
+	late := make(map[string][]cachedMessage)
+	for channelID, cc := range built {
+		if old, ok := c.messages[channelID]; ok {
+			c.lockChannel(old)
+			cc.counters, cc.tags, cc.readers = old.counters, old.tags, old.readers
+			old.moveTails(cc)
+			for i := 0; i < old.size; i++ {
+				if entry := old.at(i); entry.insertSeq > since {
+					late[channelID] = append(late[channelID], *entry)
+				}
+			}
+			old.Unlock()
+		}
+	}
+	if all {
+		c.replaceChannelsLocked(built)
+	} else {
+		for channelID, cc := range built {
+			if _, ok := c.messages[channelID]; ok {
+				c.deleteChannelLocked(channelID)
+			} else {
+				evicted = append(evicted, c.evictChannelsLocked()...)
+			}
+			c.touchChannel(cc)
+			c.storeChannelLocked(channelID, cc)
+		}
+	}
+	for channelID, entries := range late {
+		c.mergeLate(built[channelID], entries)
+	}
+	c.Unlock()
+	c.channelsEvicted(evicted)
+	c.enforceGlobalCaps()
+	return nil
+}
+
+// mergeLate stores entries added to a replaced channel while its replacement cc was being built, in the order
+// they were added, as adds would store them after the swap. Entries the replacement or another channel already
+// holds are skipped. The caller must hold the global write lock and have attached cc.
+func (c *MessageCache) mergeLate(cc *ChannelCache, entries []cachedMessage) {
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	slices.SortFunc(entries, func(a, b cachedMessage) int { return cmp.Compare(a.insertSeq, b.insertSeq) })
+	for _, entry := range entries {
+		if cc.maxMessages <= 0 || cc.find(entry.message.ID) >= 0 || c.ContainsGlobal(entry.message.ID) {
+			continue
+		}
+		c.enforceAuthorCap(cc, entry.author)
+		cc.add(entry)
+		cc.horizon.push(entry.message.ID)
+	}
+}
+
+// prepareEntries turns messages into entries for cc as adds would store them: filtered, admitted by the guild
+// policy, deduplicated by ID (first wins) and against other channels with globalDuplicate, ingested, sorted by ID
+// and trimmed to the per-author cap. The guild of cc is learned from the messages like adds do.
+func (c *MessageCache) prepareEntries(cc *ChannelCache, msgs []*discordgo.Message, globalDuplicate func(messageID string) bool) []cachedMessage {
+	entries := make([]cachedMessage, 0, len(msgs))
+	seen := make(map[string]struct{}, len(msgs))
+	now := c.now()
+	for _, msg := range msgs {
+		if msg == nil || c.skipWebhook(msg) || c.skipInteraction(msg) || containsKey(seen, msg.ID) {
+			continue
+		}
+		if p, ok := c.attributeGuild(cc, msg); ok && !p.admits(msg, now) {
+			continue
+		}
+		if globalDuplicate(msg.ID) {
+			continue
+		}
+		seen[msg.ID] = struct{}{}
+		if msg, sanitized := c.ingest(msg); msg != nil {
+			entry := c.newEntry(msg)
+			entry.size += sanitized
+			entries = append(entries, entry)
+		}
+	}
+	c.sortByID(entries)
+	return c.capAuthors(entries, nil)
+}
@@ -0,0 +1,248 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestReplaceChannel(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(5))
+	cache.SetChannelMaxMessages("channel1", 3)
+	cache.IncrCounter("channel1", "commands", 2)
+
+	rebuilt := []*discordgo.Message{{ID: "205"}, {ID: "201"}, {ID: "203"}, {ID: "201"}, nil, {ID: "204"}, {ID: "202"}}
+	if err := cache.ReplaceChannel("channel1", rebuilt); err != nil {
+		t.Fatalf("ReplaceChannel returned error: %v", err)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[203 204 205]" {
+		t.Errorf("Expected the newest rebuilt messages in order, got %v", got)
+	}
+	if n, _ := cache.GetCounter("channel1", "commands"); n != 2 {
+		t.Errorf("Expected counters to carry over, got %d", n)
+	}
+	if ok, _ := cache.Contains("channel1", "104"); ok {
+		t.Error("Old messages should be gone after a replace.")
+	}
+	cache.AddMessage("channel1", &discordgo.Message{ID: "206"})
+	if stats, _ := cache.ChannelStats("channel1"); stats.Messages != 3 || stats.MaxMessages != 3 {
+		t.Errorf("Expected the per-channel capacity to carry over, got %+v", stats)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+
+	if err := cache.ReplaceChannel("", nil); !errors.Is(err, ErrInvalidChannel) {
+		t.Errorf("Expected ErrInvalidChannel, got %v", err)
+	}
+	cache.FreezeChannel("channel1")
+	if err := cache.ReplaceChannel("channel1", nil); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected ErrChannelFrozen, got %v", err)
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(2))
+	cache.AddMessages("channel2", testHistory(2))
+
+	if err := cache.ReplaceAll(map[string][]*discordgo.Message{"channel2": testHistory(4), "channel3": testHistory(1)}); err != nil {
+		t.Fatalf("ReplaceAll returned error: %v", err)
+	}
+	if _, ok := cache.GetMessages("channel1"); ok {
+		t.Error("Channels missing from ReplaceAll should be dropped.")
+	}
+	if n := cache.TotalCount(); n != 5 {
+		t.Errorf("Expected 5 messages after ReplaceAll, got %d", n)
+	}
+
+	cache.FreezeChannel("channel3")
+	if err := cache.ReplaceAll(nil); !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected ErrChannelFrozen, got %v", err)
+	}
+	if n := cache.TotalCount(); n != 5 {
+		t.Errorf("A failed ReplaceAll should change nothing, got %d messages", n)
+	}
+}
+
+func TestReplaceChannelAtomic(t *testing.T) {
+	cache := NewMessageCache(100)
+	generation := func(prefix string) []*discordgo.Message {
+		msgs := make([]*discordgo.Message, 50)
+		for i := range msgs {
+			msgs[i] = &discordgo.Message{ID: fmt.Sprintf("%s%02d", prefix, i)}
+		}
+		return msgs
+	}
+	cache.ReplaceChannel("channel1", generation("a"))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				msgs, _ := cache.GetMessages("channel1")
+				for _, msg := range msgs[1:] {
+					if msg.ID[0] != msgs[0].ID[0] {
+						t.Errorf("Reader saw a mix of generations: %v", messageIDs(msgs))
+						return
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		cache.ReplaceChannel("channel1", generation(string(rune('a'+i%2))))
+	}
+	close(stop)
+	wg.Wait()
+
+	var adders sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		adders.Add(1)
+		go func(w int) {
+			defer adders.Done()
+			for i := 0; i < 20; i++ {
+				cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprintf("z%d-%d", w, i)})
+			}
+		}(w)
+	}
+	for i := 0; i < 20; i++ {
+		cache.ReplaceChannel("channel1", generation("a"))
+	}
+	adders.Wait()
+	cache.AddMessage("channel1", &discordgo.Message{ID: "zlast"})
+	ids, _ := cache.MessageIDs("channel1")
+	if ids[len(ids)-1] != "zlast" {
+		t.Errorf("Expected an add after the replace to land in the live channel, got %v", ids)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestReplaceChannelKeepsRacingAdds(t *testing.T) {
+	var cache *MessageCache
+	var once sync.Once
+	var addErr error
+	// The redactor runs while the replacement is built, so the add lands in the old channel mid-replace.
+	cache = NewMessageCache(10, WithRedactor(func(content string) string {
+		if content == "replacement" {
+			once.Do(func() { addErr = cache.AddMessage("channel1", &discordgo.Message{ID: "200"}) })
+		}
+		return content
+	}))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "100"})
+	cache.ReplaceChannel("channel1", []*discordgo.Message{{ID: "150", Content: "replacement"}})
+
+	ids, _ := cache.MessageIDs("channel1")
+	if addErr != nil || fmt.Sprint(ids) != "[150 200]" {
+		t.Errorf("Expected the racing add kept on top of the replacement, got %v (err %v)", ids, addErr)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+
+	cache = NewMessageCache(100, WithGlobalDedup())
+	var adders sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		adders.Add(1)
+		go func(w int) {
+			defer adders.Done()
+			for i := 0; i < 50; i++ {
+				cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprintf("z%d-%02d", w, i)})
+			}
+		}(w)
+	}
+	for i := 0; i < 20; i++ {
+		cache.ReplaceChannel("channel1", []*discordgo.Message{{ID: "a"}})
+	}
+	adders.Wait()
+	cache.ReplaceChannel("channel1", []*discordgo.Message{{ID: "a"}})
+	cache.AddMessage("channel1", &discordgo.Message{ID: "zlast"})
+	if ids, _ := cache.MessageIDs("channel1"); fmt.Sprint(ids) != "[a zlast]" {
+		t.Errorf("Expected only the replacement and the later add, got %v", ids)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestReplaceChannelKeepsReadPositions(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", testHistory(3)) // 100..102
+	cache.GetUnread("channel1", "reader")
+	cache.ReplaceChannel("channel1", testHistory(5))
+	if msgs, err := cache.GetUnread("channel1", "reader"); err != nil || fmt.Sprint(messageIDs(msgs)) != "[103 104]" {
+		t.Errorf("Expected reading to resume after the replace, got %v (err %v)", messageIDs(msgs), err)
+	}
+
+	cache.ReplaceChannel("channel1", testHistory(10)[7:])
+	if msgs, err := cache.GetUnread("channel1", "reader"); !errors.Is(err, ErrReadPositionEvicted) || len(msgs) != 3 {
+		t.Errorf("Expected ErrReadPositionEvicted once the position is gone, got %v (err %v)", messageIDs(msgs), err)
+	}
+}
+
+func TestReplaceAllReportsEveryFrozenChannel(t *testing.T) {
+	cache := NewMessageCache(5)
+	for _, channelID := range []string{"a", "b", "c"} {
+		cache.AddMessages(channelID, testHistory(2))
+	}
+	cache.FreezeChannel("a")
+	cache.FreezeChannel("c")
+
+	err := cache.ReplaceAll(map[string][]*discordgo.Message{"b": testHistory(1)})
+	if want := "channel a: " + ErrChannelFrozen.Error() + "\nchannel c: " + ErrChannelFrozen.Error(); err == nil || err.Error() != want {
+		t.Fatalf("Got %v, want %q", err, want)
+	}
+	if n, _ := cache.MessageCount("b"); n != 2 {
+		t.Errorf("Expected ReplaceAll to stay all-or-nothing, got %d messages in b", n)
+	}
+}
+
+func TestReplaceChannelAdmission(t *testing.T) {
+	cache := NewMessageCache(10, WithGlobalDedup(), WithPerAuthorCap(2))
+	cache.AddMessage("a", &discordgo.Message{ID: "1"})
+	cache.FreezeChannel("a")
+	if err := cache.ReplaceChannel("a", []*discordgo.Message{{ID: "5"}}); !errors.Is(err, ErrChannelFrozen) {
+		t.Fatalf("Expected ErrChannelFrozen, got %v", err)
+	}
+	if cache.ContainsGlobal("5") {
+		t.Errorf("A refused replace must not leave its IDs in the global set")
+	}
+	cache.AddMessage("b", &discordgo.Message{ID: "5"})
+	cache.AddMessage("b", &discordgo.Message{ID: "6"})
+	if n, _ := cache.MessageCount("b"); n != 2 {
+		t.Errorf("Expected the IDs of a refused replace to be addable, got %d messages", n)
+	}
+
+	cache.SetGuildPolicy("closed", GuildPolicy{})
+	author := &discordgo.User{ID: "u1"}
+	cache.ReplaceChannel("c", []*discordgo.Message{
+		{ID: "6"}, {ID: "7", Author: author}, {ID: "8", Author: author}, {ID: "9", Author: author},
+	})
+	msgs, _ := cache.GetMessages("c")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[8 9]" {
+		t.Errorf("Expected global duplicates and the author's oldest message over the cap skipped, got %v", got)
+	}
+	cache.ReplaceChannel("b", []*discordgo.Message{{ID: "5"}, {ID: "10"}})
+	if msgs, _ := cache.GetMessages("b"); fmt.Sprint(messageIDs(msgs)) != "[5 10]" {
+		t.Errorf("Expected IDs of the replaced channel itself not to count as global duplicates, got %v", messageIDs(msgs))
+	}
+	cache.ReplaceChannel("d", []*discordgo.Message{{ID: "11", GuildID: "closed"}})
+	if n, _ := cache.MessageCount("d"); n != 0 {
+		t.Errorf("Expected the guild policy applied to a replace, got %d messages", n)
+	}
+}
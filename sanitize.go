@@ -0,0 +1,51 @@
+package dgocacheler
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// WithSanitizeContent cleans message text on ingestion, so that payloads with invalid UTF-8 or stray control
+// characters, as some gateway events and bridges deliver, cannot break JSON snapshots, the debug handler or a
+// terminal. Invalid UTF-8 sequences are replaced with U+FFFD and C0 control characters other than newline and
+// tab are stripped, in the content, embed titles, descriptions and fields, and attachment filenames. Messages
+// are cloned first, so the caller's message is never modified. The estimated size of a message still counts the
+// bytes as received. Sanitization is off by default and runs before WithRedactor.
+func WithSanitizeContent() Option {
+	return func(c *MessageCache) {
+		c.sanitizeContent = true
+	}
+}
+
+// sanitizeMessage sanitizes the text fields of msg, which must be a clone made by cloneMessage, and returns how
+// many bytes shorter they became.
+func sanitizeMessage(msg *discordgo.Message) int {
+	removed := 0
+	redactMessage(msg, func(text string) string {
+		clean := sanitizeText(text)
+		removed += len(text) - len(clean)
+		return clean
+	})
+	return removed
+}
+
+// sanitizeText replaces invalid UTF-8 in text with U+FFFD and strips C0 control characters other than newline
+// and tab.
+func sanitizeText(text string) string {
+	if utf8.ValidString(text) && !strings.ContainsFunc(text, isStrippedControl) {
+		return text
+	}
+	return strings.Map(func(r rune) rune {
+		if isStrippedControl(r) {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(text, "\uFFFD"))
+}
+
+// isStrippedControl reports whether r is a C0 control character removed by sanitization.
+func isStrippedControl(r rune) bool {
+	return r < 0x20 && r != '\n' && r != '\t'
+}
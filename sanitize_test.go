@@ -0,0 +1,117 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// dirtyMessage carries text in every field sanitization covers.
+func dirtyMessage(id, text string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:          id,
+		Content:     text,
+		Embeds:      []*discordgo.MessageEmbed{{Title: text, Description: text, Fields: []*discordgo.MessageEmbedField{{Name: text, Value: text}}}},
+		Attachments: []*discordgo.MessageAttachment{{Filename: text, URL: "https://cdn.example/a"}},
+	}
+}
+
+// checkSanitized fails if any text field of msg is invalid UTF-8 or holds a stripped control character.
+func checkSanitized(t *testing.T, msg *discordgo.Message) {
+	t.Helper()
+	texts := []string{msg.Content, msg.Embeds[0].Title, msg.Embeds[0].Description, msg.Embeds[0].Fields[0].Name,
+		msg.Embeds[0].Fields[0].Value, msg.Attachments[0].Filename}
+	for _, text := range texts {
+		if !utf8.ValidString(text) || strings.ContainsFunc(text, isStrippedControl) {
+			t.Fatalf("Unsanitized text %q", text)
+		}
+	}
+}
+
+func TestSanitizeContent(t *testing.T) {
+	cache := NewMessageCache(10, WithSanitizeContent())
+	original := dirtyMessage("1", "a\x00b\xffc\r\nd\te\x1b[31m")
+	cache.AddMessage("channel1", original)
+
+	msg, _ := cache.GetMessage("channel1", "1")
+	if want := "ab�c\nd\te[31m"; msg.Content != want || msg.Attachments[0].Filename != want {
+		t.Errorf("Expected %q, got %q", want, msg.Content)
+	}
+	checkSanitized(t, msg)
+	if original.Content != "a\x00b\xffc\r\nd\te\x1b[31m" {
+		t.Error("Expected the caller's message to be left untouched")
+	}
+	if got, want := cache.Stats().EstimatedBytes, int64(estimateMessageSize(original)); got != want {
+		t.Errorf("Expected the size as received, %d bytes, got %d", want, got)
+	}
+
+	cache.UpdateMessage("channel1", dirtyMessage("1", "\x07bell"))
+	if msg, _ := cache.GetMessage("channel1", "1"); msg.Content != "bell" {
+		t.Errorf("Expected updates to be sanitized, got %q", msg.Content)
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
+
+func TestSanitizeContentIsOptIn(t *testing.T) {
+	cache := NewMessageCache(10)
+	msg := dirtyMessage("1", "a\x00b")
+	cache.AddMessage("channel1", msg)
+	if got, _ := cache.GetMessage("channel1", "1"); got != msg {
+		t.Error("Expected messages to be stored as given without WithSanitizeContent")
+	}
+}
+
+func FuzzSanitizeContent(f *testing.F) {
+	for _, seed := range []string{"", "hello", "a\x00b\xffc", "\xed\xa0\x80", "\r\n\t\x7f", "emoji 😀 \xf0\x9f"} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cache := NewMessageCache(10, WithSanitizeContent(), WithDebugContent(true))
+		original := dirtyMessage("1", string(data))
+		if err := cache.AddMessage("channel1", original); err != nil {
+			t.Fatalf("AddMessage failed: %v", err)
+		}
+		msg, err := cache.GetMessage("channel1", "1")
+		if err != nil {
+			t.Fatalf("GetMessage failed: %v", err)
+		}
+		checkSanitized(t, msg)
+		if got, want := cache.Stats().EstimatedBytes, int64(estimateMessageSize(original)); got != want {
+			t.Fatalf("Expected %d estimated bytes, got %d", want, got)
+		}
+
+		var snapshot bytes.Buffer
+		if _, err := cache.WriteTo(&snapshot); err != nil {
+			t.Fatalf("WriteTo failed: %v", err)
+		}
+		restored := NewMessageCache(10)
+		if _, err := restored.ReadFrom(&snapshot); err != nil {
+			t.Fatalf("ReadFrom failed: %v", err)
+		}
+		if got, _ := restored.GetMessage("channel1", "1"); got == nil || got.Content != msg.Content {
+			t.Fatalf("Expected sanitized content to round-trip through a snapshot")
+		}
+		if _, err := json.Marshal(msg); err != nil {
+			t.Fatalf("json.Marshal failed: %v", err)
+		}
+		if _, err := cache.DebugDump("channel1"); err != nil {
+			t.Fatalf("DebugDump failed: %v", err)
+		}
+		if _, err := cache.AsMessageSends("channel1"); err != nil {
+			t.Fatalf("AsMessageSends failed: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		DebugHandler(cache).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/channels/channel1/messages", nil))
+		if rec.Code != http.StatusOK || !json.Valid(rec.Body.Bytes()) {
+			t.Fatalf("Debug handler returned %d with %q", rec.Code, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package dgocacheler
+
+import (
+	"math"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// similarityWindow is the number of an author's most recent messages a new message is compared against.
+const similarityWindow = 5
+
+// similarityScanLimit bounds how far back in a channel the cache looks for an author's recent messages.
+const similarityScanLimit = 50
+
+// similarityMaxRunes bounds the length of the content compared, since the comparison runs under the channel lock.
+const similarityMaxRunes = 256
+
+// SetSimilarityDedup rejects new messages whose content is at least threshold similar (a normalized Levenshtein
+// ratio between 0 and 1) to one of the same author's last few messages in the channel. Rejected messages are
+// not stored and AddMessage returns ErrSuppressedDuplicate. Messages without content, such as attachments
+// alone, are never compared, and only the first 256 characters of longer messages are. With WithContentHashing
+// the cache only keeps digests of the texts, so only identical texts are caught, whatever the threshold. A
+// threshold of 0 disables the check.
+func (c *MessageCache) SetSimilarityDedup(threshold float64) {
+	c.similarityThreshold.Store(math.Float64bits(min(max(threshold, 0), 1)))
+}
+
+// isSimilarDuplicate reports whether message is too similar to a recent message by the same author.
+// The caller must hold at least the channel's read lock.
+func (c *MessageCache) isSimilarDuplicate(cc *ChannelCache, message *discordgo.Message) bool {
+	threshold := math.Float64frombits(c.similarityThreshold.Load())
+	if threshold == 0 {
+		return false
+	}
+	author := c.authorKey(message)
+	if author == "" || message.Content == "" {
+		return false
+	}
+	content := leadingRunes(message.Content, similarityMaxRunes)
+	compared := 0
+	for i := cc.size - 1; i >= max(0, cc.size-similarityScanLimit) && compared < similarityWindow; i-- {
+		prev := cc.at(i).message
+		if c.authorKey(prev) != author {
+			continue
+		}
+		compared++
+		switch {
+		case prev.Content == "":
+		case c.contentHashing:
+			// Digests of different texts share nothing, so only equal digests mean similar texts.
+			if prev.Content == message.Content {
+				return true
+			}
+		case similarityRunes(leadingRunes(prev.Content, similarityMaxRunes), content) >= threshold:
+			return true
+		}
+	}
+	return false
+}
+
+// similarity returns 1 minus the Levenshtein distance of a and b divided by the length of the longer one.
+func similarity(a, b string) float64 {
+	return similarityRunes([]rune(a), []rune(b))
+}
+
+// similarityRunes is similarity for rune slices.
+func similarityRunes(a, b []rune) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// leadingRunes returns up to the first n runes of s.
+func leadingRunes(s string, n int) []rune {
+	runes := make([]rune, 0, min(len(s), n))
+	for _, r := range s {
+		if len(runes) == n {
+			break
+		}
+		runes = append(runes, r)
+	}
+	return runes
+}
+
+// levenshtein returns the edit distance between a and b using two rows of the dynamic programming table.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,127 @@
+package dgocacheler
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func authoredMessage(id, authorID, content string) *discordgo.Message {
+	return &discordgo.Message{ID: id, Content: content, Author: &discordgo.User{ID: authorID}}
+}
+
+func TestSimilarityDedupRejectsNearDuplicates(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetSimilarityDedup(0.8)
+
+	if err := cache.AddMessage("channel1", authoredMessage("1", "spammer", "buy cheap gold now!!!")); err != nil {
+		t.Fatalf("First message should be accepted, got %v", err)
+	}
+	err := cache.AddMessage("channel1", authoredMessage("2", "spammer", "buy cheap gold now!!"))
+	if !errors.Is(err, ErrSuppressedDuplicate) {
+		t.Errorf("Expected ErrSuppressedDuplicate for a near-identical message, got %v", err)
+	}
+	if err := cache.AddMessage("channel1", authoredMessage("3", "spammer", "has anyone seen the patch notes?")); err != nil {
+		t.Errorf("A clearly different message should be accepted, got %v", err)
+	}
+	if err := cache.AddMessage("channel1", authoredMessage("4", "someone", "buy cheap gold now!!!")); err != nil {
+		t.Errorf("The same text from another author should be accepted, got %v", err)
+	}
+
+	if msgs, _ := cache.GetMessages("channel1"); len(msgs) != 3 {
+		t.Errorf("Expected 3 stored messages, got %d", len(msgs))
+	}
+}
+
+func TestSimilarityDedupDisabled(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetSimilarityDedup(0.5)
+	cache.SetSimilarityDedup(0)
+
+	for _, id := range []string{"1", "2", "3"} {
+		if err := cache.AddMessage("channel1", authoredMessage(id, "user", "same text")); err != nil {
+			t.Errorf("Dedup is disabled, but message %s was rejected: %v", id, err)
+		}
+	}
+}
+
+func TestSimilarityDedupWindow(t *testing.T) {
+	cache := NewMessageCache(20)
+	cache.SetSimilarityDedup(0.9)
+
+	cache.AddMessage("channel1", authoredMessage("1", "user", "hello there"))
+	for i, content := range []string{"alpha", "bravo", "charlie", "delta", "echo"} {
+		cache.AddMessage("channel1", authoredMessage(string(rune('a'+i)), "user", content))
+	}
+	// The first message has fallen out of the author's comparison window.
+	if err := cache.AddMessage("channel1", authoredMessage("9", "user", "hello there")); err != nil {
+		t.Errorf("Messages outside the comparison window should not be compared, got %v", err)
+	}
+}
+
+func TestAddMessagesReportsSuppressed(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetSimilarityDedup(1)
+	err := cache.AddMessages("channel1", []*discordgo.Message{
+		authoredMessage("1", "user", "ping"),
+		authoredMessage("2", "user", "ping"),
+		authoredMessage("3", "user", "pong"),
+	})
+	if !errors.Is(err, ErrSuppressedDuplicate) {
+		t.Errorf("Expected ErrSuppressedDuplicate, got %v", err)
+	}
+	if msgs, _ := cache.GetMessages("channel1"); len(msgs) != 2 {
+		t.Errorf("Expected the rest of the batch to be stored, got %d messages", len(msgs))
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"abc", "abc", 1},
+		{"abc", "abd", 2.0 / 3},
+		{"abc", "", 0},
+		{"kitten", "sitting", 1 - 3.0/7},
+	} {
+		if got := similarity(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestSimilarityDedupSkipsEmptyContent(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetSimilarityDedup(0.8)
+	for _, id := range []string{"1", "2", "3"} {
+		if err := cache.AddMessage("channel1", authoredMessage(id, "uploader", "")); err != nil {
+			t.Errorf("Messages without content must not be compared, got %v for message %s", err, id)
+		}
+	}
+	if err := cache.AddMessage("channel1", authoredMessage("4", "uploader", "a")); err != nil {
+		t.Errorf("Expected the first message with content accepted, got %v", err)
+	}
+
+	long := strings.Repeat("x", 10*similarityMaxRunes)
+	cache.AddMessage("channel1", authoredMessage("5", "author", long+"a"))
+	if err := cache.AddMessage("channel1", authoredMessage("6", "author", long+"b")); !errors.Is(err, ErrSuppressedDuplicate) {
+		t.Errorf("Expected long messages compared on their beginning, got %v", err)
+	}
+}
+
+func TestSimilarityDedupWithContentHashing(t *testing.T) {
+	cache := NewMessageCache(10, WithContentHashing())
+	cache.SetSimilarityDedup(0.1)
+	cache.AddMessage("channel1", authoredMessage("1", "author", "buy cheap gold now!!!"))
+	if err := cache.AddMessage("channel1", authoredMessage("2", "author", "has anyone seen the patch notes?")); err != nil {
+		t.Errorf("Digests of different texts must not count as similar, got %v", err)
+	}
+	if err := cache.AddMessage("channel1", authoredMessage("3", "author", "buy cheap gold now!!!")); !errors.Is(err, ErrSuppressedDuplicate) {
+		t.Errorf("Expected an identical text suppressed, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package dgocacheler
+
+import (
+	"slices"
+	"unsafe"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Fixed per-object overheads used by estimateMessageSize.
+const (
+	messageOverhead    = int(unsafe.Sizeof(discordgo.Message{}))
+	userOverhead       = int(unsafe.Sizeof(discordgo.User{}))
+	embedOverhead      = int(unsafe.Sizeof(discordgo.MessageEmbed{}))
+	fieldOverhead      = int(unsafe.Sizeof(discordgo.MessageEmbedField{}))
+	attachmentOverhead = int(unsafe.Sizeof(discordgo.MessageAttachment{}))
+	reactionOverhead   = int(unsafe.Sizeof(discordgo.MessageReactions{}))
+	pointerSize        = int(unsafe.Sizeof(uintptr(0)))
+)
+
+// estimateMessageSize approximates the memory held by a message: the structs it points to plus the bytes of
+// its strings. It is the single size estimator used for all byte accounting in the cache.
+func estimateMessageSize(msg *discordgo.Message) int {
+	if msg == nil {
+		return 0
+	}
+	size := messageOverhead + len(msg.ID) + len(msg.ChannelID) + len(msg.GuildID) + len(msg.Content) + len(msg.WebhookID)
+	if msg.Author != nil {
+		size += userOverhead + len(msg.Author.ID) + len(msg.Author.Username) + len(msg.Author.GlobalName) + len(msg.Author.Avatar)
+	}
+	size += len(msg.Mentions) * (pointerSize + userOverhead)
+	for _, embed := range msg.Embeds {
+		if embed == nil {
+			continue
+		}
+		size += pointerSize + embedOverhead + len(embed.Title) + len(embed.Description) + len(embed.URL)
+		for _, field := range embed.Fields {
+			if field != nil {
+				size += pointerSize + fieldOverhead + len(field.Name) + len(field.Value)
+			}
+		}
+	}
+	for _, attachment := range msg.Attachments {
+		if attachment != nil {
+			size += pointerSize + attachmentOverhead + len(attachment.ID) + len(attachment.Filename) + len(attachment.URL) + len(attachment.ProxyURL)
+		}
+	}
+	size += len(msg.Reactions) * (pointerSize + reactionOverhead)
+	return size
+}
+
+// ContentSizeBuckets counts a channel's messages by content length in bytes, for example to feed a Prometheus
+// histogram. bounds are inclusive upper bounds in strictly ascending order: the count at index i is of messages
+// longer than bounds[i-1] and at most bounds[i] bytes, and one extra count at the end holds the messages longer
+// than the last bound. The counts are not cumulative. It returns ErrCacheMiss for unknown channels and
+// ErrInvalidBounds if bounds is not strictly ascending.
+func (c *MessageCache) ContentSizeBuckets(channelID string, bounds []int) ([]int, error) {
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			return nil, ErrInvalidBounds
+		}
+	}
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	counts := make([]int, len(bounds)+1)
+	for i := 0; i < cc.size; i++ {
+		bucket, _ := slices.BinarySearch(bounds, len(cc.at(i).message.Content))
+		counts[bucket]++
+	}
+	return counts, nil
+}
@@ -0,0 +1,151 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// recomputeBytes sums the estimator over a channel's current messages.
+func recomputeBytes(cc *ChannelCache) int64 {
+	cc.RLock()
+	defer cc.RUnlock()
+	var total int64
+	for _, msg := range cc.messages() {
+		total += int64(estimateMessageSize(msg))
+	}
+	return total
+}
+
+func TestEstimateMessageSize(t *testing.T) {
+	small := estimateMessageSize(&discordgo.Message{ID: "1", Content: "hi"})
+	large := estimateMessageSize(&discordgo.Message{
+		ID:      "1",
+		Content: strings.Repeat("x", 1000),
+		Author:  &discordgo.User{ID: "42", Username: "user"},
+		Embeds:  []*discordgo.MessageEmbed{{Title: "title", Fields: []*discordgo.MessageEmbedField{{Name: "a", Value: "b"}}}},
+	})
+	if small <= 0 || large <= small+1000 {
+		t.Errorf("Unexpected estimates: small %d, large %d", small, large)
+	}
+	if estimateMessageSize(nil) != 0 {
+		t.Error("A nil message should have no size.")
+	}
+}
+
+func TestChannelStatsBytes(t *testing.T) {
+	cache := NewMessageCache(2)
+	msg1 := &discordgo.Message{ID: "1", Content: "short"}
+	msg2 := &discordgo.Message{ID: "2", Content: strings.Repeat("y", 500)}
+	cache.AddMessages("channel1", []*discordgo.Message{msg1, msg2})
+
+	stats, err := cache.ChannelStats("channel1")
+	want := int64(estimateMessageSize(msg1) + estimateMessageSize(msg2))
+	if err != nil || stats.EstimatedBytes != want || stats.Messages != 2 || stats.MaxMessages != 2 {
+		t.Errorf("Unexpected channel stats %+v (err %v), want %d bytes", stats, err, want)
+	}
+
+	// Eviction subtracts the evicted message.
+	cache.AddMessage("channel1", &discordgo.Message{ID: "3"})
+	stats, _ = cache.ChannelStats("channel1")
+	if stats.EstimatedBytes != recomputeBytes(cache.messages["channel1"]) {
+		t.Errorf("Byte count drifted after eviction: %d", stats.EstimatedBytes)
+	}
+
+	if _, err := cache.ChannelStats("missing"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
+
+func TestLargestChannels(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("small", &discordgo.Message{ID: "1", Content: "a"})
+	cache.AddMessage("large", &discordgo.Message{ID: "1", Content: strings.Repeat("a", 1000)})
+	cache.AddMessage("medium", &discordgo.Message{ID: "1", Content: strings.Repeat("a", 100)})
+
+	var names []string
+	for _, size := range cache.LargestChannels(2) {
+		names = append(names, size.ChannelID)
+	}
+	if fmt.Sprint(names) != "[large medium]" {
+		t.Errorf("Unexpected largest channels: %v", names)
+	}
+	if all := cache.LargestChannels(0); len(all) != 3 {
+		t.Errorf("Expected every channel for n <= 0, got %d", len(all))
+	}
+	if total := cache.Stats().EstimatedBytes; total != recomputeBytes(cache.messages["small"])+recomputeBytes(cache.messages["medium"])+recomputeBytes(cache.messages["large"]) {
+		t.Errorf("Stats total does not match the channels: %d", total)
+	}
+}
+
+func TestByteAccountingRandomized(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cache := NewMessageCache(20)
+	nextID := 0
+	for i := 0; i < 5000; i++ {
+		channelID := fmt.Sprintf("channel%d", rng.Intn(3))
+		switch op := rng.Intn(11); {
+		case op < 5:
+			nextID++
+			cache.AddMessage(channelID, &discordgo.Message{ID: fmt.Sprint(nextID), Content: strings.Repeat("z", rng.Intn(200))})
+		case op < 6:
+			batch := make([]*discordgo.Message, rng.Intn(30))
+			for j := range batch {
+				nextID++
+				batch[j] = &discordgo.Message{ID: fmt.Sprint(nextID), Content: strings.Repeat("b", rng.Intn(50))}
+			}
+			cache.AddMessages(channelID, batch)
+		case op < 8:
+			id := fmt.Sprint(rng.Intn(nextID + 1))
+			cache.UpdateMessage(channelID, &discordgo.Message{ID: id, Content: strings.Repeat("u", rng.Intn(300))})
+		case op < 9:
+			cache.SetChannelMaxMessages(channelID, 1+rng.Intn(30))
+		case op < 10:
+			cache.SetMaxMessages(1 + rng.Intn(30))
+		default:
+			nextID++
+			cache.mergeMessages(channelID, []*discordgo.Message{{ID: fmt.Sprint(rng.Intn(nextID + 1)), Content: "fetched"}}, "LatestOrFetch")
+		}
+
+		for _, cc := range cache.channelCaches() {
+			if got, want := cc.bytes, recomputeBytes(cc); got != want || got < 0 {
+				t.Fatalf("Step %d: %s has %d bytes, recomputed %d", i, cc.id, got, want)
+			}
+		}
+	}
+}
+
+func TestContentSizeBuckets(t *testing.T) {
+	cache := NewMessageCache(10)
+	for i, size := range []int{0, 10, 11, 100, 101, 5000} {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i), Content: strings.Repeat("x", size)})
+	}
+
+	for _, tc := range []struct {
+		bounds []int
+		want   string
+	}{
+		{[]int{10, 100, 1000}, "[2 2 1 1]"},
+		{[]int{0, 11}, "[1 2 3]"},
+		{nil, "[6]"},
+	} {
+		counts, err := cache.ContentSizeBuckets("channel1", tc.bounds)
+		if err != nil || fmt.Sprint(counts) != tc.want {
+			t.Errorf("ContentSizeBuckets(%v) = %v (err %v), want %s", tc.bounds, counts, err, tc.want)
+		}
+	}
+
+	if _, err := cache.ContentSizeBuckets("channel1", []int{100, 10}); !errors.Is(err, ErrInvalidBounds) {
+		t.Errorf("Expected ErrInvalidBounds for descending bounds, got %v", err)
+	}
+	if _, err := cache.ContentSizeBuckets("channel1", []int{10, 10}); !errors.Is(err, ErrInvalidBounds) {
+		t.Errorf("Expected ErrInvalidBounds for repeated bounds, got %v", err)
+	}
+	if _, err := cache.ContentSizeBuckets("missing", []int{10}); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package dgocacheler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Snapshot file layout: the magic bytes, a version byte, a flags byte, the body, then a big-endian CRC32
+// (IEEE) of everything before it. The body is the JSON payload, or with snapshotFlagEncrypted set, the payload
+// sealed with AES-GCM (see encryption.go). Version 1 snapshots have no checksum.
+const (
+	snapshotMagic         = "DGOC"
+	snapshotVersion       = 2
+	snapshotFlagEncrypted = 1 << 0
+	snapshotHeaderSize    = len(snapshotMagic) + 2
+	snapshotChecksumSize  = 4
+)
+
+// snapshotPayload is the serialized form of the cache contents.
+type snapshotPayload struct {
+	Channels      map[string]snapshotChannel `json:"channels"`
+	ContentHashed bool                       `json:"content_hashed,omitempty"` // ContentHashed is set when the contents are digests
+}
+
+// snapshotChannel is the serialized form of one channel.
+type snapshotChannel struct {
+	MaxMessages int                  `json:"max_messages,omitempty"` // MaxMessages is only set for per-channel capacities
+	Messages    []*discordgo.Message `json:"messages"`
+	Priorities  map[string]int       `json:"priorities,omitempty"` // Priorities holds the non-zero priorities by message ID
+	Cold        int                  `json:"cold,omitempty"`       // Cold is the number of oldest messages in the WithTieredRetention cold tier
+	Encoded     [][]byte             `json:"encoded,omitempty"`    // Encoded holds the messages encoded with SetCodec instead of Messages
+}
+
+// WriteTo writes a snapshot of every channel to w, encrypted when WithSnapshotEncryption is set.
+// It implements io.WriterTo.
+func (c *MessageCache) WriteTo(w io.Writer) (int64, error) {
+	return c.writeSnapshot(context.Background(), w)
+}
+
+// ReadFrom replaces the cache contents with a snapshot read from r. The snapshot is checksummed, decoded and
+// validated before the cache is touched, so a snapshot that fails to load leaves the cache unchanged; damaged
+// snapshots return an error wrapping ErrSnapshotCorrupt. It implements io.ReaderFrom.
+func (c *MessageCache) ReadFrom(r io.Reader) (int64, error) {
+	return c.readSnapshot(context.Background(), r)
+}
+
+// SaveToFile writes a snapshot to path, replacing the file atomically once the snapshot is complete.
+func (c *MessageCache) SaveToFile(path string) error {
+	return c.SaveToFileContext(context.Background(), path)
+}
+
+// SaveToFileContext is like SaveToFile but runs under ctx for tracing and profiling.
+func (c *MessageCache) SaveToFileContext(ctx context.Context, path string) (err error) {
+	ctx, span := c.startSpan(ctx, "SaveToFile")
+	defer func() { endSpan(span, err) }()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	buffered := bufio.NewWriter(tmp)
+	if _, err := c.writeSnapshot(ctx, buffered); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadFromFile replaces the cache contents with the snapshot stored at path. See ReadFrom.
+func (c *MessageCache) LoadFromFile(path string) error {
+	return c.LoadFromFileContext(context.Background(), path)
+}
+
+// LoadFromFileContext is like LoadFromFile but runs under ctx for tracing and profiling.
+func (c *MessageCache) LoadFromFileContext(ctx context.Context, path string) (err error) {
+	ctx, span := c.startSpan(ctx, "LoadFromFile")
+	defer func() { endSpan(span, err) }()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = c.readSnapshot(ctx, bufio.NewReader(f))
+	return err
+}
+
+// writeSnapshot encodes the cache and writes it with the snapshot header.
+func (c *MessageCache) writeSnapshot(ctx context.Context, w io.Writer) (n int64, err error) {
+	c.profileOp(ctx, "snapshot", "", func(context.Context) {
+		snapshot := c.snapshotPayload()
+		if codec := c.codec.Load(); codec != nil {
+			if err = codec.encodeSnapshot(&snapshot); err != nil {
+				return
+			}
+		}
+		var payload []byte
+		if payload, err = json.Marshal(snapshot); err != nil {
+			return
+		}
+		n, err = writeSnapshotBody(w, payload, c.snapshotKey)
+	})
+	return n, err
+}
+
+// readSnapshot decodes a snapshot and installs it as the cache contents.
+func (c *MessageCache) readSnapshot(ctx context.Context, r io.Reader) (n int64, err error) {
+	c.profileOp(ctx, "snapshot", "", func(context.Context) {
+		var data []byte
+		if data, err = io.ReadAll(r); err != nil {
+			return
+		}
+		n = int64(len(data))
+		var payload []byte
+		if payload, err = readSnapshotBody(data, c.snapshotKey); err != nil {
+			return
+		}
+		var snapshot snapshotPayload
+		if err = json.Unmarshal(payload, &snapshot); err != nil {
+			err = fmt.Errorf("%w: %v", ErrSnapshotFormat, err)
+			return
+		}
+		if err = c.codec.Load().decodeSnapshot(&snapshot); err != nil {
+			return
+		}
+		err = c.installSnapshot(snapshot)
+	})
+	return n, err
+}
+
+// snapshotPayload copies the contents of every channel, taking one channel lock at a time.
+func (c *MessageCache) snapshotPayload() snapshotPayload {
+	payload := snapshotPayload{Channels: make(map[string]snapshotChannel), ContentHashed: c.contentHashing}
+	for _, cc := range c.channelCaches() {
+		c.rlockChannel(cc)
+		channel := snapshotChannel{Messages: cc.messages()}
+		if cc.prioritized > 0 {
+			channel.Priorities = make(map[string]int, cc.prioritized)
+			for i := 0; i < cc.size; i++ {
+				if entry := cc.at(i); entry.priority != 0 {
+					channel.Priorities[entry.message.ID] = entry.priority
+				}
+			}
+		}
+		if cc.customMax {
+			channel.MaxMessages = cc.maxMessages
+		}
+		for channel.Cold < cc.size && cc.at(channel.Cold).cold {
+			channel.Cold++
+		}
+		payload.Channels[cc.id] = channel
+		cc.RUnlock()
+	}
+	return payload
+}
+
+// installSnapshot builds detached channel caches from a decoded snapshot and, once they all validate, swaps them
+// in under the global write lock. Until then they report no evictions and hold no global IDs, so a snapshot that
+// fails validation leaves no trace.
+func (c *MessageCache) installSnapshot(snapshot snapshotPayload) error {
+	c.rlockGlobal()
+	maxMessages := c.maxMessages
+	c.RUnlock()
+
+	channels := make(map[string]*ChannelCache, len(snapshot.Channels))
+	for channelID, channel := range snapshot.Channels {
+		capacity := maxMessages
+		if channel.MaxMessages > 0 {
+			capacity = channel.MaxMessages
+		}
+		cc := c.newDetachedChannel(channelID, capacity, 0)
+		cc.customMax = channel.MaxMessages > 0
+		for i, msg := range channel.Messages {
+			if msg != nil && !cc.contains(msg.ID) {
+				if c.anonymizeSalt != nil {
+					msg = cloneMessage(msg)
+					anonymizeMessage(msg, c.anonymizeSalt)
+				}
+				if c.contentHashing && !snapshot.ContentHashed {
+					msg = cloneMessage(msg)
+					hashContent(msg)
+				}
+				entry := c.newEntry(msg)
+				entry.priority = channel.Priorities[msg.ID]
+				entry.cold = i < channel.Cold // already sampled, so not sampled again
+				cc.add(entry)
+			}
+		}
+		if err := cc.validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrSnapshotCorrupt, err)
+		}
+		channels[channelID] = cc
+	}
+
+	c.lockGlobal()
+	c.replaceChannelsLocked(channels)
+	c.Unlock()
+	c.enforceGlobalCaps()
+	return nil
+}
+
+// writeSnapshotBody writes the header and payload, sealing the payload when key is set.
+func writeSnapshotBody(w io.Writer, payload, key []byte) (int64, error) {
+	var flags byte
+	if key != nil {
+		sealed, err := seal(key, payload)
+		if err != nil {
+			return 0, err
+		}
+		payload = sealed
+		flags |= snapshotFlagEncrypted
+	}
+	var buf bytes.Buffer
+	buf.Grow(snapshotHeaderSize + len(payload) + snapshotChecksumSize)
+	buf.WriteString(snapshotMagic)
+	buf.WriteByte(snapshotVersion)
+	buf.WriteByte(flags)
+	buf.Write(payload)
+	buf.Write(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(buf.Bytes())))
+	return buf.WriteTo(w)
+}
+
+// readSnapshotBody validates the header and returns the payload, opening it when the snapshot is encrypted.
+// The kind of snapshot must match the configuration: an encrypted snapshot needs a key and a key refuses
+// plaintext snapshots.
+func readSnapshotBody(data, key []byte) ([]byte, error) {
+	if len(data) < snapshotHeaderSize || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, fmt.Errorf("%w: missing header", ErrSnapshotFormat)
+	}
+	switch version := data[len(snapshotMagic)]; version {
+	case snapshotVersion:
+		if len(data) < snapshotHeaderSize+snapshotChecksumSize {
+			return nil, fmt.Errorf("%w: missing checksum", ErrSnapshotCorrupt)
+		}
+		split := len(data) - snapshotChecksumSize
+		want, got := binary.BigEndian.Uint32(data[split:]), crc32.ChecksumIEEE(data[:split])
+		if got != want {
+			return nil, fmt.Errorf("%w: checksum %08x, want %08x", ErrSnapshotCorrupt, got, want)
+		}
+		data = data[:split]
+	case 1:
+	default:
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrSnapshotFormat, version)
+	}
+	encrypted := data[len(snapshotMagic)+1]&snapshotFlagEncrypted != 0
+	body := data[snapshotHeaderSize:]
+	switch {
+	case encrypted && key == nil:
+		return nil, ErrSnapshotEncrypted
+	case !encrypted && key != nil:
+		return nil, ErrSnapshotNotEncrypted
+	case encrypted:
+		return open(key, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,104 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(8))
+	cache.AddMessages("channel2", testHistory(2))
+	cache.SetChannelMaxMessages("channel2", 3)
+
+	path := filepath.Join(t.TempDir(), "cache.snap")
+	if err := cache.SaveToFile(path); err != nil {
+		t.Fatalf("SaveToFile returned error: %v", err)
+	}
+
+	restored := NewMessageCache(5)
+	restored.AddMessages("stale", testHistory(1))
+	if err := restored.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile returned error: %v", err)
+	}
+	if msgs, _ := restored.GetMessages("channel1"); fmt.Sprint(messageIDs(msgs)) != "[103 104 105 106 107]" {
+		t.Errorf("Unexpected restored messages: %v", messageIDs(msgs))
+	}
+	if _, ok := restored.GetMessages("stale"); ok {
+		t.Error("Loading a snapshot should replace the existing channels.")
+	}
+	restored.AddMessages("channel2", testHistory(5)[2:])
+	if n, _ := restored.MessageCount("channel2"); n != 3 {
+		t.Errorf("Expected the per-channel capacity to survive the snapshot, got %d messages", n)
+	}
+}
+
+func TestSnapshotInvalidData(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(3))
+	if _, err := cache.ReadFrom(bytes.NewReader([]byte("not a snapshot"))); !errors.Is(err, ErrSnapshotFormat) {
+		t.Errorf("Expected ErrSnapshotFormat, got %v", err)
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 3 {
+		t.Errorf("A failed load should leave the cache unchanged, got %d messages", n)
+	}
+}
+
+func TestSnapshotCorruption(t *testing.T) {
+	source := NewMessageCache(5)
+	source.AddMessages("channel1", testHistory(4))
+	var buf bytes.Buffer
+	if _, err := source.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+
+	cache := NewMessageCache(5)
+	cache.AddMessages("live", testHistory(2))
+	for _, offset := range []int{snapshotHeaderSize, buf.Len() / 2, buf.Len() - 1} {
+		corrupt := bytes.Clone(buf.Bytes())
+		corrupt[offset] ^= 0x40
+		_, err := cache.ReadFrom(bytes.NewReader(corrupt))
+		if !errors.Is(err, ErrSnapshotCorrupt) {
+			t.Errorf("Flipping byte %d: expected ErrSnapshotCorrupt, got %v", offset, err)
+		}
+	}
+	if _, err := cache.ReadFrom(bytes.NewReader(buf.Bytes()[:buf.Len()-2])); !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("Expected ErrSnapshotCorrupt for a truncated snapshot, got %v", err)
+	}
+	if n, _ := cache.MessageCount("live"); n != 2 || cache.TotalCount() != 2 {
+		t.Errorf("A corrupt snapshot should leave the cache untouched, got %d messages", cache.TotalCount())
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestSnapshotInstallIsDetached(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(5))
+	var buf bytes.Buffer
+	cache.WriteTo(&buf)
+
+	var evicted []string
+	restored := NewMessageCache(3, WithGlobalDedup(), OnEvict(func(channelID string, msg *discordgo.Message, reason EvictReason) {
+		evicted = append(evicted, msg.ID)
+	}))
+	if _, err := restored.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom returned error: %v", err)
+	}
+	if len(evicted) != 0 {
+		t.Errorf("Snapshot messages that never were live must not be reported as evicted, got %v", evicted)
+	}
+	if restored.ContainsGlobal("101") || !restored.ContainsGlobal("104") {
+		t.Errorf("Expected the global ID set to hold exactly the installed messages")
+	}
+	restored.AddMessage("channel1", &discordgo.Message{ID: "105"})
+	if fmt.Sprint(evicted) != "[102]" {
+		t.Errorf("Expected evictions reported once installed, got %v", evicted)
+	}
+}
@@ -0,0 +1,203 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	spillBatchSize     = 100         // spillBatchSize is the number of buffered messages that triggers a flush
+	spillFlushInterval = time.Second // spillFlushInterval bounds how long an evicted message stays buffered
+	spillQueueSize     = 64          // spillQueueSize is the number of batches waiting for the handler before drops
+)
+
+// SpillFunc receives messages evicted from a channel, oldest first, to persist them elsewhere.
+type SpillFunc func(channelID string, msgs []*discordgo.Message) error
+
+// WithSpillHandler hands every evicted message to fn instead of losing it. Evicted messages are buffered and
+// passed to fn in batches per channel, once spillBatchSize messages are pending, every spillFlushInterval and
+// on Close, from a dedicated goroutine so a slow sink never blocks adds. When fn falls behind and spillQueueSize
+// batches are already waiting, newly flushed batches are dropped. Failures and drops are counted in Stats, and
+// handler errors are passed to the WithErrorHandler hook. Call Close to flush and stop the goroutine.
+func WithSpillHandler(fn SpillFunc) Option {
+	return func(c *MessageCache) {
+		c.spill = &spiller{handler: fn}
+	}
+}
+
+// WithErrorHandler registers fn to receive errors that happen in the background, away from any caller that
+// could return them, such as spill handler failures. It also turns on the recovery of panics in user-supplied
+// callbacks, see SetRecoverCallbacks; fn receives each recovered panic as a *CallbackPanicError.
+func WithErrorHandler(fn func(error)) Option {
+	return func(c *MessageCache) {
+		c.errorHandler = fn
+	}
+}
+
+// Close stops the background work of the cache: it stops the WithJanitor goroutine and SetHotWindow
+// reclamation, stores pending coalesced edits, flushes buffered spilled messages and stops the background
+// goroutine started by WithSpillHandler, waiting for the spill handler to finish, and writes out the WithAuditLog
+// records, returning any write error.
+// Messages evicted after Close are dropped and later audit records are only written by another Close. Close is
+// safe to call more than once.
+func (c *MessageCache) Close() error {
+	if c.janitor != nil {
+		c.janitor.close()
+	}
+	c.stopReclaimer()
+	c.edits.stopEdits()
+	c.FlushEdits()
+	if c.spill != nil {
+		c.spill.close()
+	}
+	if c.audit != nil {
+		return c.audit.close()
+	}
+	return nil
+}
+
+// reportError passes a background error to the WithErrorHandler hook, if any.
+func (c *MessageCache) reportError(err error) {
+	if c.errorHandler != nil {
+		c.errorHandler(err)
+	}
+}
+
+// spillBatch is a group of evicted messages of one channel.
+type spillBatch struct {
+	channelID string
+	msgs      []*discordgo.Message
+}
+
+// spiller buffers evicted messages and delivers them to the spill handler on its own goroutine.
+type spiller struct {
+	handler SpillFunc
+	onError func(error)
+	guard   func(callback string, fn func()) error
+
+	mu      sync.Mutex
+	pending map[string][]*discordgo.Message // pending holds the buffered messages per channel
+	count   int                             // count is the number of buffered messages
+	closed  bool
+
+	queue     chan spillBatch
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	spilled atomic.Uint64 // spilled counts messages accepted by the handler
+	failed  atomic.Uint64 // failed counts messages in batches the handler returned an error for
+	dropped atomic.Uint64 // dropped counts messages discarded because the queue was full or the spiller closed
+}
+
+// start launches the delivery goroutine.
+func (s *spiller) start(onError func(error), guard func(callback string, fn func()) error) {
+	s.onError = onError
+	s.guard = guard
+	s.pending = make(map[string][]*discordgo.Message)
+	s.queue = make(chan spillBatch, spillQueueSize)
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run()
+}
+
+// add buffers an evicted message, queueing the buffered batches once enough are pending.
+func (s *spiller) add(channelID string, msg *discordgo.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+	s.pending[channelID] = append(s.pending[channelID], msg)
+	s.count++
+	if s.count < spillBatchSize {
+		return
+	}
+	for _, batch := range s.takeLocked() {
+		select {
+		case s.queue <- batch:
+		default:
+			s.dropped.Add(uint64(len(batch.msgs)))
+		}
+	}
+}
+
+// takeLocked removes and returns the buffered batches. The caller must hold s.mu.
+func (s *spiller) takeLocked() []spillBatch {
+	batches := make([]spillBatch, 0, len(s.pending))
+	for channelID, msgs := range s.pending {
+		batches = append(batches, spillBatch{channelID: channelID, msgs: msgs})
+	}
+	clear(s.pending)
+	s.count = 0
+	return batches
+}
+
+// take is takeLocked for callers not holding s.mu.
+func (s *spiller) take() []spillBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.takeLocked()
+}
+
+// run delivers queued batches and flushes the buffer periodically until the spiller is closed.
+func (s *spiller) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(spillFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case batch := <-s.queue:
+			s.deliver(batch)
+		case <-ticker.C:
+			for len(s.queue) > 0 {
+				s.deliver(<-s.queue)
+			}
+			for _, batch := range s.take() {
+				s.deliver(batch)
+			}
+		case <-s.stop:
+			for len(s.queue) > 0 {
+				s.deliver(<-s.queue)
+			}
+			for _, batch := range s.take() {
+				s.deliver(batch)
+			}
+			return
+		}
+	}
+}
+
+// deliver passes a batch to the handler and accounts for the outcome.
+func (s *spiller) deliver(batch spillBatch) {
+	var err error
+	if panicErr := s.guard("SpillHandler", func() { err = s.handler(batch.channelID, batch.msgs) }); panicErr != nil {
+		// The recovered panic has already been reported.
+		s.failed.Add(uint64(len(batch.msgs)))
+		return
+	}
+	if err != nil {
+		s.failed.Add(uint64(len(batch.msgs)))
+		if s.onError != nil {
+			s.onError(fmt.Errorf("dgocacheler: spill handler for channel %s: %w", batch.channelID, err))
+		}
+		return
+	}
+	s.spilled.Add(uint64(len(batch.msgs)))
+}
+
+// close stops accepting messages, flushes the rest and waits for the delivery goroutine.
+func (s *spiller) close() {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.stop)
+		<-s.done
+	})
+}
@@ -0,0 +1,88 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSpillHandler(t *testing.T) {
+	var mu sync.Mutex
+	var spilled []string
+	cache := NewMessageCache(3, WithSpillHandler(func(channelID string, msgs []*discordgo.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, msg := range msgs {
+			spilled = append(spilled, channelID+"/"+msg.ID)
+		}
+		return nil
+	}))
+	cache.AddMessages("channel1", testHistory(5))
+	cache.KeepLast("channel1", 1)
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if got := fmt.Sprint(spilled); got != "[channel1/100 channel1/101 channel1/102 channel1/103]" {
+		t.Errorf("Expected every evicted message to be spilled in order, got %v", got)
+	}
+	if stats := cache.Stats(); stats.Spilled != 4 || stats.SpillFailed != 0 || stats.SpillDropped != 0 {
+		t.Errorf("Unexpected spill stats: %+v", stats)
+	}
+	cache.Close()
+}
+
+func TestSpillHandlerSlowFailingSink(t *testing.T) {
+	errSink := errors.New("sink down")
+	var errMu sync.Mutex
+	var reported []error
+	cache := NewMessageCache(10,
+		WithSpillHandler(func(channelID string, msgs []*discordgo.Message) error {
+			time.Sleep(5 * time.Millisecond)
+			return errSink
+		}),
+		WithErrorHandler(func(err error) {
+			errMu.Lock()
+			reported = append(reported, err)
+			errMu.Unlock()
+		}),
+	)
+
+	const writers, perWriter = 4, 10000
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				cache.AddMessage(fmt.Sprint("channel", w), &discordgo.Message{ID: fmt.Sprint(i)})
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	cache.Close()
+
+	// Delivering every batch to this sink would take far longer than the adds are allowed to.
+	if elapsed > 2*time.Second {
+		t.Errorf("Writers were slowed down by the sink: %v", elapsed)
+	}
+	stats := cache.Stats()
+	evicted := uint64(writers * (perWriter - 10))
+	if stats.SpillFailed+stats.SpillDropped+stats.Spilled != evicted {
+		t.Errorf("Expected all %d evictions accounted for, got %+v", evicted, stats)
+	}
+	if stats.SpillDropped == 0 || stats.SpillFailed == 0 || stats.Spilled != 0 {
+		t.Errorf("Expected failures and drops with a slow failing sink, got %+v", stats)
+	}
+	errMu.Lock()
+	defer errMu.Unlock()
+	if len(reported) == 0 || !errors.Is(reported[0], errSink) {
+		t.Errorf("Expected sink errors to reach the error handler, got %v", reported)
+	}
+}
@@ -0,0 +1,72 @@
+package dgocacheler
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// stateMirror forwards the messages stored in and removed from live channels to a discordgo.State.
+type stateMirror struct {
+	state  *discordgo.State
+	logger *slog.Logger // logger receives State errors, which are otherwise ignored
+}
+
+// WithStateMirror makes the cache feed st: every message stored is passed to st.MessageAdd, and every message
+// removed, evicted or cleared to st.MessageRemove. Deleting or evicting a channel removes its messages from st;
+// renaming one leaves st as it is. State errors, such as a channel unknown to st, never fail the cache
+// operation; they are logged at debug level when WithLogger is set. The State keeps applying its own
+// MaxMessageCount.
+func WithStateMirror(st *discordgo.State) Option {
+	return func(c *MessageCache) {
+		c.mirror = &stateMirror{state: st}
+	}
+}
+
+// add passes a stored message to the State. It hands over a copy so the State never merges into cached messages.
+func (m *stateMirror) add(channelID string, msg *discordgo.Message) {
+	mirrored := *msg
+	mirrored.ChannelID = channelID
+	m.report("MessageAdd", channelID, msg.ID, m.state.MessageAdd(&mirrored))
+}
+
+// remove removes a message from the State.
+func (m *stateMirror) remove(channelID, messageID string) {
+	m.report("MessageRemove", channelID, messageID, m.state.MessageRemove(&discordgo.Message{ID: messageID, ChannelID: channelID}))
+}
+
+// report logs a State error.
+func (m *stateMirror) report(op, channelID, messageID string, err error) {
+	if err != nil && m.logger != nil {
+		m.logger.Debug("dgocacheler: state mirror failed", "op", op, "channel_id", channelID, "message_id", messageID, "error", err)
+	}
+}
+
+// attachMirror starts mirroring a channel that becomes live, adding its current messages to the State. Channels
+// that are already mirrored are left alone. The caller must hold the global write lock.
+func (c *MessageCache) attachMirror(cc *ChannelCache) {
+	if c.mirror == nil {
+		return
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+	if cc.mirror != nil {
+		return
+	}
+	cc.mirror = c.mirror
+	for i := 0; i < cc.size; i++ {
+		cc.mirror.add(cc.id, cc.at(i).message)
+	}
+}
+
+// detachMirror stops mirroring a channel that is dropped, removing its messages from the State. The caller must
+// hold the channel's write lock.
+func (cc *ChannelCache) detachMirror() {
+	if cc.mirror == nil {
+		return
+	}
+	for i := 0; i < cc.size; i++ {
+		cc.mirror.remove(cc.id, cc.at(i).message.ID)
+	}
+	cc.mirror = nil
+}
@@ -0,0 +1,63 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// stateMessageIDs returns the IDs of the messages st holds for a channel.
+func stateMessageIDs(t *testing.T, st *discordgo.State, channelID string) []string {
+	t.Helper()
+	channel, err := st.Channel(channelID)
+	if err != nil {
+		t.Fatalf("State has no channel %s: %v", channelID, err)
+	}
+	return messageIDs(channel.Messages)
+}
+
+func TestStateMirror(t *testing.T) {
+	st := discordgo.NewState()
+	st.MaxMessageCount = 100
+	st.GuildAdd(&discordgo.Guild{ID: "g1", Channels: []*discordgo.Channel{{ID: "channel1"}, {ID: "channel2"}}})
+	cache := NewMessageCache(4, WithStateMirror(st))
+
+	agree := func(step, channelID string) {
+		t.Helper()
+		cached, _ := cache.GetMessages(channelID)
+		if got, want := fmt.Sprint(stateMessageIDs(t, st, channelID)), fmt.Sprint(messageIDs(cached)); got != want {
+			t.Errorf("%s: State holds %v, cache holds %v", step, got, want)
+		}
+	}
+
+	cache.AddMessages("channel1", testHistory(3))
+	agree("add", "channel1")
+	cache.AddMessages("channel1", testHistory(6)[3:])
+	agree("evict", "channel1")
+	cache.RemoveMessage("channel1", "104")
+	agree("remove", "channel1")
+	cache.UpdateMessage("channel1", &discordgo.Message{ID: "105", Content: "edited"})
+	agree("update", "channel1")
+	if channel, _ := st.Channel("channel1"); channel.Messages[len(channel.Messages)-1].Content != "edited" {
+		t.Error("Expected the edit to reach the State.")
+	}
+	cache.ReplaceChannel("channel1", testHistory(2))
+	agree("replace", "channel1")
+
+	cache.AddMessages("channel2", testHistory(2))
+	cache.ClearChannel("channel2")
+	agree("clear", "channel2")
+	cache.AddMessages("channel2", testHistory(2))
+	cache.DeleteChannel("channel2")
+	if ids := stateMessageIDs(t, st, "channel2"); len(ids) != 0 {
+		t.Errorf("Expected deleting the channel to empty it in the State, got %v", ids)
+	}
+
+	if err := cache.AddMessage("unknown", &discordgo.Message{ID: "1"}); err != nil {
+		t.Errorf("Expected State errors not to fail adds, got %v", err)
+	}
+	if n, _ := cache.MessageCount("unknown"); n != 1 {
+		t.Errorf("Expected the message to be cached despite the State error, got %d", n)
+	}
+}
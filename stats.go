@@ -0,0 +1,119 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Stats is a point-in-time summary of the cache.
+type Stats struct {
+	Channels          int           // Channels is the number of channels held by the cache
+	Messages          int           // Messages is the total number of cached messages across all channels
+	EstimatedBytes    int64         // EstimatedBytes is the estimated memory held by all cached messages
+	LockProfiling     bool          // LockProfiling reports whether lock wait tracking is enabled
+	GlobalLockWait    LockWaitStats // GlobalLockWait summarizes waits for the global lock when lock profiling is enabled
+	ChannelLockWait   LockWaitStats // ChannelLockWait summarizes waits for per-channel locks when lock profiling is enabled
+	FrozenSkipped     uint64        // FrozenSkipped counts the adds, updates, removes and evictions ignored on frozen channels
+	Spilled           uint64        // Spilled counts the evicted messages accepted by the spill handler
+	SpillFailed       uint64        // SpillFailed counts the evicted messages in batches the spill handler failed on
+	SpillDropped      uint64        // SpillDropped counts the evicted messages dropped because the spill queue was full
+	CallbackPanics    uint64        // CallbackPanics counts the panics recovered from user-supplied callbacks
+	DuplicatesDropped uint64        // DuplicatesDropped counts the messages dropped because their ID was already cached
+	BloomFillRatio    float64       // BloomFillRatio is the fraction of non-zero Bloom dedup filter slots across channels
+	HorizonSuppressed uint64        // HorizonSuppressed counts re-adds of evicted messages rejected by the dedup horizon
+	AddFastPath       uint64        // AddFastPath counts adds that found their channel without the global write lock, while contention tracking is on
+	AddSlowPath       uint64        // AddSlowPath counts adds that took the global write lock to create their channel, while contention tracking is on
+}
+
+// Stats returns a summary of the cache contents and, when enabled, lock contention.
+func (c *MessageCache) Stats() Stats {
+	var stats Stats
+	var bloomFilled, bloomSlots int
+	for _, cc := range c.channelCaches() {
+		c.rlockChannel(cc)
+		stats.Messages += cc.size
+		stats.EstimatedBytes += cc.bytes
+		if cc.bloom != nil {
+			bloomFilled += cc.bloom.filled
+			bloomSlots += len(cc.bloom.counts)
+		}
+		cc.RUnlock()
+		stats.Channels++
+	}
+	if bloomSlots > 0 {
+		stats.BloomFillRatio = float64(bloomFilled) / float64(bloomSlots)
+	}
+	stats.FrozenSkipped = c.frozenSkips.Load()
+	stats.CallbackPanics = c.callbackPanics.Load()
+	stats.DuplicatesDropped = c.duplicatesDropped.Load()
+	stats.HorizonSuppressed = c.horizonSuppressed.Load()
+	stats.AddFastPath = c.addFastPath.Load()
+	stats.AddSlowPath = c.addSlowPath.Load()
+	if c.spill != nil {
+		stats.Spilled = c.spill.spilled.Load()
+		stats.SpillFailed = c.spill.failed.Load()
+		stats.SpillDropped = c.spill.dropped.Load()
+	}
+	if c.lockProfile != nil {
+		stats.LockProfiling = true
+		stats.GlobalLockWait = c.lockProfile.global.snapshot()
+		stats.ChannelLockWait = c.lockProfile.channel.snapshot()
+	}
+	return stats
+}
+
+// ChannelStats is a point-in-time summary of a single channel.
+type ChannelStats struct {
+	Messages       int   // Messages is the number of cached messages
+	MaxMessages    int   // MaxMessages is the channel capacity
+	EstimatedBytes int64 // EstimatedBytes is the estimated memory held by the channel's messages
+}
+
+// ChannelStats returns a summary of a single channel, or ErrCacheMiss for unknown channels.
+func (c *MessageCache) ChannelStats(channelID string) (ChannelStats, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return ChannelStats{}, ErrCacheMiss
+	}
+	c.rlockChannel(cc)
+	defer cc.RUnlock()
+	return cc.stats(), nil
+}
+
+// stats summarizes the channel. The caller must hold the read lock.
+func (cc *ChannelCache) stats() ChannelStats {
+	return ChannelStats{
+		Messages:       cc.size,
+		MaxMessages:    cc.maxMessages,
+		EstimatedBytes: cc.bytes,
+	}
+}
+
+// ChannelSize is the estimated memory held by one channel.
+type ChannelSize struct {
+	ChannelID      string // ChannelID identifies the channel
+	Messages       int    // Messages is the number of cached messages
+	EstimatedBytes int64  // EstimatedBytes is the estimated memory held by the channel's messages
+}
+
+// LargestChannels returns the n channels holding the most estimated bytes, largest first.
+// Channels of equal size are ordered by channel ID. A non-positive n returns every channel.
+func (c *MessageCache) LargestChannels(n int) []ChannelSize {
+	caches := c.channelCaches()
+	sizes := make([]ChannelSize, 0, len(caches))
+	for _, cc := range caches {
+		c.rlockChannel(cc)
+		sizes = append(sizes, ChannelSize{ChannelID: cc.id, Messages: cc.size, EstimatedBytes: cc.bytes})
+		cc.RUnlock()
+	}
+	slices.SortFunc(sizes, func(a, b ChannelSize) int {
+		if c := cmp.Compare(b.EstimatedBytes, a.EstimatedBytes); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ChannelID, b.ChannelID)
+	})
+	if n > 0 && n < len(sizes) {
+		sizes = sizes[:n]
+	}
+	return sizes
+}
@@ -0,0 +1,35 @@
+package dgocacheler
+
+import "fmt"
+
+// WithStrictCapacity turns every channel into a bounded buffer that never discards messages on its own: when a
+// channel is full, adds fail with ErrChannelFull instead of evicting the oldest message, and the caller decides
+// when to export and trim. Raising the capacity with SetMaxMessages or SetChannelMaxMessages unblocks adds.
+func WithStrictCapacity() Option {
+	return func(c *MessageCache) {
+		c.strictCapacity = true
+	}
+}
+
+// ChannelFullError is returned by AddMessages when a batch hits a full channel in strict capacity mode.
+// It matches ErrChannelFull with errors.Is.
+type ChannelFullError struct {
+	ChannelID string // ChannelID is the full channel
+	Stored    int    // Stored is how many leading messages of the batch were taken; messages[Stored:] were not added
+}
+
+// Error implements error.
+func (e *ChannelFullError) Error() string {
+	return fmt.Sprintf("%v: channel %s, stored %d messages of the batch", ErrChannelFull, e.ChannelID, e.Stored)
+}
+
+// Unwrap returns ErrChannelFull.
+func (e *ChannelFullError) Unwrap() error {
+	return ErrChannelFull
+}
+
+// channelFull reports whether an add must be rejected because the channel is full in strict capacity mode.
+// The caller must hold at least the channel's read lock.
+func (c *MessageCache) channelFull(cc *ChannelCache) bool {
+	return c.strictCapacity && cc.size >= cc.maxMessages
+}
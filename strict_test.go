@@ -0,0 +1,51 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStrictCapacity(t *testing.T) {
+	cache := NewMessageCache(3, WithStrictCapacity())
+	history := testHistory(6)
+	for _, msg := range history[:3] {
+		if err := cache.AddMessage("channel1", msg); err != nil {
+			t.Fatalf("Expected message %s to fit, got %v", msg.ID, err)
+		}
+	}
+	if err := cache.AddMessage("channel1", history[3]); !errors.Is(err, ErrChannelFull) {
+		t.Errorf("Expected ErrChannelFull for the fourth message, got %v", err)
+	}
+	if err := cache.AddMessage("channel1", history[0]); err != nil {
+		t.Errorf("Duplicates of cached messages should still be skipped silently, got %v", err)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[100 101 102]" {
+		t.Errorf("Expected nothing to be evicted, got %v", got)
+	}
+
+	cache.SetMaxMessages(5)
+	if err := cache.AddMessage("channel1", history[3]); err != nil {
+		t.Errorf("Expected growing the capacity to unblock adds, got %v", err)
+	}
+}
+
+func TestStrictCapacityPartialBatch(t *testing.T) {
+	cache := NewMessageCache(4, WithStrictCapacity())
+	cache.AddMessages("channel1", testHistory(2))
+
+	batch := testHistory(6)[1:] // 101 is already cached
+	err := cache.AddMessages("channel1", batch)
+	var full *ChannelFullError
+	if !errors.As(err, &full) || !errors.Is(err, ErrChannelFull) {
+		t.Fatalf("Expected a ChannelFullError, got %v", err)
+	}
+	if full.Stored != 3 || full.ChannelID != "channel1" {
+		t.Errorf("Expected 3 batch messages taken in channel1, got %+v", full)
+	}
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[100 101 102 103]" {
+		t.Errorf("Unexpected messages after a partial batch: %v", got)
+	}
+}
@@ -0,0 +1,87 @@
+package dgocacheler
+
+import "sync"
+
+// WithSyncMapChannels mirrors the channel map into a sync.Map so that looking up an existing channel takes no
+// global lock. This favors read-heavy workloads over many channels. Creating, replacing and deleting channels
+// still serialize on the global lock, and the API is unchanged.
+func WithSyncMapChannels() Option {
+	return func(c *MessageCache) {
+		c.syncChannels = &sync.Map{}
+	}
+}
+
+// loadChannel looks up a channel in the sync.Map mirror. It must only be called when WithSyncMapChannels is set.
+func (c *MessageCache) loadChannel(channelID string) (*ChannelCache, bool) {
+	v, ok := c.syncChannels.Load(channelID)
+	if !ok {
+		return nil, false
+	}
+	return v.(*ChannelCache), true
+}
+
+// storeChannelLocked installs a channel cache under channelID. The caller must hold the global write lock.
+func (c *MessageCache) storeChannelLocked(channelID string, cc *ChannelCache) {
+	c.messages[channelID] = cc
+	c.attachMirror(cc)
+	if cc.onEvict == nil || cc.globalIDs != c.globalIDs {
+		c.lockChannel(cc)
+		cc.onEvict = c.evictHook()
+		cc.attachGlobalIDs(c.globalIDs)
+		cc.Unlock()
+	}
+	if c.crossposts != nil && cc.crossposts == nil {
+		c.lockChannel(cc)
+		cc.attachCrossposts(c.crossposts)
+		cc.Unlock()
+	}
+	if idx := c.users.Load(); cc.users != idx {
+		c.lockChannel(cc)
+		cc.attachUsers(idx)
+		cc.Unlock()
+	}
+	if l := c.lru.Load(); cc.lru != l {
+		c.lockChannel(cc)
+		cc.attachLRU(l)
+		cc.Unlock()
+	}
+	if c.syncChannels != nil {
+		c.syncChannels.Store(channelID, cc)
+	}
+}
+
+// deleteChannelLocked drops the channel cache stored under channelID from the cache and retires it. The caller
+// must hold the global write lock.
+func (c *MessageCache) deleteChannelLocked(channelID string) {
+	if cc, ok := c.messages[channelID]; ok {
+		c.lockChannel(cc)
+		cc.retired = true
+		cc.endTails()
+		cc.releaseGlobalIDs()
+		cc.attachUsers(nil)
+		cc.attachLRU(nil)
+		cc.detachMirror()
+		cc.detachCrossposts()
+		cc.Unlock()
+	}
+	c.unlinkChannelLocked(channelID)
+}
+
+// unlinkChannelLocked removes the map entry for channelID while the channel cache itself lives on, for example
+// under another ID. The caller must hold the global write lock.
+func (c *MessageCache) unlinkChannelLocked(channelID string) {
+	delete(c.messages, channelID)
+	if c.syncChannels != nil {
+		c.syncChannels.Delete(channelID)
+	}
+}
+
+// replaceChannelsLocked swaps the whole channel map for channels. The caller must hold the global write lock.
+func (c *MessageCache) replaceChannelsLocked(channels map[string]*ChannelCache) {
+	for channelID := range c.messages {
+		c.deleteChannelLocked(channelID)
+	}
+	for channelID, cc := range channels {
+		c.storeChannelLocked(channelID, cc)
+	}
+}
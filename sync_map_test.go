@@ -0,0 +1,72 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSyncMapChannels(t *testing.T) {
+	cache := NewMessageCache(10, WithSyncMapChannels())
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			channelID := fmt.Sprintf("channel%d", i%4)
+			cache.AddMessage(channelID, &discordgo.Message{ID: fmt.Sprint(i)})
+			cache.GetMessages(channelID)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 4; i++ {
+		if msgs, ok := cache.GetMessages(fmt.Sprintf("channel%d", i)); !ok || len(msgs) != 5 {
+			t.Errorf("Expected 5 messages in channel%d, got %d", i, len(msgs))
+		}
+	}
+	if stats := cache.Stats(); stats.Channels != 4 || stats.Messages != 20 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+
+	cache.SetChannelMaxMessages("channel9", 3)
+	if cc, ok := cache.loadChannel("channel9"); !ok || cc.maxMessages != 3 {
+		t.Error("Channels created by SetChannelMaxMessages must be visible through the sync.Map.")
+	}
+	if _, ok := cache.GetMessages("missing"); ok {
+		t.Error("GetMessages should report a miss for an unknown channel.")
+	}
+}
+
+func BenchmarkReadHeavyChannels(b *testing.B) {
+	const channels = 64
+	for _, bc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"rwmutex", nil},
+		{"syncmap", []Option{WithSyncMapChannels()}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			cache := NewMessageCache(50, bc.opts...)
+			ids := make([]string, channels)
+			for i := range ids {
+				ids[i] = fmt.Sprintf("channel%d", i)
+				cache.AddMessages(ids[i], testHistory(50))
+			}
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if i%100 == 0 {
+						cache.AddMessage(ids[i%channels], &discordgo.Message{ID: "1"})
+					} else {
+						cache.GetMessagesLimit(ids[i%channels], 10)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
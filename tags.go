@@ -0,0 +1,31 @@
+package dgocacheler
+
+import "slices"
+
+// SetChannelTags replaces the tags of a channel, such as "nsfw" or "guild:123", creating the channel if needed.
+// Calling it without tags removes them. Tags survive ClearChannel and ReplaceChannel but not DeleteChannel.
+func (c *MessageCache) SetChannelTags(channelID string, tags ...string) {
+	cc := c.lockChannelForAdd(channelID)
+	defer cc.Unlock()
+	cc.tags = nil
+	if len(tags) > 0 {
+		cc.tags = make(map[string]struct{}, len(tags))
+		for _, tag := range tags {
+			cc.tags[tag] = struct{}{}
+		}
+	}
+}
+
+// ChannelsWithTag returns the IDs of the channels carrying tag, in sorted order.
+func (c *MessageCache) ChannelsWithTag(tag string) []string {
+	var ids []string
+	for _, cc := range c.channelCaches() {
+		c.rlockChannel(cc)
+		if containsKey(cc.tags, tag) {
+			ids = append(ids, cc.id)
+		}
+		cc.RUnlock()
+	}
+	slices.Sort(ids)
+	return ids
+}
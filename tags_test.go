@@ -0,0 +1,37 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestChannelTags(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(2))
+	cache.SetChannelTags("channel1", "nsfw", "guild:1")
+	cache.SetChannelTags("channel2", "guild:1")
+	cache.SetChannelTags("channel3", "staff-only")
+
+	if got := fmt.Sprint(cache.ChannelsWithTag("guild:1")); got != "[channel1 channel2]" {
+		t.Errorf("Unexpected channels for guild:1: %v", got)
+	}
+	if got := cache.ChannelsWithTag("unused"); len(got) != 0 {
+		t.Errorf("Expected no channels for an unused tag, got %v", got)
+	}
+
+	cache.ClearChannel("channel1")
+	cache.ReplaceChannel("channel1", testHistory(1))
+	if got := fmt.Sprint(cache.ChannelsWithTag("nsfw")); got != "[channel1]" {
+		t.Errorf("Expected tags to survive clears and replaces, got %v", got)
+	}
+
+	cache.SetChannelTags("channel3")
+	if got := cache.ChannelsWithTag("staff-only"); len(got) != 0 {
+		t.Errorf("Expected SetChannelTags without tags to remove them, got %v", got)
+	}
+	cache.DeleteChannel("channel2")
+	cache.SetChannelTags("channel2")
+	if got := fmt.Sprint(cache.ChannelsWithTag("guild:1")); got != "[channel1]" {
+		t.Errorf("Expected DeleteChannel to drop tags, got %v", got)
+	}
+}
@@ -0,0 +1,138 @@
+package dgocacheler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// tailSubscriber queues the messages for one Tail consumer. Adds never wait for the consumer: messages are
+// queued and a forwarding goroutine delivers them.
+type tailSubscriber struct {
+	mu     sync.Mutex
+	queue  []*discordgo.Message
+	notify chan struct{}                // notify has a buffer of one and signals that the queue is non-empty
+	done   chan struct{}                // done is closed once the channel cache is retired and nothing more is queued
+	owner  atomic.Pointer[ChannelCache] // owner is the channel cache the subscriber is attached to
+}
+
+// push queues messages for delivery.
+func (s *tailSubscriber) push(msgs ...*discordgo.Message) {
+	s.mu.Lock()
+	s.queue = append(s.queue, msgs...)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take removes and returns all queued messages.
+func (s *tailSubscriber) take() []*discordgo.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msgs := s.queue
+	s.queue = nil
+	return msgs
+}
+
+// Tail streams a channel: it first emits the cached messages oldest first, then every message added afterwards,
+// until ctx is done or the channel is dropped from the cache, when the returned channel is closed. The
+// snapshot and the subscription are taken under the same channel lock, so no message is missed or repeated in
+// between. Like an add, Tail creates the channel if it is not cached, which counts towards WithMaxChannels. The
+// channel ID is resolved as AddMessage does; without one the returned channel is closed at once. Channels
+// dropped from the cache, by DeleteChannel, WithMaxChannels, ReplaceAll or loading a snapshot, end their streams
+// once the queued messages are delivered, while ReplaceChannel keeps them. Messages merged in by fetches are not
+// emitted. A slow consumer does not block adds; pending messages are queued for it instead, without a bound, so
+// a consumer that stops reading must cancel ctx.
+func (c *MessageCache) Tail(ctx context.Context, channelID string) <-chan *discordgo.Message {
+	out := make(chan *discordgo.Message)
+	channelID, err := c.resolveChannel(channelID)
+	if err != nil {
+		close(out)
+		return out
+	}
+	sub := &tailSubscriber{notify: make(chan struct{}, 1), done: make(chan struct{})}
+	cc := c.lockChannelForAdd(channelID)
+	sub.push(cc.messages()...)
+	cc.attachTail(sub)
+	cc.Unlock()
+
+	go func() {
+		defer func() {
+			c.detachTail(sub)
+			close(out)
+		}()
+		deliver := func() bool {
+			for _, msg := range sub.take() {
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		for deliver() {
+			select {
+			case <-sub.notify:
+			case <-sub.done:
+				deliver()
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// attachTail subscribes sub to the channel. The caller must hold the write lock.
+func (cc *ChannelCache) attachTail(sub *tailSubscriber) {
+	if cc.tails == nil {
+		cc.tails = make(map[*tailSubscriber]struct{})
+	}
+	cc.tails[sub] = struct{}{}
+	sub.owner.Store(cc)
+}
+
+// moveTails hands the Tail consumers of cc over to next, which nothing else can reach yet. The caller must hold
+// the write lock of cc.
+func (cc *ChannelCache) moveTails(next *ChannelCache) {
+	for sub := range cc.tails {
+		next.attachTail(sub)
+	}
+	cc.tails = nil
+}
+
+// endTails ends the streams of the channel's Tail consumers once their queued messages are delivered. The caller
+// must hold the write lock.
+func (cc *ChannelCache) endTails() {
+	for sub := range cc.tails {
+		close(sub.done)
+	}
+	cc.tails = nil
+}
+
+// detachTail unsubscribes sub from whichever channel cache it is attached to, following moves by ReplaceChannel.
+func (c *MessageCache) detachTail(sub *tailSubscriber) {
+	for {
+		cc := sub.owner.Load()
+		c.lockChannel(cc)
+		if sub.owner.Load() == cc {
+			delete(cc.tails, sub)
+			cc.Unlock()
+			return
+		}
+		cc.Unlock()
+	}
+}
+
+// notifyTails queues a newly added message for the channel's Tail consumers. The caller must hold the write lock.
+func (cc *ChannelCache) notifyTails(msg *discordgo.Message) {
+	for sub := range cc.tails {
+		sub.push(msg)
+	}
+}
@@ -0,0 +1,128 @@
+package dgocacheler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestTailNoGap(t *testing.T) {
+	cache := NewMessageCache(1000)
+	history := testHistory(200)
+	cache.AddMessages("channel1", history[:50])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for _, msg := range history[50:] {
+			cache.AddMessage("channel1", msg)
+		}
+	}()
+	tail := cache.Tail(ctx, "channel1")
+
+	for i := range history {
+		select {
+		case msg := <-tail:
+			if want := fmt.Sprint(100 + i); msg.ID != want {
+				t.Fatalf("Message %d: expected ID %s, got %s", i, want, msg.ID)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for message %d", i)
+		}
+	}
+}
+
+func TestTailStopsOnCancel(t *testing.T) {
+	cache := NewMessageCache(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	tail := cache.Tail(ctx, "channel1")
+	cache.AddMessages("channel1", testHistory(3))
+	<-tail
+	cancel()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-tail:
+			if !ok {
+				cache.messages["channel1"].RLock()
+				defer cache.messages["channel1"].RUnlock()
+				if n := len(cache.messages["channel1"].tails); n != 0 {
+					t.Errorf("Expected the subscriber to be removed, %d remain", n)
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("Tail channel was not closed after cancellation")
+		}
+	}
+}
+
+// drainTail reads tail until it is closed and returns the IDs it emitted, failing after a timeout.
+func drainTail(t *testing.T, tail <-chan *discordgo.Message) []string {
+	t.Helper()
+	var ids []string
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-tail:
+			if !ok {
+				return ids
+			}
+			ids = append(ids, msg.ID)
+		case <-timeout:
+			t.Fatalf("Tail channel was not closed, got %v", ids)
+		}
+	}
+}
+
+func TestTailEmptyChannel(t *testing.T) {
+	cache := NewMessageCache(10)
+	if ids := drainTail(t, cache.Tail(context.Background(), "")); len(ids) != 0 {
+		t.Errorf("Expected no messages, got %v", ids)
+	}
+	if n := cache.Stats().Channels; n != 0 {
+		t.Errorf("Expected no channel created, got %d", n)
+	}
+}
+
+func TestTailEndsWhenChannelDropped(t *testing.T) {
+	cache := NewMessageCache(10, WithMaxChannels(1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.AddMessages("channel1", testHistory(2))
+	deleted := cache.Tail(ctx, "channel1")
+	cache.DeleteChannel("channel1")
+	cache.AddMessage("channel1", &discordgo.Message{ID: "200"})
+	if ids := drainTail(t, deleted); fmt.Sprint(ids) != "[100 101]" {
+		t.Errorf("Expected the queued messages before the stream ends, got %v", ids)
+	}
+
+	evicted := cache.Tail(ctx, "channel1")
+	cache.AddMessage("channel2", &discordgo.Message{ID: "300"})
+	if ids := drainTail(t, evicted); fmt.Sprint(ids) != "[200]" {
+		t.Errorf("Expected an evicted channel to end its stream, got %v", ids)
+	}
+}
+
+func TestTailFollowsReplaceChannel(t *testing.T) {
+	cache := NewMessageCache(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	tail := cache.Tail(ctx, "channel1")
+	cache.ReplaceChannel("channel1", testHistory(1))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "200"})
+	if msg := <-tail; msg.ID != "200" {
+		t.Errorf("Expected adds after the replace to be streamed, got %s", msg.ID)
+	}
+
+	cancel()
+	drainTail(t, tail)
+	cache.messages["channel1"].RLock()
+	defer cache.messages["channel1"].RUnlock()
+	if n := len(cache.messages["channel1"].tails); n != 0 {
+		t.Errorf("Expected the subscriber removed from the replacement, %d remain", n)
+	}
+}
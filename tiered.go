@@ -0,0 +1,58 @@
+package dgocacheler
+
+// WithTieredRetention keeps a sparse history behind the newest messages of each channel. The newest hotSize
+// messages form the hot tier and are all kept. Messages aging out of it enter the cold tier, which keeps only
+// every sampleRate-th of them and at most coldSize in all; the others are evicted with EvictCapacity. Both tiers
+// live in the channel's one buffer, so GetMessages and every other read, lookup and count see them merged in
+// chronological order. It sets the channel capacity to hotSize + coldSize + 1, room for both tiers and the
+// message moving between them; capacities set later with SetMaxMessages or SetChannelMaxMessages still bound
+// both tiers together, trimming the cold tier first. The option is ignored unless all three values are
+// positive.
+func WithTieredRetention(hotSize, coldSize, sampleRate int) Option {
+	return func(c *MessageCache) {
+		if hotSize <= 0 || coldSize <= 0 || sampleRate <= 0 {
+			return
+		}
+		c.tiers = tierPolicy{hot: hotSize, cold: coldSize, sampleRate: sampleRate}
+		c.maxMessages = hotSize + coldSize + 1
+	}
+}
+
+// tierPolicy sizes the tiers of WithTieredRetention. The zero value disables tiering.
+type tierPolicy struct {
+	hot        int // hot is the number of newest messages always kept
+	cold       int // cold is the most older messages kept
+	sampleRate int // sampleRate keeps every sampleRate-th message leaving the hot tier
+}
+
+// tierState applies a tier policy to one channel.
+type tierState struct {
+	tierPolicy
+	aged int // aged counts the messages that left the hot tier
+}
+
+// ageTiers samples the entries that left the hot tier since the last call into the cold tier, oldest first,
+// and trims the cold tier to its size. The caller must hold the write lock.
+func (cc *ChannelCache) ageTiers() {
+	t := cc.tiers
+	if t == nil {
+		return
+	}
+	start := cc.size - t.hot
+	for start > 0 && !cc.at(start-1).cold {
+		start--
+	}
+	for i := start; i < cc.size-t.hot; {
+		if t.aged++; t.aged%t.sampleRate == 0 {
+			cc.at(i).cold = true
+			i++
+			continue
+		}
+		cc.evictAt(i, EvictCapacity)
+		cc.wrapped = true
+	}
+	for cc.size-t.hot > t.cold {
+		cc.evictAt(0, EvictCapacity)
+		cc.wrapped = true
+	}
+}
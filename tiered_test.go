@@ -0,0 +1,85 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestTieredRetentionSamplesColdTier(t *testing.T) {
+	var evicted []string
+	cache := NewMessageCache(100, WithTieredRetention(3, 3, 2), OnEvict(func(_ string, message *discordgo.Message, reason EvictReason) {
+		if reason != EvictCapacity {
+			t.Errorf("Expected reason %q, got %q", EvictCapacity, reason)
+		}
+		evicted = append(evicted, message.ID)
+	}))
+	for i := 1; i <= 12; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[4 6 8 10 11 12]" {
+		t.Errorf("Expected every second aged message behind the hot tier, got %s", got)
+	}
+	if got := fmt.Sprint(evicted); got != "[1 3 5 7 2 9]" {
+		t.Errorf("Expected the unsampled and the oldest sampled messages to be evicted, got %s", got)
+	}
+	if _, err := cache.GetMessage("channel1", "6"); err != nil {
+		t.Errorf("Expected cold messages to be found, got %v", err)
+	}
+}
+
+func TestTieredRetentionMergesTiersChronologically(t *testing.T) {
+	cache := NewMessageCache(100, WithTieredRetention(4, 10, 3))
+	for i := 1; i <= 20; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	// Removing a hot message moves the newest cold one back into the hot tier; it is not sampled again.
+	cache.RemoveMessage("channel1", "18")
+	cache.AddMessage("channel1", &discordgo.Message{ID: "21"})
+
+	msgs, _ := cache.GetMessages("channel1")
+	if got := fmt.Sprint(messageIDs(msgs)); got != "[3 6 9 12 15 17 19 20 21]" {
+		t.Errorf("Expected both tiers in chronological order, got %s", got)
+	}
+	if msgs, _ := cache.GetMessagesLimit("channel1", 4); fmt.Sprint(messageIDs(msgs)) != "[17 19 20 21]" {
+		t.Errorf("Expected the newest messages from the hot tier, got %v", messageIDs(msgs))
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 9 {
+		t.Errorf("Expected both tiers to be counted, got %d", n)
+	}
+}
+
+func TestTieredRetentionSurvivesSnapshot(t *testing.T) {
+	cache := NewMessageCache(100, WithTieredRetention(2, 5, 2))
+	for i := 1; i <= 10; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	before, _ := cache.GetMessages("channel1")
+
+	var buf bytes.Buffer
+	if _, err := cache.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	restored := NewMessageCache(100, WithTieredRetention(2, 5, 2))
+	if _, err := restored.ReadFrom(&buf); err != nil {
+		t.Fatalf("ReadFrom failed: %v", err)
+	}
+	after, _ := restored.GetMessages("channel1")
+	if fmt.Sprint(messageIDs(after)) != fmt.Sprint(messageIDs(before)) {
+		t.Errorf("Expected the cold tier not to be sampled again, got %v, want %v", messageIDs(after), messageIDs(before))
+	}
+}
+
+func TestTieredRetentionIgnoresInvalidSizes(t *testing.T) {
+	cache := NewMessageCache(5, WithTieredRetention(3, 0, 2))
+	for i := 1; i <= 8; i++ {
+		cache.AddMessage("channel1", &discordgo.Message{ID: fmt.Sprint(i)})
+	}
+	if msgs, _ := cache.GetMessages("channel1"); fmt.Sprint(messageIDs(msgs)) != "[4 5 6 7 8]" {
+		t.Errorf("Expected plain retention, got %v", messageIDs(msgs))
+	}
+}
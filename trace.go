@@ -0,0 +1,50 @@
+package dgocacheler
+
+import (
+	"context"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// traceIDKey is the context key of the trace ID set by ContextWithTraceID.
+type traceIDKey struct{}
+
+// ContextWithTraceID returns a copy of ctx carrying traceID, which AddMessageTraced attaches to its logs as
+// the "trace_id" attribute.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID set by ContextWithTraceID, or the empty string.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// AddMessageTraced is like AddMessage but correlates its logs with a request: when ctx carries a trace ID set by
+// ContextWithTraceID and WithLogger is configured, every log record emitted while adding the message carries it,
+// and the outcome of the add is logged at debug level. Without a logger or trace ID it behaves like AddMessage.
+// The SetSpanStarter span is started from ctx.
+func (c *MessageCache) AddMessageTraced(ctx context.Context, channelID string, message *discordgo.Message) (err error) {
+	if end := c.traceAdd(ctx, "AddMessageTraced"); end != nil {
+		defer func() { end(err) }()
+	}
+	channelID, err = c.resolveChannel(channelID)
+	if err != nil {
+		return err
+	}
+	traceID := TraceIDFromContext(ctx)
+	if traceID == "" || c.logger == nil {
+		_, _, err = c.addMessage(channelID, message, 0, addCall{source: "AddMessageTraced", logger: c.logger})
+		return err
+	}
+	logger := c.logger.With("trace_id", traceID)
+	outcome, held, err := c.addMessage(channelID, message, 0, addCall{source: "AddMessageTraced", logger: logger})
+	switch {
+	case held:
+		logger.DebugContext(ctx, "dgocacheler: message held while paused", "channel_id", channelID)
+	case message != nil:
+		logger.DebugContext(ctx, "dgocacheler: added message", "channel_id", channelID, "message_id", message.ID, "outcome", outcome.String(), "error", err)
+	}
+	return err
+}
@@ -0,0 +1,73 @@
+package dgocacheler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAddMessageTraced(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cache := NewMessageCache(5, WithLogger(logger), WithDuplicateTracking(1))
+	ctx := ContextWithTraceID(context.Background(), "trace-123")
+
+	if err := cache.AddMessageTraced(ctx, "channel1", &discordgo.Message{ID: "1"}); err != nil {
+		t.Fatalf("AddMessageTraced returned error: %v", err)
+	}
+	cache.AddMessageTraced(ctx, "channel1", &discordgo.Message{ID: "1"})
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected an outcome line per add and a duplicate line, got %q", logs.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "trace_id=trace-123") {
+			t.Errorf("Expected the trace ID in %q", line)
+		}
+	}
+	if !strings.Contains(logs.String(), "dropped duplicate message") || !strings.Contains(lines[0], "outcome=stored") {
+		t.Errorf("Unexpected log records: %q", logs.String())
+	}
+
+	logs.Reset()
+	cache.AddMessageTraced(context.Background(), "channel1", &discordgo.Message{ID: "2"})
+	if logs.Len() != 0 {
+		t.Errorf("Expected no logs without a trace ID, got %q", logs.String())
+	}
+	if n, _ := cache.MessageCount("channel1"); n != 2 {
+		t.Errorf("Expected 2 messages, got %d", n)
+	}
+}
+
+func TestAddMessageTracedSpan(t *testing.T) {
+	type ctxKey struct{}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	for _, cache := range []*MessageCache{NewMessageCache(5), NewMessageCache(5, WithLogger(logger))} {
+		var ops, values []string
+		var errs []error
+		cache.SetSpanStarter(func(ctx context.Context, op string) func(err error) {
+			ops = append(ops, op)
+			values = append(values, ctx.Value(ctxKey{}).(string))
+			return func(err error) { errs = append(errs, err) }
+		})
+		ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+		cache.AddMessageTraced(ctx, "channel1", &discordgo.Message{ID: "1"})
+		cache.AddMessageTraced(ContextWithTraceID(ctx, "trace-123"), "", &discordgo.Message{ID: "2"})
+
+		if len(ops) != 2 || ops[0] != "cache.AddMessageTraced" || ops[1] != "cache.AddMessageTraced" {
+			t.Fatalf("Expected an AddMessageTraced span per add, got %v", ops)
+		}
+		if values[0] != "request" || values[1] != "request" {
+			t.Errorf("Expected the spans started from the caller's context, got %v", values)
+		}
+		if len(errs) != 2 || errs[0] != nil || !errors.Is(errs[1], ErrInvalidChannel) {
+			t.Errorf("Expected the add errors passed to the finisher, got %v", errs)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package dgocacheler
+
+import "context"
+
+// Tracer starts spans around the slower, context-accepting cache operations. It is deliberately minimal so any
+// tracing library can be adapted to it; see the contrib/otel module for an OpenTelemetry adapter.
+// Hot-path operations such as AddMessage and GetMessages are never traced; SetSpanStarter also covers adds.
+type Tracer interface {
+	// Start begins a span named name as a child of any span in ctx.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is the part of a tracing span the cache uses.
+type Span interface {
+	// SetAttribute records a string or int attribute on the span.
+	SetAttribute(key string, value any)
+	// RecordError marks the span as failed with err.
+	RecordError(err error)
+	// End completes the span.
+	End()
+}
+
+// Span attribute keys set by the cache.
+const (
+	AttrChannelID   = "dgocacheler.channel_id"   // AttrChannelID is the channel an operation works on
+	AttrResultCount = "dgocacheler.result_count" // AttrResultCount is the number of messages returned or stored
+)
+
+// WithTracer enables tracing of the slower, context-accepting cache operations.
+func WithTracer(tracer Tracer) Option {
+	return func(c *MessageCache) {
+		c.tracer = tracer
+	}
+}
+
+// spanHook is a span starter set by SetSpanStarter.
+type spanHook func(ctx context.Context, op string) func(err error)
+
+// SetSpanStarter hooks a tracing backend into the cache without implementing Tracer. Every operation traced
+// with a Tracer, and every add (AddMessage, AddMessageTraced, TryAddMessage, AddMessageWithPriority,
+// AddMessages, AddMessagesReport and PrependMessages), calls fn with the operation's context and a name such as
+// "cache.AddMessage" when it starts, and the returned function with the operation's error, nil on success, when
+// it ends. AddMessageTraced passes its ctx; the other adds have no context and pass context.Background(). fn
+// runs on the calling goroutine, so it must be fast. A nil fn, the default, removes the hook. It works alongside
+// WithTracer.
+func (c *MessageCache) SetSpanStarter(fn func(ctx context.Context, op string) func(err error)) {
+	if fn == nil {
+		c.spans.Store(nil)
+		return
+	}
+	hook := spanHook(fn)
+	c.spans.Store(&hook)
+}
+
+// startSpan starts a span named dgocacheler.<op> with the tracer and one named cache.<op> with the span
+// starter, returning a no-op span when neither is configured.
+func (c *MessageCache) startSpan(ctx context.Context, op string) (context.Context, Span) {
+	ctx, span := c.startTracerSpan(ctx, op)
+	if hook := c.spans.Load(); hook != nil {
+		span = c.startHookSpan(ctx, *hook, op, span)
+	}
+	return ctx, span
+}
+
+// traceAdd starts a span for an add method with the span starter, returning the function ending it, or nil
+// when no span starter is set. Adds are not traced with the tracer.
+func (c *MessageCache) traceAdd(ctx context.Context, op string) func(error) {
+	hook := c.spans.Load()
+	if hook == nil {
+		return nil
+	}
+	span := c.startHookSpan(ctx, *hook, op, noopSpan{})
+	return func(err error) { endSpan(span, err) }
+}
+
+// startHookSpan starts a span with the span starter, wrapping the tracer's span for the same operation.
+func (c *MessageCache) startHookSpan(ctx context.Context, hook spanHook, op string, span Span) Span {
+	s := &hookSpan{Span: span, c: c}
+	if err := c.guard("SpanStarter", func() { s.finish = hook(ctx, "cache."+op) }); err != nil {
+		s.finish = nil
+	}
+	return s
+}
+
+// hookSpan is a span of the span starter, ended together with the tracer span it wraps.
+type hookSpan struct {
+	Span               // Span is the tracer's span, a no-op span without a tracer
+	finish func(error) // finish ends the span starter's span, nil if the starter returned none
+	err    error       // err is the error recorded for the operation
+	c      *MessageCache
+}
+
+func (s *hookSpan) RecordError(err error) {
+	s.err = err
+	s.Span.RecordError(err)
+}
+
+func (s *hookSpan) End() {
+	if s.finish != nil {
+		s.c.guard("SpanStarter", func() { s.finish(s.err) })
+	}
+	s.Span.End()
+}
+
+// startTracerSpan starts a span named dgocacheler.<op>, or returns a no-op span when no tracer is configured.
+func (c *MessageCache) startTracerSpan(ctx context.Context, op string) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	spanCtx, span := ctx, Span(noopSpan{})
+	if err := c.guard("Tracer", func() { spanCtx, span = c.tracer.Start(ctx, "dgocacheler."+op) }); err != nil {
+		return ctx, noopSpan{}
+	}
+	return spanCtx, guardedSpan{span: span, c: c}
+}
+
+// guardedSpan runs the methods of a tracer's span under the cache's callback panic recovery.
+type guardedSpan struct {
+	span Span
+	c    *MessageCache
+}
+
+func (s guardedSpan) SetAttribute(key string, value any) {
+	s.c.guard("Tracer", func() { s.span.SetAttribute(key, value) })
+}
+
+func (s guardedSpan) RecordError(err error) {
+	s.c.guard("Tracer", func() { s.span.RecordError(err) })
+}
+
+func (s guardedSpan) End() {
+	s.c.guard("Tracer", func() { s.span.End() })
+}
+
+// endSpan records err, if any, and ends the span.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// noopSpan is the Span used when tracing is disabled.
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, any) {}
+func (noopSpan) RecordError(error)        {}
+func (noopSpan) End()                     {}
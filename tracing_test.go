@@ -0,0 +1,122 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// recordingTracer is a Tracer that keeps every span it starts.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+	ended bool
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	span := &recordedSpan{name: name, attrs: map[string]any{}}
+	r.spans = append(r.spans, span)
+	return ctx, span
+}
+
+func (s *recordedSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *recordedSpan) RecordError(err error)              { s.err = err }
+func (s *recordedSpan) End()                               { s.ended = true }
+
+func TestTracingLatestOrFetch(t *testing.T) {
+	tracer := &recordingTracer{}
+	loader := &fakeLoader{history: testHistory(5)}
+	cache := NewMessageCache(10, WithTracer(tracer), WithLoader(loader.load))
+
+	if _, err := cache.LatestOrFetch("channel1", 3); err != nil {
+		t.Fatalf("LatestOrFetch returned error: %v", err)
+	}
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Expected one span, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "dgocacheler.LatestOrFetch" || !span.ended {
+		t.Errorf("Unexpected span %q (ended %v)", span.name, span.ended)
+	}
+	if span.attrs[AttrChannelID] != "channel1" || span.attrs[AttrResultCount] != 3 {
+		t.Errorf("Unexpected span attributes: %v", span.attrs)
+	}
+}
+
+func TestTracingRecordsErrors(t *testing.T) {
+	tracer := &recordingTracer{}
+	errFetch := errors.New("fetch failed")
+	loader := &fakeLoader{err: errFetch}
+	cache := NewMessageCache(10, WithTracer(tracer), WithLoader(loader.load))
+
+	cache.LatestOrFetch("channel1", 3)
+	if len(tracer.spans) != 1 || !errors.Is(tracer.spans[0].err, errFetch) {
+		t.Errorf("Expected the fetch error to be recorded on the span")
+	}
+}
+
+func TestTracingSkipsHotPath(t *testing.T) {
+	tracer := &recordingTracer{}
+	cache := NewMessageCache(10, WithTracer(tracer))
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.GetMessages("channel1")
+	cache.GetMessagesLimit("channel1", 1)
+	if len(tracer.spans) != 0 {
+		t.Errorf("Hot-path operations must not create spans, got %d", len(tracer.spans))
+	}
+
+	cache.SetMaxMessages(5)
+	if len(tracer.spans) != 1 || tracer.spans[0].name != "dgocacheler.SetMaxMessages" {
+		t.Errorf("Expected a SetMaxMessages span")
+	}
+}
+
+func TestSetSpanStarter(t *testing.T) {
+	tracer := &recordingTracer{}
+	cache := NewMessageCache(10, WithTracer(tracer))
+	var started, finished []string
+	var errs []error
+	cache.SetSpanStarter(func(ctx context.Context, op string) func(err error) {
+		started = append(started, op)
+		return func(err error) {
+			finished = append(finished, op)
+			errs = append(errs, err)
+		}
+	})
+
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.SealChannel("channel1")
+	cache.AddMessage("channel1", &discordgo.Message{ID: "2"})
+	cache.AddMessages("channel1", []*discordgo.Message{{ID: "3"}})
+	if fmt.Sprint(started) != "[cache.AddMessage cache.AddMessage cache.AddMessages]" || len(finished) != len(started) {
+		t.Fatalf("Unexpected spans started %v, finished %v", started, finished)
+	}
+	if errs[0] != nil || !errors.Is(errs[1], ErrChannelSealed) || !errors.Is(errs[2], ErrChannelSealed) {
+		t.Errorf("Expected the add errors passed to the finisher, got %v", errs)
+	}
+	if len(tracer.spans) != 0 {
+		t.Errorf("Adds must not create tracer spans, got %d", len(tracer.spans))
+	}
+	cache.SetMaxMessages(5)
+	if started[len(started)-1] != "cache.SetMaxMessages" || len(tracer.spans) != 1 || !tracer.spans[0].ended {
+		t.Errorf("Expected traced operations to start both spans, got %v", started)
+	}
+
+	cache.SetSpanStarter(nil)
+	cache.AddMessage("channel2", &discordgo.Message{ID: "1"})
+	if len(started) != 4 || len(finished) != 4 {
+		t.Errorf("Expected no spans once the starter is removed, got %v", started)
+	}
+}
@@ -0,0 +1,43 @@
+package dgocacheler
+
+import "github.com/bwmarrin/discordgo"
+
+// GetUnread returns the cached messages of a channel that a consumer has not read yet, in chronological
+// order, and marks them read, so that several consumers can each work through a channel at their own pace.
+// A consumer's position is the last message GetUnread returned it; a consumer reading a channel for the first
+// time gets every cached message. If that message was removed while newer and older ones are still cached,
+// reading resumes after the messages with lower IDs. If it was evicted, every cached message is returned
+// together with ErrReadPositionEvicted. Positions live with the channel and are dropped with it. It returns
+// ErrCacheMiss for unknown channels.
+func (c *MessageCache) GetUnread(channelID, consumerID string) ([]*discordgo.Message, error) {
+	cc, ok := c.channelCache(channelID)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	c.lockChannel(cc)
+	defer cc.Unlock()
+
+	start := 0
+	var err error
+	if last, ok := cc.readers[consumerID]; ok && cc.size > 0 {
+		switch i := cc.find(last); {
+		case i >= 0:
+			start = i + 1
+		case c.compareIDs(cc.at(0).message.ID, last) < 0:
+			for start < cc.size && c.compareIDs(cc.at(start).message.ID, last) <= 0 {
+				start++
+			}
+		default:
+			err = ErrReadPositionEvicted
+		}
+	}
+	msgs := cc.window(start, cc.size-start)
+	cc.touch(start, len(msgs))
+	if cc.size > 0 {
+		if cc.readers == nil {
+			cc.readers = make(map[string]string)
+		}
+		cc.readers[consumerID] = cc.at(cc.size - 1).message.ID
+	}
+	return msgs, err
+}
@@ -0,0 +1,44 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestGetUnread(t *testing.T) {
+	cache := NewMessageCache(5)
+	if _, err := cache.GetUnread("channel1", "alice"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+	history := testHistory(12) // 100..111
+	cache.AddMessages("channel1", history[:3])
+
+	unread := func(consumerID, want string, wantErr error) {
+		t.Helper()
+		msgs, err := cache.GetUnread("channel1", consumerID)
+		if got := fmt.Sprint(messageIDs(msgs)); got != want || !errors.Is(err, wantErr) {
+			t.Errorf("GetUnread(%s) = %v, %v, want %v, %v", consumerID, got, err, want, wantErr)
+		}
+	}
+	unread("alice", "[100 101 102]", nil)
+	unread("alice", "[]", nil)
+
+	cache.AddMessages("channel1", history[3:5])
+	unread("bob", "[100 101 102 103 104]", nil)
+	unread("alice", "[103 104]", nil)
+
+	cache.AddMessages("channel1", history[5:7])
+	cache.RemoveMessage("channel1", "106")
+	unread("bob", "[105]", nil)
+	unread("bob", "[]", nil)
+
+	cache.AddMessages("channel1", history[7:8])
+	cache.RemoveMessage("channel1", "105")
+	unread("bob", "[107]", nil)
+
+	cache.AddMessages("channel1", history[8:12])
+	unread("alice", "[107 108 109 110 111]", ErrReadPositionEvicted)
+	unread("alice", "[]", nil)
+	unread("bob", "[108 109 110 111]", nil)
+}
@@ -0,0 +1,165 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Update runs fn with a view of every cached channel, keyed by channel ID, while holding the global write lock
+// and every channel lock, so that coordinated changes across channels, such as rebalancing capacities, happen in
+// one atomic step: no other goroutine observes the cache in between. The views read and change their channels
+// without locking and are only valid until fn returns; later calls return ErrViewClosed.
+//
+// fn must not call any MessageCache method, directly or from callbacks it triggers such as OnEvict: every lock
+// is already held, so doing so deadlocks. Changes that fail, for example on frozen channels, return their error
+// from the view method, and Update returns the first failure of each channel as *ChannelError values joined
+// with errors.Join. Channels cannot be added or removed through the map.
+func (c *MessageCache) Update(fn func(channels map[string]*ChannelView)) error {
+	c.lockGlobal()
+	defer c.Unlock()
+	views := make(map[string]*ChannelView, len(c.messages))
+	for _, channelID := range slices.Sorted(maps.Keys(c.messages)) {
+		cc := c.messages[channelID]
+		c.lockChannel(cc)
+		defer cc.Unlock()
+		views[channelID] = &ChannelView{cache: c, cc: cc, channelID: channelID}
+	}
+	defer func() {
+		for _, view := range views {
+			view.closed = true
+		}
+	}()
+	fn(maps.Clone(views))
+
+	failed := make(map[string]error)
+	for channelID, view := range views {
+		if view.err != nil {
+			failed[channelID] = view.err
+		}
+	}
+	return joinChannelErrors(failed)
+}
+
+// ChannelView gives the function passed to Update access to one channel. It must not be retained or shared
+// with other goroutines.
+type ChannelView struct {
+	cache     *MessageCache
+	cc        *ChannelCache
+	channelID string
+	err       error // err is the first failed change, reported by Update
+	closed    bool  // closed is set once Update returned
+}
+
+// ChannelID returns the ID of the channel.
+func (v *ChannelView) ChannelID() string {
+	return v.channelID
+}
+
+// Len returns the number of cached messages, or 0 once the view is closed.
+func (v *ChannelView) Len() int {
+	if v.closed {
+		return 0
+	}
+	return v.cc.size
+}
+
+// MaxMessages returns the channel capacity, or 0 once the view is closed.
+func (v *ChannelView) MaxMessages() int {
+	if v.closed {
+		return 0
+	}
+	return v.cc.maxMessages
+}
+
+// Frozen reports whether the channel is frozen, in which case changes fail with ErrChannelFrozen.
+func (v *ChannelView) Frozen() bool {
+	return v.cc.frozen.Load()
+}
+
+// Messages returns the cached messages in chronological order, or nil once the view is closed.
+func (v *ChannelView) Messages() []*discordgo.Message {
+	if v.closed {
+		return nil
+	}
+	return v.cc.messages()
+}
+
+// SetMaxMessages sets the channel capacity as SetChannelMaxMessages does, evicting the oldest messages if it
+// shrinks. It returns ErrInvalidMaxMessages if maxMessages is not positive.
+func (v *ChannelView) SetMaxMessages(maxMessages int) error {
+	if maxMessages <= 0 {
+		return v.fail(fmt.Errorf("%w: %d", ErrInvalidMaxMessages, maxMessages))
+	}
+	if err := v.writable(); err != nil {
+		return err
+	}
+	before := v.cc.size
+	v.cc.customMax = true
+	v.cc.setMaxMessages(maxMessages)
+	v.cache.auditRemoval("Update", v.cc, before-v.cc.size)
+	return nil
+}
+
+// EvictOldest evicts the n oldest messages, or all of them if the channel holds fewer, as EvictOldest does, and
+// returns how many were evicted.
+func (v *ChannelView) EvictOldest(n int) (int, error) {
+	if n <= 0 {
+		return 0, v.fail(ErrInvalidLimit)
+	}
+	if err := v.writable(); err != nil {
+		return 0, err
+	}
+	evicted := min(n, v.cc.size)
+	for i := 0; i < evicted; i++ {
+		v.cc.evictAt(0, EvictManual)
+	}
+	v.cache.auditRemoval("Update", v.cc, evicted)
+	return evicted, nil
+}
+
+// Remove removes one message, returning ErrMessageNotFound if it is not cached.
+func (v *ChannelView) Remove(messageID string) error {
+	if err := v.writable(); err != nil {
+		return err
+	}
+	i := v.cc.find(messageID)
+	if i < 0 {
+		return v.fail(ErrMessageNotFound)
+	}
+	v.cache.auditMessage("Update", v.cc, v.cc.removeAt(i))
+	return nil
+}
+
+// Clear removes all messages and counters of the channel, as ClearChannel does.
+func (v *ChannelView) Clear() error {
+	if err := v.writable(); err != nil {
+		return err
+	}
+	v.cache.clearLocked("Update", v.cc)
+	return nil
+}
+
+// writable returns the error a change must fail with: ErrViewClosed once Update returned, ErrChannelFrozen or
+// ErrChannelSealed.
+func (v *ChannelView) writable() error {
+	switch {
+	case v.closed:
+		return ErrViewClosed
+	case v.cc.frozen.Load():
+		return v.fail(ErrChannelFrozen)
+	case v.cc.sealed:
+		return v.fail(ErrChannelSealed)
+	}
+	return nil
+}
+
+// fail records err as a failed change of the channel, unless an earlier one was recorded, and returns it.
+func (v *ChannelView) fail(err error) error {
+	if v.err == nil && !v.closed {
+		v.err = err
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestUpdateRebalancesCapacities(t *testing.T) {
+	const budget = 12
+	cache := NewMessageCache(10)
+	for i := 0; i < 10; i++ {
+		cache.AddMessage("busy", &discordgo.Message{ID: fmt.Sprint(100 + i)})
+	}
+	cache.AddMessage("quiet1", &discordgo.Message{ID: "200"})
+	cache.AddMessage("quiet2", &discordgo.Message{ID: "300"})
+
+	// Concurrent readers must never see the cache mid-rebalance, with some channels shrunk and others not.
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			total := 0
+			cache.Update(func(channels map[string]*ChannelView) {
+				for _, view := range channels {
+					total += view.MaxMessages()
+				}
+			})
+			if total != 30 && total != budget {
+				t.Errorf("Observed a partial rebalance with total capacity %d", total)
+				return
+			}
+		}
+	}()
+
+	err := cache.Update(func(channels map[string]*ChannelView) {
+		messages := 0
+		for _, view := range channels {
+			messages += view.Len()
+		}
+		for _, view := range channels {
+			view.SetMaxMessages(max(1, budget*view.Len()/messages))
+		}
+	})
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if stats, _ := cache.ChannelStats("busy"); stats.MaxMessages != 10 || stats.Messages != 10 {
+		t.Errorf("Expected the busy channel to keep 10 messages, got %+v", stats)
+	}
+	if stats, _ := cache.ChannelStats("quiet1"); stats.MaxMessages != 1 {
+		t.Errorf("Expected quiet channels to shrink to 1, got %+v", stats)
+	}
+}
+
+func TestUpdateReportsFailedChanges(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessage("channel1", &discordgo.Message{ID: "1"})
+	cache.AddMessage("channel2", &discordgo.Message{ID: "2"})
+	cache.AddMessage("channel2", &discordgo.Message{ID: "3"})
+	cache.FreezeChannel("channel1")
+
+	var leaked *ChannelView
+	err := cache.Update(func(channels map[string]*ChannelView) {
+		if err := channels["channel1"].Clear(); !errors.Is(err, ErrChannelFrozen) {
+			t.Errorf("Expected ErrChannelFrozen from the view, got %v", err)
+		}
+		if err := channels["channel2"].Remove("2"); err != nil {
+			t.Errorf("Remove failed: %v", err)
+		}
+		if n, err := channels["channel2"].EvictOldest(5); n != 1 || err != nil {
+			t.Errorf("Expected 1 message evicted, got %d, %v", n, err)
+		}
+		leaked = channels["channel2"]
+	})
+	var channelErr *ChannelError
+	if !errors.As(err, &channelErr) || channelErr.ChannelID != "channel1" || !errors.Is(err, ErrChannelFrozen) {
+		t.Errorf("Expected Update to report the frozen channel, got %v", err)
+	}
+	if msgs, _ := cache.GetMessages("channel2"); len(msgs) != 0 {
+		t.Errorf("Expected channel2 to be emptied, got %v", messageIDs(msgs))
+	}
+	if err := leaked.Clear(); !errors.Is(err, ErrViewClosed) {
+		t.Errorf("Expected ErrViewClosed after Update returned, got %v", err)
+	}
+	if leaked.Messages() != nil || leaked.ChannelID() != "channel2" {
+		t.Error("Expected a closed view to stop reading its channel")
+	}
+}
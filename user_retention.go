@@ -0,0 +1,182 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// SetPerUserRetention caps how many messages of one user the cache retains across all channels, regardless of
+// the channel capacities, for example to meet a privacy requirement. When an add pushes an author over n
+// cached messages, the author's oldest messages are evicted wherever they live, reported to OnEvict with
+// EvictUserRetention, before the adding call returns. Lowering n evicts right away. Messages in frozen
+// channels count toward the cap but are never evicted; the oldest evictable ones go instead. Messages without
+// an author are not limited. n <= 0 removes the cap.
+//
+// The cap is backed by an index of every cached message per author, costing roughly 50 bytes per message.
+func (c *MessageCache) SetPerUserRetention(n int) {
+	c.lockGlobal()
+	idx := c.users.Load()
+	switch {
+	case n <= 0 && idx != nil:
+		for _, cc := range c.messages {
+			c.lockChannel(cc)
+			cc.users = nil
+			cc.Unlock()
+		}
+		c.users.Store(nil)
+	case n > 0 && idx == nil:
+		idx = &userIndex{refs: make(map[string][]userRef), over: make(map[string]struct{})}
+		for _, cc := range c.messages {
+			c.lockChannel(cc)
+			cc.attachUsers(idx)
+			cc.Unlock()
+		}
+		c.users.Store(idx)
+	}
+	if n > 0 {
+		idx.setLimit(n)
+	}
+	c.Unlock()
+	c.enforceGlobalCaps()
+}
+
+// userIndex lists the cached messages of each author across channels in insertion order. Its lock is only
+// ever taken last, so it may be acquired while holding the global or a channel lock.
+type userIndex struct {
+	mu    sync.Mutex
+	limit int                  // limit is the number of messages retained per author
+	refs  map[string][]userRef // refs holds each author's messages ordered by insertion sequence
+	over  map[string]struct{}  // over holds the authors that may have more than limit messages
+}
+
+// userRef locates one cached message of an author.
+type userRef struct {
+	seq       uint64        // seq is the insertion sequence of the entry
+	messageID string        // messageID is the ID of the message
+	channel   *ChannelCache // channel holds the message, whatever ID it is stored under
+}
+
+// update adds (sign 1) or removes (sign -1) an entry of cc.
+func (x *userIndex) update(cc *ChannelCache, entry cachedMessage, sign int) {
+	author := messageAuthorID(entry.message)
+	if author == "" {
+		return
+	}
+	ref := userRef{seq: entry.insertSeq, messageID: entry.message.ID, channel: cc}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	refs := x.refs[author]
+	i, _ := slices.BinarySearchFunc(refs, ref.seq, func(r userRef, seq uint64) int { return cmp.Compare(r.seq, seq) })
+	if sign > 0 {
+		for i < len(refs) && refs[i].seq == ref.seq {
+			i++
+		}
+		x.refs[author] = slices.Insert(refs, i, ref)
+		if len(refs)+1 > x.limit {
+			x.over[author] = struct{}{}
+		}
+		return
+	}
+	for ; i < len(refs) && refs[i].seq == ref.seq; i++ {
+		if refs[i] == ref {
+			if refs = slices.Delete(refs, i, i+1); len(refs) == 0 {
+				delete(x.refs, author)
+			} else {
+				x.refs[author] = refs
+			}
+			return
+		}
+	}
+}
+
+// setLimit changes the per-author limit, flagging the authors now over it.
+func (x *userIndex) setLimit(n int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.limit = n
+	x.flagOverLocked()
+}
+
+// recheck flags every author over the limit again, for when messages that could not be evicted become evictable.
+func (x *userIndex) recheck() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.flagOverLocked()
+}
+
+// flagOverLocked flags the authors over the limit. The caller must hold x.mu.
+func (x *userIndex) flagOverLocked() {
+	for author, refs := range x.refs {
+		if len(refs) > x.limit {
+			x.over[author] = struct{}{}
+		}
+	}
+}
+
+// nextOver returns an author flagged as over the limit with a copy of the author's references, oldest first,
+// and how many of them are in excess. Flags of authors back within the limit are cleared on the way.
+func (x *userIndex) nextOver() (author string, refs []userRef, excess int, ok bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for author := range x.over {
+		refs := x.refs[author]
+		if len(refs) > x.limit {
+			return author, slices.Clone(refs), len(refs) - x.limit, true
+		}
+		delete(x.over, author)
+	}
+	return "", nil, 0, false
+}
+
+// settle clears the over-limit flag of an author whose excess messages cannot be evicted.
+func (x *userIndex) settle(author string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	delete(x.over, author)
+}
+
+// attachUsers moves the channel's entries from its current user index, if any, to idx, which may be nil.
+// Channels join the index when they are stored in the cache and leave it when they are dropped, so channels
+// being built off to the side never count. The caller must hold the global and the channel's write lock.
+func (cc *ChannelCache) attachUsers(idx *userIndex) {
+	if cc.users == idx {
+		return
+	}
+	for i := 0; i < cc.size; i++ {
+		if cc.users != nil {
+			cc.users.update(cc, *cc.at(i), -1)
+		}
+		if idx != nil {
+			idx.update(cc, *cc.at(i), 1)
+		}
+	}
+	cc.users = idx
+}
+
+// enforceUserRetention evicts the oldest messages of every author over the per-user cap. It must be called
+// without holding any cache lock.
+func (c *MessageCache) enforceUserRetention() {
+	idx := c.users.Load()
+	if idx == nil {
+		return
+	}
+	for {
+		author, refs, excess, ok := idx.nextOver()
+		if !ok {
+			return
+		}
+		evicted := 0
+		for _, ref := range refs {
+			if evicted == excess {
+				break
+			}
+			if c.evictEntry(ref.channel, ref.seq, ref.messageID, EvictUserRetention) {
+				evicted++
+			}
+		}
+		if evicted == 0 {
+			idx.settle(author) // only frozen channels hold the excess
+		}
+	}
+}
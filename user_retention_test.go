@@ -0,0 +1,204 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPerUserRetentionEvictsAcrossChannels(t *testing.T) {
+	var evicted []string
+	cache := NewMessageCache(10, OnEvict(func(channelID string, message *discordgo.Message, reason EvictReason) {
+		if reason != EvictUserRetention {
+			t.Errorf("Expected reason %q, got %q", EvictUserRetention, reason)
+		}
+		evicted = append(evicted, channelID+"/"+message.ID)
+	}))
+	cache.SetPerUserRetention(2)
+
+	cache.AddMessage("channel1", authoredMessage("1", "alice", "a"))
+	cache.AddMessage("channel2", authoredMessage("2", "alice", "b"))
+	cache.AddMessage("channel1", authoredMessage("3", "bob", "c"))
+	cache.AddMessage("channel3", authoredMessage("4", "alice", "d"))
+
+	if !slices.Equal(evicted, []string{"channel1/1"}) {
+		t.Errorf("Expected alice's oldest message to be evicted, got %v", evicted)
+	}
+	if _, err := cache.GetMessage("channel1", "1"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected the evicted message to be gone, got %v", err)
+	}
+	if _, err := cache.GetMessage("channel1", "3"); err != nil {
+		t.Errorf("Expected bob's message to be kept, got %v", err)
+	}
+}
+
+func TestPerUserRetentionLoweringEvictsImmediately(t *testing.T) {
+	cache := NewMessageCache(10)
+	for i := 1; i <= 6; i++ {
+		cache.AddMessage(fmt.Sprintf("channel%d", i%3), authoredMessage(fmt.Sprint(i), "alice", "hi"))
+	}
+	cache.SetPerUserRetention(4)
+	if got := cache.Stats().Messages; got != 4 {
+		t.Errorf("Expected 4 messages after setting the cap, got %d", got)
+	}
+	cache.SetPerUserRetention(1)
+	if _, err := cache.GetMessage("channel0", "6"); err != nil {
+		t.Errorf("Expected the newest message to survive, got %v", err)
+	}
+	if got := cache.Stats().Messages; got != 1 {
+		t.Errorf("Expected 1 message after lowering the cap, got %d", got)
+	}
+
+	cache.SetPerUserRetention(0)
+	cache.AddMessage("channel1", authoredMessage("7", "alice", "hi"))
+	cache.AddMessage("channel2", authoredMessage("8", "alice", "hi"))
+	if got := cache.Stats().Messages; got != 3 {
+		t.Errorf("Expected no cap after removing it, got %d messages", got)
+	}
+}
+
+func TestPerUserRetentionSkipsFrozenChannels(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.SetPerUserRetention(2)
+	cache.AddMessage("frozen", authoredMessage("1", "alice", "a"))
+	cache.AddMessage("frozen", authoredMessage("2", "alice", "b"))
+	cache.FreezeChannel("frozen")
+
+	cache.AddMessage("channel1", authoredMessage("3", "alice", "c"))
+	if got, _ := cache.GetMessages("frozen"); len(got) != 2 {
+		t.Errorf("Expected the frozen channel to keep its messages, got %d", len(got))
+	}
+	if _, err := cache.GetMessage("channel1", "3"); !errors.Is(err, ErrMessageNotFound) {
+		t.Errorf("Expected the newest evictable message to go instead, got %v", err)
+	}
+}
+
+func TestPerUserRetentionMatchesBruteForce(t *testing.T) {
+	const limit = 5
+	rng := rand.New(rand.NewSource(1))
+	cache := NewMessageCache(8)
+	cache.SetPerUserRetention(limit)
+	channels := []string{"c0", "c1", "c2", "c3"}
+	authors := []string{"a0", "a1", "a2", "a3", "a4", ""}
+	nextID := 0
+	message := func() *discordgo.Message {
+		nextID++
+		m := &discordgo.Message{ID: fmt.Sprint(nextID), Content: "hello"}
+		if author := authors[rng.Intn(len(authors))]; author != "" {
+			m.Author = &discordgo.User{ID: author}
+		}
+		return m
+	}
+	someMessage := func(channelID string) (*discordgo.Message, bool) {
+		msgs, _ := cache.GetMessages(channelID)
+		if len(msgs) == 0 {
+			return nil, false
+		}
+		return msgs[rng.Intn(len(msgs))], true
+	}
+
+	for op := 0; op < 5000; op++ {
+		channelID := channels[rng.Intn(len(channels))]
+		switch rng.Intn(16) {
+		case 0, 1, 2, 3, 4:
+			cache.AddMessage(channelID, message())
+		case 5:
+			cache.AddMessages(channelID, []*discordgo.Message{message(), message(), message()})
+		case 6:
+			if m, ok := someMessage(channelID); ok {
+				cache.RemoveMessage(channelID, m.ID)
+			}
+		case 7:
+			if m, ok := someMessage(channelID); ok {
+				edited := *m
+				edited.Author = &discordgo.User{ID: authors[rng.Intn(len(authors)-1)]}
+				cache.UpdateMessage(channelID, &edited)
+			}
+		case 8:
+			cache.EvictOldest(channelID, rng.Intn(3))
+		case 9:
+			if rng.Intn(4) == 0 {
+				cache.ClearChannel(channelID)
+			} else {
+				cache.KeepLast(channelID, rng.Intn(6))
+			}
+		case 10:
+			if rng.Intn(4) == 0 {
+				cache.DeleteChannel(channelID)
+			} else {
+				cache.DrainChannel(channelID)
+			}
+		case 11:
+			cache.ReplaceChannel(channelID, []*discordgo.Message{message(), message(), message(), message()})
+		case 12:
+			cache.SetMaxMessages(3 + rng.Intn(8))
+		case 13:
+			cache.SetChannelMaxMessages(channelID, 2+rng.Intn(8))
+		case 14:
+			cache.RenameChannel(channelID, channels[rng.Intn(len(channels))])
+		case 15:
+			if rng.Intn(2) == 0 {
+				cache.FreezeChannel(channelID)
+			} else {
+				cache.UnfreezeChannel(channelID)
+			}
+		}
+		checkUserIndex(t, cache, limit)
+		if t.Failed() {
+			t.Fatalf("Index diverged after operation %d", op)
+		}
+	}
+	if err := cache.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+}
+
+// checkUserIndex compares the per-user index with the messages actually cached.
+func checkUserIndex(t *testing.T, cache *MessageCache, limit int) {
+	t.Helper()
+	counts := make(map[string]int)
+	evictable := make(map[string]int)
+	live := make(map[*ChannelCache]map[string]bool)
+	for _, cc := range cache.channelCaches() {
+		cc.RLock()
+		ids := make(map[string]bool, cc.size)
+		for i := 0; i < cc.size; i++ {
+			message := cc.at(i).message
+			ids[message.ID] = true
+			if author := messageAuthorID(message); author != "" {
+				counts[author]++
+				if !cc.frozen.Load() {
+					evictable[author]++
+				}
+			}
+		}
+		cc.RUnlock()
+		live[cc] = ids
+	}
+
+	idx := cache.users.Load()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for author, n := range counts {
+		if got := len(idx.refs[author]); got != n {
+			t.Errorf("Author %q: index holds %d messages, cache holds %d", author, got, n)
+		}
+		if n > limit && evictable[author] > 0 {
+			t.Errorf("Author %q holds %d messages, above the cap of %d", author, n, limit)
+		}
+	}
+	for author, refs := range idx.refs {
+		if counts[author] == 0 {
+			t.Errorf("Index holds %d messages of %q, who has none cached", len(refs), author)
+		}
+		for _, ref := range refs {
+			if !live[ref.channel][ref.messageID] {
+				t.Errorf("Index refers to message %s of %q, which is not cached", ref.messageID, author)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package dgocacheler
+
+import (
+	"fmt"
+	"maps"
+)
+
+// Validate checks the internal invariants of every channel: the ring buffer bounds, the byte and priority
+// bookkeeping and the ID map. It returns an error wrapping ErrInvariant describing the first violation found,
+// or nil if the cache is consistent. It is meant for tests and debugging; it locks one channel at a time.
+func (c *MessageCache) Validate() error {
+	for _, cc := range c.channelCaches() {
+		c.rlockChannel(cc)
+		err := cc.validate()
+		cc.RUnlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks the invariants of a single channel. The caller must hold at least the read lock.
+func (cc *ChannelCache) validate() error {
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("%w: channel %s: %s", ErrInvariant, cc.id, fmt.Sprintf(format, args...))
+	}
+	switch {
+	case cc.size < 0 || cc.size > len(cc.buffer):
+		return fail("size %d outside buffer of %d", cc.size, len(cc.buffer))
+	case cc.size > max(cc.maxMessages, 0):
+		return fail("size %d exceeds max messages %d", cc.size, cc.maxMessages)
+	case len(cc.buffer) > 0 && (cc.head < 0 || cc.head >= len(cc.buffer)):
+		return fail("head %d outside buffer of %d", cc.head, len(cc.buffer))
+	}
+	var bytes int64
+	prioritized := 0
+	authors := make(map[string]int)
+	for i := 0; i < cc.size; i++ {
+		entry := cc.at(i)
+		if entry.message == nil {
+			return fail("nil message at position %d", i)
+		}
+		if cc.tracksIDs() && !cc.contains(entry.message.ID) {
+			return fail("message %s missing from the ID map", entry.message.ID)
+		}
+		bytes += int64(entry.size)
+		if entry.priority != 0 {
+			prioritized++
+		}
+		authors[entry.author]++
+	}
+	switch {
+	case cc.messageIDs != nil && len(cc.messageIDs) != cc.size:
+		return fail("ID map holds %d IDs for %d messages", len(cc.messageIDs), cc.size)
+	case bytes != cc.bytes:
+		return fail("byte count %d, entries sum to %d", cc.bytes, bytes)
+	case prioritized != cc.prioritized:
+		return fail("prioritized count %d, entries have %d", cc.prioritized, prioritized)
+	case cc.authorCounts != nil && !maps.Equal(authors, cc.authorCounts):
+		return fail("author counts %v, entries have %v", cc.authorCounts, authors)
+	}
+	return nil
+}
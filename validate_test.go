@@ -0,0 +1,26 @@
+package dgocacheler
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	cache := NewMessageCache(5)
+	cache.AddMessages("channel1", testHistory(8))
+	cache.AddMessageWithPriority("channel1", testHistory(10)[9], 1)
+	if err := cache.Validate(); err != nil {
+		t.Fatalf("Validate returned error for a consistent cache: %v", err)
+	}
+
+	cc := cache.messages["channel1"]
+	cc.bytes++
+	if err := cache.Validate(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Expected ErrInvariant for a drifted byte count, got %v", err)
+	}
+	cc.bytes--
+	cc.untrackID(cc.at(0).message.ID)
+	if err := cache.Validate(); !errors.Is(err, ErrInvariant) {
+		t.Errorf("Expected ErrInvariant for a missing ID, got %v", err)
+	}
+}
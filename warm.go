@@ -0,0 +1,150 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// WarmReport describes the outcome of warming a guild's channels.
+type WarmReport struct {
+	Counts  map[string]int   // Counts maps each warmed channel ID to the number of messages fetched for it
+	Skipped map[string]error // Skipped maps channel IDs the bot may not read to the permission error returned
+	Errors  map[string]error // Errors maps channel IDs whose backfill failed for another reason to the error
+}
+
+// WarmGuild backfills the perChannel newest messages of every text and announcement channel of a guild, using
+// up to concurrency workers. Channels are listed from the session state when the guild is known there, and
+// from the API otherwise. Channels the bot lacks permission to read are recorded in the report's Skipped map
+// instead of failing the warm; other per-channel failures are recorded in Errors and returned as *ChannelError
+// values joined with errors.Join. Cancelling ctx stops channels from being started and returns ctx.Err().
+func (c *MessageCache) WarmGuild(ctx context.Context, s *discordgo.Session, guildID string, perChannel int, concurrency int) (WarmReport, error) {
+	if perChannel <= 0 || concurrency <= 0 {
+		return WarmReport{}, ErrInvalidLimit
+	}
+	channelIDs, err := guildTextChannels(s, guildID)
+	if err != nil {
+		return WarmReport{}, err
+	}
+	return c.warmChannels(ctx, SessionLoader(s), channelIDs, perChannel, make(chan struct{}, concurrency))
+}
+
+// WarmOnReady returns a Ready handler that warms every guild of the session with WarmGuild, perChannel
+// messages per channel. concurrency caps the number of channels backfilled at once across all guilds. Guilds
+// are warmed in the background; their failures are passed to the WithErrorHandler handler.
+//
+//	session.AddHandler(cache.WarmOnReady(ctx, 50, 4))
+func (c *MessageCache) WarmOnReady(ctx context.Context, perChannel int, concurrency int) func(*discordgo.Session, *discordgo.Ready) {
+	sem := make(chan struct{}, max(concurrency, 1))
+	return func(s *discordgo.Session, r *discordgo.Ready) {
+		loader := SessionLoader(s)
+		for _, guild := range r.Guilds {
+			go func() {
+				channelIDs, err := guildTextChannels(s, guild.ID)
+				if err == nil {
+					_, err = c.warmChannels(ctx, loader, channelIDs, perChannel, sem)
+				}
+				if err != nil {
+					c.reportError(err)
+				}
+			}()
+		}
+	}
+}
+
+// warmChannels backfills channels with loader, running at most cap(sem) backfills at once. sem may be shared to
+// cap concurrency across several warms.
+func (c *MessageCache) warmChannels(ctx context.Context, loader Loader, channelIDs []string, perChannel int, sem chan struct{}) (WarmReport, error) {
+	report := WarmReport{Counts: make(map[string]int), Skipped: make(map[string]error), Errors: make(map[string]error)}
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, channelID := range channelIDs {
+		if !acquire(ctx, sem) {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fetched, err := loader(ctx, channelID, perChannel)
+			if err == nil {
+				err = c.mergeMessages(channelID, fetched, "WarmGuild")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				report.Counts[channelID] = len(fetched)
+			case isMissingPermissions(err):
+				report.Skipped[channelID] = err
+			default:
+				report.Errors[channelID] = err
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	return report, joinChannelErrors(report.Errors)
+}
+
+// acquire takes a slot of sem, reporting false without holding one if ctx is done first.
+func acquire(ctx context.Context, sem chan struct{}) bool {
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+	if ctx.Err() != nil {
+		<-sem
+		return false
+	}
+	return true
+}
+
+// guildTextChannels returns the IDs of a guild's text and announcement channels, from the session state when the
+// guild is cached there and from the API otherwise.
+func guildTextChannels(s *discordgo.Session, guildID string) ([]string, error) {
+	var channels []*discordgo.Channel
+	if s.State != nil {
+		if guild, err := s.State.Guild(guildID); err == nil && len(guild.Channels) > 0 {
+			channels = guild.Channels
+		}
+	}
+	if channels == nil {
+		var err error
+		if channels, err = s.GuildChannels(guildID); err != nil {
+			return nil, err
+		}
+	}
+	var ids []string
+	for _, channel := range channels {
+		if channel.Type == discordgo.ChannelTypeGuildText || channel.Type == discordgo.ChannelTypeGuildNews {
+			ids = append(ids, channel.ID)
+		}
+	}
+	return ids, nil
+}
+
+// isMissingPermissions reports whether err is a Discord API error caused by the bot lacking access to a channel.
+func isMissingPermissions(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	if restErr.Message != nil {
+		switch restErr.Message.Code {
+		case discordgo.ErrCodeMissingAccess, discordgo.ErrCodeMissingPermissions:
+			return true
+		}
+	}
+	return restErr.Response != nil && restErr.Response.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,85 @@
+package dgocacheler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestWarmChannels(t *testing.T) {
+	forbidden := &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeMissingAccess}}
+	failure := errors.New("unavailable")
+	var running, peak atomic.Int32
+	loader := func(ctx context.Context, channelID string, limit int) ([]*discordgo.Message, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		switch channelID {
+		case "secret":
+			return nil, forbidden
+		case "broken":
+			return nil, failure
+		}
+		return testHistory(limit), nil
+	}
+	cache := NewMessageCache(10)
+
+	report, err := cache.warmChannels(context.Background(), loader, []string{"a", "b", "secret", "broken", "c"}, 3, make(chan struct{}, 2))
+	var channelErr *ChannelError
+	if !errors.Is(err, failure) || !errors.As(err, &channelErr) || channelErr.ChannelID != "broken" {
+		t.Errorf("Expected the non-permission failure to be returned for its channel, got %v", err)
+	}
+	if len(report.Counts) != 3 || report.Counts["a"] != 3 {
+		t.Errorf("Unexpected counts: %v", report.Counts)
+	}
+	if !errors.Is(report.Skipped["secret"], forbidden) || len(report.Skipped) != 1 {
+		t.Errorf("Expected the forbidden channel to be skipped, got %v", report.Skipped)
+	}
+	if !errors.Is(report.Errors["broken"], failure) {
+		t.Errorf("Expected the failure to be reported, got %v", report.Errors)
+	}
+	if n, _ := cache.MessageCount("b"); n != 3 {
+		t.Errorf("Expected warmed channels to be cached, got %d messages", n)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 concurrent backfills, got %d", peak.Load())
+	}
+}
+
+func TestWarmChannelsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int32
+	loader := func(context.Context, string, int) ([]*discordgo.Message, error) {
+		calls.Add(1)
+		return nil, nil
+	}
+	cache := NewMessageCache(10)
+	if _, err := cache.warmChannels(ctx, loader, []string{"a", "b"}, 3, make(chan struct{}, 1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Expected no backfills after cancellation, got %d", calls.Load())
+	}
+}
+
+func TestGuildTextChannelsFromState(t *testing.T) {
+	s := &discordgo.Session{State: discordgo.NewState()}
+	s.State.GuildAdd(&discordgo.Guild{ID: "g1", Channels: []*discordgo.Channel{
+		{ID: "text", Type: discordgo.ChannelTypeGuildText},
+		{ID: "voice", Type: discordgo.ChannelTypeGuildVoice},
+		{ID: "news", Type: discordgo.ChannelTypeGuildNews},
+	}})
+	ids, err := guildTextChannels(s, "g1")
+	if err != nil || len(ids) != 2 || ids[0] != "text" || ids[1] != "news" {
+		t.Errorf("Expected [text news], got %v (err %v)", ids, err)
+	}
+}
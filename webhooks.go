@@ -0,0 +1,86 @@
+package dgocacheler
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// WebhookPolicy controls how messages sent through webhooks, such as Matrix or IRC bridges, are handled.
+type WebhookPolicy int
+
+const (
+	// WebhookCache caches webhook messages like any other message. This is the default.
+	WebhookCache WebhookPolicy = iota
+	// WebhookSkip drops webhook messages without caching them.
+	WebhookSkip
+	// WebhookRewriteAuthor caches webhook messages but groups them for author-based features under
+	// "webhook:<WebhookID>:<Username>", so users relayed by the same bridge are told apart.
+	WebhookRewriteAuthor
+)
+
+// WithWebhookPolicy sets how webhook messages (those with a WebhookID) are handled.
+func WithWebhookPolicy(policy WebhookPolicy) Option {
+	return func(c *MessageCache) {
+		c.webhookPolicy = policy
+	}
+}
+
+// authorKey returns the key author-based features group a message under. This is the author ID, or the
+// empty string for messages without an author, unless WebhookRewriteAuthor applies.
+func (c *MessageCache) authorKey(msg *discordgo.Message) string {
+	if c.webhookPolicy == WebhookRewriteAuthor && msg.WebhookID != "" {
+		username := ""
+		if msg.Author != nil {
+			username = msg.Author.Username
+		}
+		return "webhook:" + msg.WebhookID + ":" + username
+	}
+	if msg.Author == nil {
+		return ""
+	}
+	return msg.Author.ID
+}
+
+// skipWebhook reports whether a message must be dropped under the webhook policy.
+func (c *MessageCache) skipWebhook(msg *discordgo.Message) bool {
+	return c.webhookPolicy == WebhookSkip && msg.WebhookID != ""
+}
+
+// GetMessagesByAuthor retrieves the messages of a channel by one author, oldest first. The author is matched
+// against the same key AuthorCounts reports. It returns ErrCacheMiss for unknown channels.
+func (c *MessageCache) GetMessagesByAuthor(channelID, authorID string) ([]*discordgo.Message, error) {
+	return c.filterMessages(channelID, func(msg *discordgo.Message) bool {
+		return c.authorKey(msg) == authorID
+	})
+}
+
+// AuthorCount is the number of cached messages by one author.
+type AuthorCount struct {
+	AuthorID string // AuthorID is the author key, as reported by AuthorCounts
+	Messages int    // Messages is the number of cached messages by the author
+}
+
+// TopAuthors returns up to n authors with the most cached messages in a channel, most active first.
+// Ties are ordered by author ID. It returns ErrCacheMiss for unknown channels and ErrInvalidLimit if n is not positive.
+func (c *MessageCache) TopAuthors(channelID string, n int) ([]AuthorCount, error) {
+	if n <= 0 {
+		return nil, ErrInvalidLimit
+	}
+	counts, err := c.AuthorCounts(channelID)
+	if err != nil {
+		return nil, err
+	}
+	top := make([]AuthorCount, 0, len(counts))
+	for authorID, count := range counts {
+		top = append(top, AuthorCount{AuthorID: authorID, Messages: count})
+	}
+	slices.SortFunc(top, func(a, b AuthorCount) int {
+		if c := cmp.Compare(b.Messages, a.Messages); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.AuthorID, b.AuthorID)
+	})
+	return top[:min(n, len(top))], nil
+}
@@ -0,0 +1,88 @@
+package dgocacheler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func webhookMessage(id, webhookID, username string) *discordgo.Message {
+	return &discordgo.Message{
+		ID:        id,
+		WebhookID: webhookID,
+		Author:    &discordgo.User{ID: webhookID, Username: username, Bot: true},
+	}
+}
+
+func webhookFixtures() []*discordgo.Message {
+	// A webhook message without an author, as discordgo sometimes produces.
+	anonymous := &discordgo.Message{ID: "5", WebhookID: "hook1"}
+	return []*discordgo.Message{
+		authoredMessage("1", "alice", "hi"),
+		webhookMessage("2", "hook1", "matrix-bob"),
+		webhookMessage("3", "hook1", "matrix-carol"),
+		webhookMessage("4", "hook1", "matrix-bob"),
+		anonymous,
+	}
+}
+
+func TestWebhookPolicyDefault(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", webhookFixtures())
+
+	counts, _ := cache.AuthorCounts("channel1")
+	if len(counts) != 3 || counts["hook1"] != 3 || counts[""] != 1 {
+		t.Errorf("By default webhook messages are grouped under the webhook author, got %v", counts)
+	}
+}
+
+func TestWebhookPolicySkip(t *testing.T) {
+	cache := NewMessageCache(10, WithWebhookPolicy(WebhookSkip))
+	cache.AddMessages("channel1", webhookFixtures())
+	if msgs, _ := cache.GetMessages("channel1"); fmt.Sprint(messageIDs(msgs)) != "[1]" {
+		t.Errorf("Expected webhook messages to be skipped, got %v", messageIDs(msgs))
+	}
+}
+
+func TestWebhookPolicyRewriteAuthor(t *testing.T) {
+	cache := NewMessageCache(10, WithWebhookPolicy(WebhookRewriteAuthor))
+	cache.AddMessages("channel1", webhookFixtures())
+
+	counts, _ := cache.AuthorCounts("channel1")
+	if len(counts) != 4 || counts["alice"] != 1 || counts["webhook:hook1:matrix-bob"] != 2 ||
+		counts["webhook:hook1:matrix-carol"] != 1 || counts["webhook:hook1:"] != 1 {
+		t.Errorf("Unexpected rewritten counts: %v", counts)
+	}
+
+	msgs, err := cache.GetMessagesByAuthor("channel1", "webhook:hook1:matrix-bob")
+	if err != nil || fmt.Sprint(messageIDs(msgs)) != "[2 4]" {
+		t.Errorf("Unexpected messages by bridge user: %v (err %v)", messageIDs(msgs), err)
+	}
+
+	top, err := cache.TopAuthors("channel1", 2)
+	if err != nil || len(top) != 2 || top[0].AuthorID != "webhook:hook1:matrix-bob" || top[0].Messages != 2 {
+		t.Errorf("Unexpected top authors: %v (err %v)", top, err)
+	}
+}
+
+func TestTopAuthors(t *testing.T) {
+	cache := NewMessageCache(10)
+	cache.AddMessages("channel1", []*discordgo.Message{
+		authoredMessage("1", "bob", "a"),
+		authoredMessage("2", "alice", "b"),
+		authoredMessage("3", "carol", "c"),
+		authoredMessage("4", "carol", "d"),
+	})
+	top, err := cache.TopAuthors("channel1", 5)
+	if err != nil || fmt.Sprint(top) != "[{carol 2} {alice 1} {bob 1}]" {
+		t.Errorf("Unexpected top authors: %v (err %v)", top, err)
+	}
+	if _, err := cache.TopAuthors("channel1", 0); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Expected ErrInvalidLimit, got %v", err)
+	}
+	if _, err := cache.GetMessagesByAuthor("missing", "bob"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+}